@@ -0,0 +1,209 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"strconv"
+	"strings"
+
+	bwo_define "github.com/TriM-Organization/bedrock-world-operator/define"
+
+	wsdefine "github.com/Yeah114/WaterStructure/define"
+	"github.com/Yeah114/blocks"
+)
+
+// autoBoundsDefaultIgnore 自动检测建筑范围时默认忽略的自然方块
+var autoBoundsDefaultIgnore = []string{
+	"stone", "granite", "diorite", "andesite", "deepslate", "tuff", "bedrock",
+	"dirt", "grass_block", "dirt_with_roots", "podzol", "mycelium", "clay",
+	"sand", "sandstone", "gravel", "snow_layer", "ice", "short_grass",
+	"water", "flowing_water", "lava", "flowing_lava",
+	"coal_ore", "iron_ore", "copper_ore", "gold_ore", "deepslate_coal_ore",
+	"netherrack", "soul_sand", "soul_soil", "basalt", "blackstone", "magma",
+	"end_stone",
+}
+
+// autoBoundsOptions 自动检测建筑范围的选项
+type autoBoundsOptions struct {
+	Dimension bwo_define.Dimension
+	Chunks    *[2]bwo_define.ChunkPos // 扫描的区块范围（闭区间），nil 表示扫描维度中的全部区块
+	Ignore    []string                // 忽略的方块名称
+}
+
+// parseBlockNameList 解析逗号分隔的方块名称列表，去掉 minecraft: 前缀
+func parseBlockNameList(s string) []string {
+	var names []string
+	for _, name := range strings.Split(s, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		name = strings.TrimPrefix(name, "minecraft:")
+		if name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// buildIgnoreSet 将方块名称转换为运行时 ID 集合（包含方块的所有状态），空气总是被忽略
+func buildIgnoreSet(names []string) (map[uint32]bool, error) {
+	wanted := make(map[string]bool, len(names))
+	for _, name := range names {
+		wanted[name] = true
+	}
+
+	ignore := map[uint32]bool{blocks.AIR_RUNTIMEID: true}
+	matched := make(map[string]bool)
+	for _, block := range blocks.MC_CURRENT.Blocks() {
+		name := block.ShortName()
+		if wanted[name] {
+			ignore[block.Rtid()] = true
+			matched[name] = true
+		}
+	}
+
+	var unknown []string
+	for _, name := range names {
+		if !matched[name] {
+			unknown = append(unknown, name)
+		}
+	}
+	if len(unknown) > 0 {
+		return nil, fmt.Errorf("未知的方块: %s", strings.Join(unknown, ", "))
+	}
+	return ignore, nil
+}
+
+// detectBuildBounds 扫描区块，计算除空气和忽略方块以外所有方块的最小包围盒
+func detectBuildBounds(worldPath string, opts autoBoundsOptions) (start, end wsdefine.BlockPos, err error) {
+	ignore, err := buildIgnoreSet(opts.Ignore)
+	if err != nil {
+		return start, end, err
+	}
+
+	err = readWorldPath(worldPath, func(worldDir string) error {
+		var chunks []bwo_define.ChunkPos
+		if opts.Chunks != nil {
+			for x := opts.Chunks[0][0]; x <= opts.Chunks[1][0]; x++ {
+				for z := opts.Chunks[0][1]; z <= opts.Chunks[1][1]; z++ {
+					chunks = append(chunks, bwo_define.ChunkPos{x, z})
+				}
+			}
+		} else {
+			db, err := openWorldDB(worldDir)
+			if err != nil {
+				return err
+			}
+			chunks, err = listWorldChunks(db, opts.Dimension)
+			db.Close()
+			if err != nil {
+				return err
+			}
+		}
+		if len(chunks) == 0 {
+			return fmt.Errorf("%s中没有区块", dimensionName(opts.Dimension))
+		}
+
+		bedrockWorld, err := openWorldReadOnly(worldDir)
+		if err != nil {
+			return err
+		}
+		defer bedrockWorld.Close()
+
+		fmt.Printf("正在扫描%s的 %d 个区块...\n", dimensionName(opts.Dimension), len(chunks))
+		found := 0
+		for _, pos := range chunks {
+			c, exists, err := bedrockWorld.LoadChunk(opts.Dimension, pos)
+			if err != nil {
+				return fmt.Errorf("读取区块 %v 失败: %w", pos, err)
+			}
+			if !exists {
+				continue
+			}
+			for index, sub := range c.Sub() {
+				if sub == nil || sub.Empty() {
+					continue
+				}
+				baseY := int32(c.SubY(int16(index)))
+				for x := byte(0); x < 16; x++ {
+					for y := byte(0); y < 16; y++ {
+						for z := byte(0); z < 16; z++ {
+							if ignore[sub.Block(x, y, z, 0)] {
+								continue
+							}
+							p := wsdefine.BlockPos{pos[0]*16 + int32(x), baseY + int32(y), pos[1]*16 + int32(z)}
+							if found == 0 {
+								start, end = p, p
+							} else {
+								start = wsdefine.BlockPos{minInt32(start[0], p[0]), minInt32(start[1], p[1]), minInt32(start[2], p[2])}
+								end = wsdefine.BlockPos{maxInt32(end[0], p[0]), maxInt32(end[1], p[1]), maxInt32(end[2], p[2])}
+							}
+							found++
+						}
+					}
+				}
+			}
+		}
+		if found == 0 {
+			return fmt.Errorf("扫描范围内没有找到建筑方块")
+		}
+		fmt.Printf("找到 %d 个建筑方块\n", found)
+		return nil
+	})
+	return start, end, err
+}
+
+// promptAutoBounds 交互式输入扫描范围和忽略列表，返回检测到的建筑范围
+func promptAutoBounds(worldPath string, dimension bwo_define.Dimension, reader *bufio.Reader) (start, end wsdefine.BlockPos, err error) {
+	opts := autoBoundsOptions{Dimension: dimension, Ignore: autoBoundsDefaultIgnore}
+
+	fmt.Println("请输入扫描的区块范围 (格式: x1 z1 x2 z2，留空扫描全部区块):")
+	fmt.Print("> ")
+	input, err := reader.ReadString('\n')
+	if err != nil {
+		return start, end, fmt.Errorf("读取输入失败: %w", err)
+	}
+	if fields := strings.Fields(input); len(fields) > 0 {
+		if len(fields) != 4 {
+			return start, end, fmt.Errorf("区块范围格式不正确，需要4个整数")
+		}
+		var v [4]int32
+		for i, f := range fields {
+			n, err := strconv.ParseInt(f, 10, 32)
+			if err != nil {
+				return start, end, fmt.Errorf("区块范围必须是整数: %s", f)
+			}
+			v[i] = int32(n)
+		}
+		opts.Chunks = &[2]bwo_define.ChunkPos{
+			{minInt32(v[0], v[2]), minInt32(v[1], v[3])},
+			{maxInt32(v[0], v[2]), maxInt32(v[1], v[3])},
+		}
+	}
+
+	fmt.Println("默认忽略的方块:")
+	fmt.Printf("  %s\n", strings.Join(autoBoundsDefaultIgnore, ", "))
+	fmt.Println("请输入忽略的方块（逗号分隔，留空使用默认，以 + 开头表示在默认列表上追加，输入 none 只忽略空气）:")
+	fmt.Print("> ")
+	input, err = reader.ReadString('\n')
+	if err != nil {
+		return start, end, fmt.Errorf("读取输入失败: %w", err)
+	}
+	input = strings.TrimSpace(input)
+	switch {
+	case input == "":
+	case strings.EqualFold(input, "none"):
+		opts.Ignore = nil
+	case strings.HasPrefix(input, "+"):
+		opts.Ignore = append(append([]string{}, autoBoundsDefaultIgnore...), parseBlockNameList(input[1:])...)
+	default:
+		opts.Ignore = parseBlockNameList(input)
+	}
+
+	start, end, err = detectBuildBounds(worldPath, opts)
+	if err != nil {
+		return start, end, err
+	}
+	fmt.Printf("检测到建筑范围: (%d, %d, %d) ~ (%d, %d, %d)，尺寸 %d × %d × %d\n",
+		start[0], start[1], start[2], end[0], end[1], end[2],
+		end[0]-start[0]+1, end[1]-start[1]+1, end[2]-start[2]+1)
+	return start, end, nil
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	bwo_define "github.com/TriM-Organization/bedrock-world-operator/define"
+	"github.com/TriM-Organization/bedrock-world-operator/world"
+
+	wsdefine "github.com/Yeah114/WaterStructure/define"
+	"github.com/Yeah114/blocks"
+	"github.com/Yeah114/blocks/block_entity"
+)
+
+// javaStructureFormats 方块实体 NBT 使用 Java 版格式的结构格式
+var javaStructureFormats = []string{"Schematic", "SchemV1", "SchemV2", "Litematic", "AxiomBP"}
+
+// isJavaStructureFormat 判断结构格式是否为 Java 版格式
+func isJavaStructureFormat(format string) bool {
+	for _, f := range javaStructureFormats {
+		if strings.EqualFold(f, format) {
+			return true
+		}
+	}
+	return false
+}
+
+// javaBlockOverrides 转换为 Java 版方块实体时得到的 Java 版方块（世界坐标 → 方块字符串）：头颅类型和旋转、
+// 旗帜底色在基岩版中保存在方块实体里，在 Java 版中保存在方块里，导出时覆盖逐方块转换的结果
+type javaBlockOverrides map[[3]int32]string
+
+// translateBlockEntities 将世界主世界 start~end 范围内的方块实体 NBT 在 Java 版和基岩版格式之间转换
+// toBedrock 为 true 时从 Java 版转换为基岩版，否则从基岩版转换为 Java 版，返回转换的方块实体数量，
+// 转换为 Java 版时还返回需要改写的 Java 版方块。
+// javaBlockName 返回源结构中该位置的 Java 版方块名称（决定头颅类型和旗帜底色），没有时为 nil
+func translateBlockEntities(w *world.BedrockWorld, start, end wsdefine.BlockPos, toBedrock bool, javaBlockName func(x, y, z int32) string) (int, javaBlockOverrides, error) {
+	dim := bwo_define.Dimension(bwo_define.DimensionIDOverworld)
+	editor := newChunkEditor(w, dim)
+	region := subChunkRegionOf(start, end)
+	translated := 0
+	var overrides javaBlockOverrides
+	if !toBedrock {
+		overrides = javaBlockOverrides{}
+	}
+	for cx := region.Min[0]; cx <= region.Max[0]; cx++ {
+		for cz := region.Min[2]; cz <= region.Max[2]; cz++ {
+			pos := bwo_define.ChunkPos{cx, cz}
+			list, err := decodeNBTList(w.LoadNBTPayloadOnly(dim, pos))
+			if err != nil {
+				return translated, overrides, fmt.Errorf("读取区块 %v 的方块实体失败: %w", pos, err)
+			}
+			if len(list) == 0 {
+				continue
+			}
+
+			for i, m := range list {
+				x, y, z, ok := nbtBlockPos(m)
+				if !ok || !blockInBox(x, y, z, start, end) {
+					continue
+				}
+				runtimeID, err := editor.block(x, y, z, 0)
+				if err != nil {
+					return translated, overrides, err
+				}
+				block, _ := blocks.RuntimeIDToBlock(runtimeID)
+				if toBedrock {
+					javaName := ""
+					if javaBlockName != nil {
+						javaName = javaBlockName(x, y, z)
+					}
+					list[i] = block_entity.JavaToBedrock(m, block, javaName)
+				} else {
+					exported := ""
+					if block != nil {
+						// 直接查询转换器，不计入转换报告
+						if javaBlock, _, found := blocks.BedrockToJavaConvertor.TryBestSearchByState(block.NameForSearch(), block.StatesForSearch()); found {
+							exported = javaBlock.String()
+						}
+					}
+					var javaBlock string
+					list[i], javaBlock = block_entity.BedrockToJava(m, exported)
+					if javaBlock != exported {
+						overrides[[3]int32{x, y, z}] = javaBlock
+					}
+				}
+				translated++
+			}
+			if err := w.SaveNBT(dim, pos, list); err != nil {
+				return translated, overrides, fmt.Errorf("写入区块 %v 的方块实体失败: %w", pos, err)
+			}
+		}
+	}
+	return translated, overrides, nil
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/Yeah114/blocks"
+	"github.com/Yeah114/blocks/describe"
+)
+
+// resolveBlock 解析方块字符串，无法识别时给出相近的方块名称，状态无效时列出该方块可用的状态
+func resolveBlock(input string) (uint32, error) {
+	runtimeID, err := blocks.LookupBlockStr(input)
+	if err == nil {
+		return runtimeID, nil
+	}
+
+	var unknown *blocks.UnknownBlockError
+	var invalid *blocks.InvalidStateError
+	switch {
+	case errors.As(err, &unknown):
+		if len(unknown.Suggestions) == 0 {
+			return 0, fmt.Errorf("无法识别方块: %s（使用 'block search <关键字>' 查找方块名称）", input)
+		}
+		return 0, fmt.Errorf("无法识别方块: %s，你是不是想输入: %s", input, strings.Join(unknown.Suggestions, ", "))
+	case errors.As(err, &invalid):
+		var reason string
+		switch {
+		case invalid.Err != nil:
+			reason = fmt.Sprintf("无法解析状态 %s: %v", invalid.State, invalid.Err)
+		case invalid.Value != "":
+			reason = fmt.Sprintf("状态 %s 没有值 %s", invalid.Key, invalid.Value)
+		default:
+			reason = fmt.Sprintf("没有状态 %s", invalid.Key)
+		}
+		if len(invalid.ValidStates) == 0 {
+			return 0, fmt.Errorf("方块 %s 的状态无效: %s（该方块没有状态）", invalid.Name, reason)
+		}
+		return 0, fmt.Errorf("方块 %s 的状态无效: %s，可用的状态: %s", invalid.Name, reason, blocks.FormatValidStates(invalid.ValidStates))
+	default:
+		return 0, fmt.Errorf("无法识别方块 %s: %w", input, err)
+	}
+}
+
+// lookupBlock 解析任意形式的方块（基岩版字符串、Java 版字符串、"名称 数据值"、运行时 ID）并输出它的所有表示
+func lookupBlock(input string) error {
+	input = strings.TrimSpace(input)
+	if input == "" {
+		return fmt.Errorf("请输入方块名称或运行时 ID")
+	}
+
+	var runtimeID uint32
+	if id, err := strconv.ParseUint(input, 10, 32); err == nil {
+		runtimeID = uint32(id)
+	} else {
+		id, err := resolveBlock(input)
+		if err != nil {
+			return err
+		}
+		runtimeID = id
+	}
+	block, found := blocks.RuntimeIDToBlock(runtimeID)
+	if !found {
+		return fmt.Errorf("运行时 ID 超出范围: %d（共 %d 种方块状态）", runtimeID, len(blocks.MC_CURRENT.Blocks()))
+	}
+
+	fmt.Printf("输入:       %s\n", input)
+	fmt.Printf("基岩版:     %s\n", block.BedrockString())
+	fmt.Printf("完整名称:   %s\n", block.LongName())
+	fmt.Printf("状态 SNBT:  %s\n", block.States().SNBTString())
+	fmt.Printf("数据值:     %d\n", block.LegacyValue())
+	fmt.Printf("运行时 ID:  %d\n", block.Rtid())
+	if javaBlock, found := blocks.RuntimeIDToJavaBlockStr(runtimeID); found {
+		fmt.Printf("Java 版:    %s\n", javaBlock)
+	} else {
+		fmt.Println("Java 版:    （没有对应的方块）")
+	}
+	if id, exact, found := blocks.RuntimeIDToSchematicID(runtimeID); found {
+		if exact {
+			fmt.Printf("Schematic:  %d:%d\n", id.Block, id.Data)
+		} else {
+			fmt.Printf("Schematic:  %d:%d（近似，部分状态无法保留）\n", id.Block, id.Data)
+		}
+	} else {
+		fmt.Println("Schematic:  （没有对应的 ID）")
+	}
+	if schema, ok := blocks.CurrentSchema().Block(block.ShortName()); ok && len(schema.States) > 0 {
+		fmt.Printf("可用状态（%d 种组合）:\n", len(schema.Variants()))
+		for _, st := range schema.States {
+			fmt.Printf("  %-28s %-6s %s\n", st.Name, propValTypeName(st.Type), strings.Join(st.ValueStrings(), " "))
+		}
+	}
+	return nil
+}
+
+// propValTypeName 方块状态值类型的名称
+func propValTypeName(t describe.PropValType) string {
+	switch t {
+	case describe.PropValTypeUint8:
+		return "bool"
+	case describe.PropValTypeInt32:
+		return "int"
+	default:
+		return "string"
+	}
+}
+
+// searchBlocks 按名称子串（不区分大小写）列出方块，showStates 为 true 时列出每个状态
+func searchBlocks(keyword string, showStates bool) error {
+	keyword = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(keyword), "minecraft:"))
+	if keyword == "" {
+		return fmt.Errorf("请输入搜索关键字")
+	}
+
+	schema := blocks.CurrentSchema()
+	var matched []*blocks.BlockSchema
+	for _, name := range schema.Names() {
+		if strings.Contains(strings.ToLower(name), keyword) {
+			bsc, _ := schema.Block(name)
+			matched = append(matched, bsc)
+		}
+	}
+	if len(matched) == 0 {
+		return fmt.Errorf("没有名称包含 '%s' 的方块", keyword)
+	}
+
+	if !showStates {
+		fmt.Printf("找到 %d 种方块:\n", len(matched))
+	}
+	for _, bsc := range matched {
+		variants := bsc.Variants()
+		if !showStates {
+			fmt.Printf("  %-40s %4d 种状态  运行时 ID %d 起\n", bsc.Name, len(variants), variants[0].Rtid())
+			continue
+		}
+		for _, block := range variants {
+			fmt.Printf("%6d  %s\n", block.Rtid(), block.BedrockString())
+		}
+	}
+	return nil
+}
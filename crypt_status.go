@@ -0,0 +1,125 @@
+package main
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"sort"
+	"strings"
+)
+
+// cryptStatus 检查世界目录或 .mcworld 文件的加密状态（只读，不修改任何文件）
+func cryptStatus(worldPath string) error {
+	info, err := os.Stat(worldPath)
+	if err != nil {
+		return fmt.Errorf("无法访问路径: %w", err)
+	}
+
+	var dbDir string
+	var state dbCryptState
+	var files []dbFileCrypt
+	if info.IsDir() {
+		dbDir = resolveDBDir(worldPath)
+		state, files, err = detectDBCrypt(dbDir)
+	} else {
+		dbDir, state, files, err = detectMCWorldCrypt(worldPath)
+	}
+	if err != nil {
+		return err
+	}
+	if len(files) == 0 {
+		return fmt.Errorf("在 %s 中没有找到数据库文件", dbDir)
+	}
+
+	sort.Slice(files, func(i, j int) bool { return files[i].Name < files[j].Name })
+
+	fmt.Printf("数据库: %s\n", dbDir)
+	fmt.Println()
+	fmt.Printf("%-24s %12s  %s\n", "文件", "大小", "状态")
+	counts := make(map[dbCryptState]int)
+	for _, f := range files {
+		fmt.Printf("%-24s %12d  %s\n", f.Name, f.Size, f.State)
+		counts[f.State]++
+	}
+	fmt.Println()
+	fmt.Printf("已加密: %d  未加密: %d  未知: %d\n",
+		counts[cryptStateEncrypted], counts[cryptStatePlain], counts[cryptStateUnknown])
+	fmt.Printf("总体状态: %s\n", state)
+	if state == cryptStateMixed {
+		fmt.Println("警告: 部分文件已加密、部分未加密，存档可能在加密/解密过程中被中断")
+	}
+	return nil
+}
+
+// detectMCWorldCrypt 直接读取 .mcworld 压缩包中 db 目录的文件头，不解压到磁盘
+func detectMCWorldCrypt(mcworldPath string) (string, dbCryptState, []dbFileCrypt, error) {
+	zr, err := zip.OpenReader(mcworldPath)
+	if err != nil {
+		return "", cryptStateUnknown, nil, fmt.Errorf("无法打开世界文件: %w", err)
+	}
+	defer zr.Close()
+
+	dbDir := mcworldPath + "!/db"
+	var files []dbFileCrypt
+	for _, file := range zr.File {
+		name := strings.ReplaceAll(file.Name, "\\", "/")
+		dir, base := path.Split(name)
+		if file.FileInfo().IsDir() || path.Base(strings.TrimSuffix(dir, "/")) != "db" || !isDBCryptFile(base) {
+			continue
+		}
+		dbDir = mcworldPath + "!/" + strings.TrimSuffix(dir, "/")
+
+		rc, err := file.Open()
+		if err != nil {
+			return "", cryptStateUnknown, nil, fmt.Errorf("读取 %s 失败: %w", name, err)
+		}
+		data, err := readDBStreamSample(rc)
+		rc.Close()
+		if err != nil {
+			return "", cryptStateUnknown, nil, fmt.Errorf("读取 %s 失败: %w", name, err)
+		}
+		size := int64(file.UncompressedSize64)
+		files = append(files, dbFileCrypt{
+			Name:  base,
+			Size:  size,
+			State: detectFileCrypt(base, data, size),
+		})
+	}
+	return dbDir, summarizeDBCrypt(files), files, nil
+}
+
+// readDBStreamSample 从顺序流中读取文件头部和尾部，结果与 readDBFileSample 相同
+func readDBStreamSample(r io.Reader) ([]byte, error) {
+	const headSize = 16
+	head := make([]byte, headSize)
+	n, err := io.ReadFull(r, head)
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		return head[:n], nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// 流式读取剩余部分，只保留最后 8 字节
+	var tail []byte
+	buf := make([]byte, 32*1024)
+	for {
+		n, err := r.Read(buf)
+		if n > 0 {
+			tail = append(tail, buf[:n]...)
+			if len(tail) > 8 {
+				tail = tail[len(tail)-8:]
+			}
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	// 文件总长度不足 headSize+8 时 tail 不足 8 字节，此时拼接结果即为完整内容
+	return append(head, tail...), nil
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/Yeah114/blocks"
+)
+
+// recordConflictsListed 每个文件最多列出的冲突数量
+const recordConflictsListed = 10
+
+// applyRecordFlags 从参数中取出 --records / --java-records / --keep-records 并加载自定义转换记录
+// 这些选项可以放在任何命令（或交互模式）之前或之后，返回去掉这些选项后的参数
+func applyRecordFlags(args []string) ([]string, error) {
+	var records, javaRecords []string
+	overwrite := true
+	rest := make([]string, 0, len(args))
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case "--records", "--java-records":
+			if i+1 >= len(args) {
+				return nil, fmt.Errorf("选项 %s 缺少文件路径", args[i])
+			}
+			if args[i] == "--records" {
+				records = append(records, args[i+1])
+			} else {
+				javaRecords = append(javaRecords, args[i+1])
+			}
+			i++
+		case "--keep-records":
+			overwrite = false
+		default:
+			rest = append(rest, args[i])
+		}
+	}
+
+	for _, path := range records {
+		report, err := blocks.LoadConvertRecordsFile(path, overwrite)
+		if err != nil {
+			return nil, fmt.Errorf("加载转换记录失败: %w", err)
+		}
+		printRecordLoadReport(report)
+	}
+	for _, path := range javaRecords {
+		report, err := blocks.LoadJavaConvertRecordsFile(path, overwrite)
+		if err != nil {
+			return nil, fmt.Errorf("加载 Java 转换记录失败: %w", err)
+		}
+		printRecordLoadReport(report)
+	}
+	return rest, nil
+}
+
+// printRecordLoadReport 输出自定义转换记录的加载结果和冲突
+func printRecordLoadReport(report *blocks.RecordLoadReport) {
+	fmt.Fprintf(os.Stderr, "已加载转换记录: %s（加载 %d 条，冲突 %d，因冲突未加载 %d，跳过 %d）\n",
+		report.Source, report.Loaded, len(report.Conflicts), report.Rejected, len(report.Skipped))
+	for i, c := range report.Conflicts {
+		if i == recordConflictsListed {
+			fmt.Fprintf(os.Stderr, "  ... 还有 %d 个冲突\n", len(report.Conflicts)-recordConflictsListed)
+			break
+		}
+		action := "保留原映射"
+		if c.Overwritten {
+			action = "已覆盖"
+		}
+		fmt.Fprintf(os.Stderr, "  冲突: %s %s  原: %s  新: %s（%s）\n", c.Name, c.State, c.Existing, c.New, action)
+	}
+	for _, s := range report.Skipped {
+		fmt.Fprintf(os.Stderr, "  跳过: %s\n", s)
+	}
+}
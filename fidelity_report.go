@@ -0,0 +1,92 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/Yeah114/blocks"
+)
+
+// fidelityEntriesListed 转换结束时最多列出的有损方块数量
+const fidelityEntriesListed = 15
+
+// fidelityReportFile 写入 --report 文件的转换保真度报告，数量统计的是方块查找次数：
+// 转换器会缓存结果，同一种源方块按调色板或不同 ID 只查找一次，不等于放置的方块数量
+type fidelityReportFile struct {
+	Source   string                  `json:"source"`
+	Target   string                  `json:"target_format"`
+	Exact    int                     `json:"exact_lookups"`
+	Fuzzy    int                     `json:"fuzzy_lookups"`
+	Fallback int                     `json:"fallback_lookups"`
+	Blocks   []*blocks.FidelityEntry `json:"blocks"`
+}
+
+// convertWithFidelityReport 转换结构并统计每种源方块的匹配情况（精确、模糊、找不到），
+// 结束时输出有损转换的方块，reportPath 不为空时把完整报告写为 JSON
+func convertWithFidelityReport(srcPath, targetFormat, destPath string, useFast bool, placement worldPlacement, reportPath string) error {
+	blocks.StartFidelityReport()
+	err := convertStructure(srcPath, targetFormat, destPath, useFast, placement)
+	report := blocks.StopFidelityReport()
+	if err != nil || report == nil {
+		return err
+	}
+
+	printFidelityReport(report)
+	if reportPath == "" {
+		return nil
+	}
+	counts := report.LookupCounts()
+	data, err := json.MarshalIndent(fidelityReportFile{
+		Source:   srcPath,
+		Target:   targetFormat,
+		Exact:    counts[blocks.MatchExact],
+		Fuzzy:    counts[blocks.MatchFuzzy],
+		Fallback: counts[blocks.MatchFallback],
+		Blocks:   report.Entries(),
+	}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("生成转换报告失败: %w", err)
+	}
+	if err := os.WriteFile(reportPath, data, 0644); err != nil {
+		return fmt.Errorf("写入转换报告失败: %w", err)
+	}
+	fmt.Printf("转换报告已保存到: %s\n", reportPath)
+	return nil
+}
+
+// printFidelityReport 输出模糊匹配和找不到对应方块的源方块
+func printFidelityReport(report *blocks.FidelityReport) {
+	counts := report.LookupCounts()
+	if counts[blocks.MatchFuzzy] == 0 && counts[blocks.MatchFallback] == 0 {
+		return
+	}
+	fmt.Printf("方块匹配（按查找次数）: 精确 %d，模糊 %d，找不到 %d\n",
+		counts[blocks.MatchExact], counts[blocks.MatchFuzzy], counts[blocks.MatchFallback])
+
+	listed := 0
+	lossy := 0
+	for _, e := range report.Entries() {
+		if e.Kind == blocks.MatchExact {
+			continue
+		}
+		lossy++
+		if listed == fidelityEntriesListed {
+			continue
+		}
+		listed++
+		target := e.Target
+		if e.ToJava {
+			target = "Java " + target
+		}
+		if e.Kind == blocks.MatchFallback {
+			fmt.Printf("  找不到（查找 %d 次）: %s → %s\n", e.Lookups, e.Source, target)
+		} else {
+			fmt.Printf("  模糊（查找 %d 次）: %s → %s（不同 %d，缺少 %d，多余 %d）\n",
+				e.Lookups, e.Source, target, e.Different, e.Missing, e.Redundant)
+		}
+	}
+	if lossy > listed {
+		fmt.Printf("  ... 还有 %d 种方块（使用 --report <文件> 导出完整报告）\n", lossy-listed)
+	}
+}
@@ -0,0 +1,232 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+
+	bwo_define "github.com/TriM-Organization/bedrock-world-operator/define"
+	world_define "github.com/TriM-Organization/bedrock-world-operator/world/define"
+	"github.com/TriM-Organization/bedrock-world-operator/world/leveldat"
+)
+
+// dimensionSummary 单个维度的统计信息
+type dimensionSummary struct {
+	chunks        map[bwo_define.ChunkPos]struct{}
+	minChunk      bwo_define.ChunkPos
+	maxChunk      bwo_define.ChunkPos
+	subChunks     int
+	minSubChunk   int8
+	maxSubChunk   int8
+	blockEntities int
+	entities      int
+}
+
+// worldSummary 遍历 LevelDB 得到的世界统计信息
+type worldSummary struct {
+	dimensions map[bwo_define.Dimension]*dimensionSummary
+	actors     int      // actorprefix 实体记录数
+	templates  []string // LevelDB 中保存的结构模板名称
+	badRecords int      // 无法解码的方块实体/实体记录数
+}
+
+// dimension 返回维度的统计信息，不存在时创建
+func (s *worldSummary) dimension(dim bwo_define.Dimension) *dimensionSummary {
+	d, ok := s.dimensions[dim]
+	if !ok {
+		d = &dimensionSummary{chunks: make(map[bwo_define.ChunkPos]struct{})}
+		s.dimensions[dim] = d
+	}
+	return d
+}
+
+// addChunk 记录区块并更新区块范围
+func (d *dimensionSummary) addChunk(pos bwo_define.ChunkPos) {
+	if _, ok := d.chunks[pos]; ok {
+		return
+	}
+	if len(d.chunks) == 0 {
+		d.minChunk, d.maxChunk = pos, pos
+	} else {
+		d.minChunk = bwo_define.ChunkPos{minInt32(d.minChunk[0], pos[0]), minInt32(d.minChunk[1], pos[1])}
+		d.maxChunk = bwo_define.ChunkPos{maxInt32(d.maxChunk[0], pos[0]), maxInt32(d.maxChunk[1], pos[1])}
+	}
+	d.chunks[pos] = struct{}{}
+}
+
+// addSubChunk 记录子区块并更新纵向范围
+func (d *dimensionSummary) addSubChunk(index int8) {
+	if d.subChunks == 0 {
+		d.minSubChunk, d.maxSubChunk = index, index
+	} else {
+		if index < d.minSubChunk {
+			d.minSubChunk = index
+		}
+		if index > d.maxSubChunk {
+			d.maxSubChunk = index
+		}
+	}
+	d.subChunks++
+}
+
+// scanWorldSummary 遍历数据库中的所有键，统计各维度的区块、方块实体、实体和结构模板
+func scanWorldSummary(db *worldDB) (*worldSummary, error) {
+	summary := &worldSummary{dimensions: make(map[bwo_define.Dimension]*dimensionSummary)}
+	actorPrefix := []byte(world_define.KeyEntity)
+	templatePrefix := []byte(structureTemplatePrefix)
+
+	err := db.forEach(nil, func(key, value []byte) error {
+		if k, ok := parseChunkKey(key); ok {
+			d := summary.dimension(k.Dimension)
+			switch k.Tag {
+			case world_define.KeySubChunkData:
+				d.addChunk(k.Pos)
+				d.addSubChunk(k.SubChunk)
+			case world_define.KeyVersion, world_define.KeyVersionOld:
+				d.addChunk(k.Pos)
+			case world_define.KeyBlockEntities:
+				list, err := decodeNBTList(value)
+				if err != nil {
+					summary.badRecords++
+				}
+				d.blockEntities += len(list)
+			case world_define.KeyEntities:
+				// 旧版存档直接把实体保存在区块中
+				list, err := decodeNBTList(value)
+				if err != nil {
+					summary.badRecords++
+				}
+				d.entities += len(list)
+			}
+			return nil
+		}
+		if dim, _, ok := parseDigpKey(key); ok {
+			// 新版存档的 digp 记录区块内实体的 ID 列表，每个 ID 8 字节
+			summary.dimension(dim).entities += len(value) / 8
+			return nil
+		}
+		switch {
+		case bytes.HasPrefix(key, actorPrefix):
+			summary.actors++
+		case bytes.HasPrefix(key, templatePrefix):
+			summary.templates = append(summary.templates, string(key[len(templatePrefix):]))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("遍历数据库失败: %w", err)
+	}
+	sort.Strings(summary.templates)
+	return summary, nil
+}
+
+// inspectWorld 检查世界目录或 .mcworld 文件并输出概要信息（只读）
+func inspectWorld(worldPath string) error {
+	return readWorldPath(worldPath, func(worldDir string) error {
+		// 先遍历数据库（同时检查是否加密），再直接读取 level.dat
+		db, err := openWorldDB(worldDir)
+		if err != nil {
+			return err
+		}
+		summary, err := scanWorldSummary(db)
+		db.Close()
+		if err != nil {
+			return err
+		}
+
+		ldat, err := readLevelDat(worldDir)
+		if err != nil {
+			return err
+		}
+
+		structureFiles, err := listWorldStructures(worldDir)
+		if err != nil {
+			return fmt.Errorf("读取结构列表失败: %w", err)
+		}
+
+		printWorldSummary(worldPath, ldat, summary, structureFiles)
+		return nil
+	})
+}
+
+// formatGameVersion 将 [1 21 0 3 0] 格式化为 1.21.0.3
+func formatGameVersion(version []int32) string {
+	for len(version) > 3 && version[len(version)-1] == 0 {
+		version = version[:len(version)-1]
+	}
+	if len(version) == 0 {
+		return "未知"
+	}
+	parts := make([]string, len(version))
+	for i, v := range version {
+		parts[i] = fmt.Sprint(v)
+	}
+	return strings.Join(parts, ".")
+}
+
+// printWorldSummary 输出世界概要信息
+func printWorldSummary(worldPath string, ldat *leveldat.Data, summary *worldSummary, structureFiles []string) {
+	line := "=" + strings.Repeat("=", 70) + "="
+
+	fmt.Println(line)
+	fmt.Printf("世界: %s\n", worldPath)
+	fmt.Println(line)
+	fmt.Printf("名称: %s\n", ldat.LevelName)
+	fmt.Printf("游戏版本: %s（最低兼容 %s）\n",
+		formatGameVersion(ldat.LastOpenedWithVersion), formatGameVersion(ldat.MinimumCompatibleClientVersion))
+	fmt.Printf("存储版本: %d  网络协议版本: %d\n", ldat.StorageVersion, ldat.NetworkVersion)
+	fmt.Printf("出生点: %d %d %d\n", ldat.SpawnX, ldat.SpawnY, ldat.SpawnZ)
+	fmt.Println()
+
+	var present []string
+	totalChunks := 0
+	for _, dim := range worldDimensions {
+		if d, ok := summary.dimensions[dim]; ok && len(d.chunks) > 0 {
+			present = append(present, dimensionName(dim))
+			totalChunks += len(d.chunks)
+		}
+	}
+	if len(present) == 0 {
+		fmt.Println("维度: 无（世界中没有区块数据）")
+	} else {
+		fmt.Printf("维度: %s\n", strings.Join(present, "、"))
+	}
+	fmt.Printf("区块总数: %d\n", totalChunks)
+
+	for _, dim := range worldDimensions {
+		d, ok := summary.dimensions[dim]
+		if !ok {
+			continue
+		}
+		fmt.Println()
+		fmt.Printf("[%s]\n", dimensionName(dim))
+		fmt.Printf("  区块数: %d  子区块数: %d\n", len(d.chunks), d.subChunks)
+		if len(d.chunks) > 0 {
+			fmt.Printf("  区块范围: (%d, %d) ~ (%d, %d)\n", d.minChunk[0], d.minChunk[1], d.maxChunk[0], d.maxChunk[1])
+			fmt.Printf("  方块范围: X %d ~ %d, Z %d ~ %d",
+				d.minChunk[0]*16, d.maxChunk[0]*16+15, d.minChunk[1]*16, d.maxChunk[1]*16+15)
+			if d.subChunks > 0 {
+				fmt.Printf(", Y %d ~ %d", int32(d.minSubChunk)*16, int32(d.maxSubChunk)*16+15)
+			}
+			fmt.Println()
+		}
+		fmt.Printf("  方块实体: %d  实体: %d\n", d.blockEntities, d.entities)
+	}
+	fmt.Println()
+	fmt.Printf("实体记录 (actorprefix): %d\n", summary.actors)
+	if summary.badRecords > 0 {
+		fmt.Printf("警告: %d 条方块实体/实体记录无法解码\n", summary.badRecords)
+	}
+
+	fmt.Println()
+	fmt.Printf("结构模板 (LevelDB): %d\n", len(summary.templates))
+	for _, name := range summary.templates {
+		fmt.Printf("  - %s\n", name)
+	}
+	fmt.Printf("结构文件 (structures/): %d\n", len(structureFiles))
+	for _, name := range structureFiles {
+		fmt.Printf("  - %s\n", name)
+	}
+	fmt.Println(line)
+}
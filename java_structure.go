@@ -0,0 +1,522 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math/bits"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+
+	bwo_define "github.com/TriM-Organization/bedrock-world-operator/define"
+	"github.com/TriM-Organization/bedrock-world-operator/world"
+	"github.com/sandertv/gophertunnel/minecraft/nbt"
+
+	wsdefine "github.com/Yeah114/WaterStructure/define"
+	"github.com/Yeah114/blocks"
+)
+
+// javaPaletteFormats 以 Java 版方块字符串调色板保存方块的结构格式，导出时按周围方块补全 Java 版方块状态
+var javaPaletteFormats = []string{"SchemV1", "SchemV2", "Litematic"}
+
+// isJavaPaletteFormat 判断结构格式是否以 Java 版方块字符串调色板保存方块
+func isJavaPaletteFormat(format string) bool {
+	for _, f := range javaPaletteFormats {
+		if strings.EqualFold(f, format) {
+			return true
+		}
+	}
+	return false
+}
+
+// javaBlockState Java 版方块名称和方块状态
+type javaBlockState struct {
+	Name       string
+	Properties map[string]string
+}
+
+// javaBlockRegion 结构中的一个方块区域，方块按 YZX 顺序保存调色板下标
+type javaBlockRegion struct {
+	origin  [3]int // 区域最小角落相对于整个结构最小角落的坐标
+	size    [3]int
+	palette []javaBlockState
+	blocks  []int
+	store   func(r *javaBlockRegion) // 把调色板和方块写回文件的 NBT
+}
+
+func (r *javaBlockRegion) index(x, y, z int) int {
+	return (y*r.size[2]+z)*r.size[0] + x
+}
+
+// javaStructureFile 解码后的 Sponge schematic（SchemV1/SchemV2）或 Litematic 文件，均为 gzip 压缩的大端序 NBT
+type javaStructureFile struct {
+	rootName string
+	root     map[string]any
+	regions  []*javaBlockRegion
+}
+
+// readJavaStructureFile 读取 Java 版调色板格式的结构文件
+func readJavaStructureFile(r io.Reader, format string) (*javaStructureFile, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("解压 %s 失败: %w", format, err)
+	}
+	defer gz.Close()
+	data, err := io.ReadAll(gz)
+	if err != nil {
+		return nil, fmt.Errorf("解压 %s 失败: %w", format, err)
+	}
+	var root map[string]any
+	if err := nbt.UnmarshalEncoding(data, &root, nbt.BigEndian); err != nil {
+		return nil, fmt.Errorf("解析 %s 失败: %w", format, err)
+	}
+	f := &javaStructureFile{rootName: nbtRootName(data), root: root}
+	if strings.EqualFold(format, "Litematic") {
+		err = f.readLitematicRegions()
+	} else {
+		err = f.readSpongeBlocks()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("解析 %s 失败: %w", format, err)
+	}
+	return f, nil
+}
+
+// readSpongeBlocks 读取 Sponge schematic 的 Palette（方块字符串 → 下标）和 varint 编码的 BlockData
+func (f *javaStructureFile) readSpongeBlocks() error {
+	width, _ := f.root["Width"].(int16)
+	height, _ := f.root["Height"].(int16)
+	length, _ := f.root["Length"].(int16)
+	r := &javaBlockRegion{size: [3]int{int(uint16(width)), int(uint16(height)), int(uint16(length))}}
+	paletteNBT, _ := f.root["Palette"].(map[string]any)
+	r.palette = make([]javaBlockState, len(paletteNBT))
+	for state, v := range paletteNBT {
+		i, ok := v.(int32)
+		if !ok || i < 0 || int(i) >= len(r.palette) {
+			return fmt.Errorf("调色板中 %s 的下标无效", state)
+		}
+		r.palette[i] = parseJavaBlockState(state)
+	}
+	data := nbtByteArray(f.root["BlockData"])
+	r.blocks = make([]int, 0, r.size[0]*r.size[1]*r.size[2])
+	for value, shift, i := 0, 0, 0; i < len(data); i++ {
+		value |= int(data[i]&0x7F) << shift
+		if data[i]&0x80 != 0 {
+			shift += 7
+			continue
+		}
+		if value >= len(r.palette) {
+			return fmt.Errorf("BlockData 中的调色板下标 %d 超出调色板大小 %d", value, len(r.palette))
+		}
+		r.blocks = append(r.blocks, value)
+		value, shift = 0, 0
+	}
+	if len(r.blocks) != r.size[0]*r.size[1]*r.size[2] {
+		return fmt.Errorf("BlockData 的方块数量 %d 与尺寸 %dx%dx%d 不符", len(r.blocks), r.size[0], r.size[1], r.size[2])
+	}
+	r.store = func(r *javaBlockRegion) {
+		palette := make(map[string]any, len(r.palette))
+		for i, state := range r.palette {
+			palette[blocks.JavaBlockString(state.Name, state.Properties)] = int32(i)
+		}
+		var data []byte
+		for _, v := range r.blocks {
+			for v >= 0x80 {
+				data = append(data, byte(v)|0x80)
+				v >>= 7
+			}
+			data = append(data, byte(v))
+		}
+		f.root["Palette"] = palette
+		f.root["PaletteMax"] = int32(len(r.palette))
+		f.root["BlockData"] = byteArrayNBT(data)
+	}
+	f.regions = []*javaBlockRegion{r}
+	return nil
+}
+
+// readLitematicRegions 读取 Litematic 各区域的 BlockStatePalette 和按位打包的 BlockStates，
+// 区域尺寸为负数时表示区域从 Position 向负方向延伸
+func (f *javaStructureFile) readLitematicRegions() error {
+	regionsNBT, _ := f.root["Regions"].(map[string]any)
+	names := make([]string, 0, len(regionsNBT))
+	for name := range regionsNBT {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var minCorner [3]int
+	var corners [][3]int
+	for i, name := range names {
+		regionNBT, _ := regionsNBT[name].(map[string]any)
+		pos, size := nbtVec3(regionNBT["Position"]), nbtVec3(regionNBT["Size"])
+		r := &javaBlockRegion{}
+		var corner [3]int
+		for axis := 0; axis < 3; axis++ {
+			corner[axis] = pos[axis]
+			r.size[axis] = size[axis]
+			if size[axis] < 0 {
+				corner[axis] = pos[axis] + size[axis] + 1
+				r.size[axis] = -size[axis]
+			}
+			if i == 0 || corner[axis] < minCorner[axis] {
+				minCorner[axis] = corner[axis]
+			}
+		}
+		corners = append(corners, corner)
+
+		paletteNBT, _ := regionNBT["BlockStatePalette"].([]any)
+		r.palette = make([]javaBlockState, 0, len(paletteNBT))
+		for _, entry := range paletteNBT {
+			m, _ := entry.(map[string]any)
+			name, _ := m["Name"].(string)
+			state := javaBlockState{Name: name, Properties: map[string]string{}}
+			props, _ := m["Properties"].(map[string]any)
+			for k, v := range props {
+				if s, ok := v.(string); ok {
+					state.Properties[k] = s
+				}
+			}
+			r.palette = append(r.palette, state)
+		}
+		if len(r.palette) == 0 {
+			return fmt.Errorf("区域 %s 没有调色板", name)
+		}
+		volume := r.size[0] * r.size[1] * r.size[2]
+		longs := nbtLongArray(regionNBT["BlockStates"])
+		bitsPerBlock := litematicBits(len(r.palette))
+		if len(longs)*64 < volume*bitsPerBlock {
+			return fmt.Errorf("区域 %s 的 BlockStates 长度不足", name)
+		}
+		r.blocks = make([]int, volume)
+		for i := range r.blocks {
+			v := litematicValue(longs, i, bitsPerBlock)
+			if v >= len(r.palette) {
+				return fmt.Errorf("区域 %s 的调色板下标 %d 超出调色板大小 %d", name, v, len(r.palette))
+			}
+			r.blocks[i] = v
+		}
+		r.store = func(r *javaBlockRegion) {
+			palette := make([]any, len(r.palette))
+			for i, state := range r.palette {
+				entry := map[string]any{"Name": state.Name}
+				if len(state.Properties) > 0 {
+					props := make(map[string]any, len(state.Properties))
+					for k, v := range state.Properties {
+						props[k] = v
+					}
+					entry["Properties"] = props
+				}
+				palette[i] = entry
+			}
+			bitsPerBlock := litematicBits(len(r.palette))
+			longs := make([]int64, (len(r.blocks)*bitsPerBlock+63)/64)
+			for i, v := range r.blocks {
+				setLitematicValue(longs, i, bitsPerBlock, v)
+			}
+			regionNBT["BlockStatePalette"] = palette
+			regionNBT["BlockStates"] = longArrayNBT(longs)
+		}
+		f.regions = append(f.regions, r)
+	}
+	for i, r := range f.regions {
+		for axis := 0; axis < 3; axis++ {
+			r.origin[axis] = corners[i][axis] - minCorner[axis]
+		}
+	}
+	return nil
+}
+
+// blockAt 返回结构内坐标（相对于最小角落）的 Java 版方块
+func (f *javaStructureFile) blockAt(x, y, z int) (javaBlockState, bool) {
+	for _, r := range f.regions {
+		lx, ly, lz := x-r.origin[0], y-r.origin[1], z-r.origin[2]
+		if lx < 0 || ly < 0 || lz < 0 || lx >= r.size[0] || ly >= r.size[1] || lz >= r.size[2] {
+			continue
+		}
+		return r.palette[r.blocks[r.index(lx, ly, lz)]], true
+	}
+	return javaBlockState{}, false
+}
+
+// write 把修改后的方块写回 NBT，重新压缩写入文件，覆盖原内容
+func (f *javaStructureFile) write(out *os.File) error {
+	for _, r := range f.regions {
+		r.store(r)
+	}
+	data, err := nbt.MarshalEncoding(f.root, nbt.BigEndian)
+	if err != nil {
+		return fmt.Errorf("编码结构失败: %w", err)
+	}
+	return writeGzipNBT(out, withNBTRootName(data, f.rootName))
+}
+
+// javaBlockNamesOf 读取 Java 版调色板格式的源结构，返回按世界坐标查询源方块名称的函数，start 是结构最小角落
+// 在世界中的坐标。源格式不以方块字符串调色板保存方块时返回 nil
+func javaBlockNamesOf(src *os.File, format string, start wsdefine.BlockPos) (func(x, y, z int32) string, error) {
+	if !isJavaPaletteFormat(format) {
+		return nil, nil
+	}
+	if _, err := src.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	f, err := readJavaStructureFile(src, format)
+	if err != nil {
+		return nil, err
+	}
+	return func(x, y, z int32) string {
+		state, _ := f.blockAt(int(x-start.X()), int(y-start.Y()), int(z-start.Z()))
+		return state.Name
+	}, nil
+}
+
+// applyJavaContext 用 ContextJavaConvertor 重新生成导出的 Java 版结构中的方块：
+// 含水、门的另一半、栅栏/玻璃板/铁栏杆/红石线的连接、楼梯形状等 Java 版状态在基岩版中保存在第二层或相邻方块中，
+// 结构导出器逐个方块转换时会丢失。javaBlocks 中的方块（头颅、旗帜）直接使用。返回状态被补全的方块数量
+func applyJavaContext(out *os.File, format string, w *world.BedrockWorld, start, end wsdefine.BlockPos, javaBlocks javaBlockOverrides) (int, error) {
+	if _, err := out.Seek(0, io.SeekStart); err != nil {
+		return 0, err
+	}
+	f, err := readJavaStructureFile(out, format)
+	if err != nil {
+		return 0, err
+	}
+
+	editor := newChunkEditor(w, bwo_define.DimensionIDOverworld)
+	var readErr error
+	convertor := blocks.NewContextJavaConvertor(blocks.BlockAccessorFunc(func(x, y, z int32, layer uint8) uint32 {
+		runtimeID, err := editor.block(x, y, z, layer)
+		if err != nil && readErr == nil {
+			readErr = err
+		}
+		return runtimeID
+	}))
+	origin := [3]int32{minInt32(start.X(), end.X()), minInt32(start.Y(), end.Y()), minInt32(start.Z(), end.Z())}
+
+	changed := 0
+	for _, r := range f.regions {
+		palette := []javaBlockState{}
+		paletteIndex := map[string]int{}
+		blockIndices := make([]int, len(r.blocks))
+		for y := 0; y < r.size[1]; y++ {
+			for z := 0; z < r.size[2]; z++ {
+				for x := 0; x < r.size[0]; x++ {
+					i := r.index(x, y, z)
+					state := r.palette[r.blocks[i]]
+					exported := blocks.JavaBlockString(state.Name, state.Properties)
+					wx, wy, wz := origin[0]+int32(r.origin[0]+x), origin[1]+int32(r.origin[1]+y), origin[2]+int32(r.origin[2]+z)
+					if javaBlock, ok := javaBlocks[[3]int32{wx, wy, wz}]; ok {
+						state = parseJavaBlockState(javaBlock)
+					} else if name, props, found := convertor.JavaBlock(wx, wy, wz); found {
+						state = javaBlockState{Name: name, Properties: props}
+					}
+					if !strings.Contains(state.Name, ":") {
+						state.Name = "minecraft:" + state.Name
+					}
+					key := blocks.JavaBlockString(state.Name, state.Properties)
+					if key != exported {
+						changed++
+					}
+					index, ok := paletteIndex[key]
+					if !ok {
+						index = len(palette)
+						palette = append(palette, state)
+						paletteIndex[key] = index
+					}
+					blockIndices[i] = index
+				}
+			}
+		}
+		if readErr != nil {
+			return 0, readErr
+		}
+		r.palette, r.blocks = palette, blockIndices
+	}
+	if changed == 0 {
+		return 0, nil
+	}
+	return changed, f.write(out)
+}
+
+// parseJavaBlockState 解析 name[key=value,...] 格式的 Java 版方块字符串
+func parseJavaBlockState(s string) javaBlockState {
+	state := javaBlockState{Name: s, Properties: map[string]string{}}
+	name, props, ok := strings.Cut(s, "[")
+	if !ok {
+		return state
+	}
+	state.Name = name
+	for _, kv := range strings.Split(strings.TrimSuffix(props, "]"), ",") {
+		if k, v, ok := strings.Cut(kv, "="); ok {
+			state.Properties[strings.TrimSpace(k)] = strings.TrimSpace(v)
+		}
+	}
+	return state
+}
+
+// litematicBits Litematic 每个方块占用的位数，至少为 2
+func litematicBits(paletteSize int) int {
+	if n := bits.Len(uint(paletteSize - 1)); n > 2 {
+		return n
+	}
+	return 2
+}
+
+// litematicValue 读取第 i 个方块的调色板下标，Litematic 的数值可以跨越两个 long
+func litematicValue(longs []int64, i, bitsPerBlock int) int {
+	bit := i * bitsPerBlock
+	start, offset := bit>>6, uint(bit&63)
+	mask := uint64(1)<<uint(bitsPerBlock) - 1
+	v := uint64(longs[start]) >> offset
+	if end := (bit + bitsPerBlock - 1) >> 6; end != start {
+		v |= uint64(longs[end]) << (64 - offset)
+	}
+	return int(v & mask)
+}
+
+// setLitematicValue 写入第 i 个方块的调色板下标，longs 中对应的位必须为 0
+func setLitematicValue(longs []int64, i, bitsPerBlock, value int) {
+	bit := i * bitsPerBlock
+	start, offset := bit>>6, uint(bit&63)
+	longs[start] |= int64(uint64(value) << offset)
+	if end := (bit + bitsPerBlock - 1) >> 6; end != start {
+		longs[end] |= int64(uint64(value) >> (64 - offset))
+	}
+}
+
+// nbtVec3 读取 {x, y, z} 形式的 NBT 坐标
+func nbtVec3(v any) [3]int {
+	m, _ := v.(map[string]any)
+	var vec [3]int
+	for i, key := range []string{"x", "y", "z"} {
+		if n, ok := m[key].(int32); ok {
+			vec[i] = int(n)
+		}
+	}
+	return vec
+}
+
+// writeGzipNBT 把 NBT 压缩后写入文件，覆盖原内容
+func writeGzipNBT(f *os.File, data []byte) error {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	if _, err := gz.Write(data); err != nil {
+		return err
+	}
+	if err := gz.Close(); err != nil {
+		return err
+	}
+	if err := f.Truncate(0); err != nil {
+		return err
+	}
+	if _, err := f.WriteAt(buf.Bytes(), 0); err != nil {
+		return fmt.Errorf("写入输出文件失败: %w", err)
+	}
+	return nil
+}
+
+// nbtRootName 返回大端序 NBT 根复合标签的名称，WorldEdit 读取 schematic 时要求根标签名为 "Schematic"
+func nbtRootName(data []byte) string {
+	if len(data) < 3 || data[0] != 0x0A {
+		return ""
+	}
+	n := int(binary.BigEndian.Uint16(data[1:]))
+	if len(data) < 3+n {
+		return ""
+	}
+	return string(data[3 : 3+n])
+}
+
+// withNBTRootName 给编码后的大端序 NBT 设置根标签名称（nbt.MarshalEncoding 总是写入空名称）
+func withNBTRootName(data []byte, name string) []byte {
+	if name == "" || len(data) < 3 || data[0] != 0x0A || data[1] != 0 || data[2] != 0 {
+		return data
+	}
+	out := make([]byte, 0, len(data)+len(name))
+	out = append(out, 0x0A)
+	out = binary.BigEndian.AppendUint16(out, uint16(len(name)))
+	out = append(out, name...)
+	return append(out, data[3:]...)
+}
+
+// nbtByteArray 返回 NBT TAG_Byte_Array 的内容（解码结果是定长数组），不是字节数组时返回 nil
+func nbtByteArray(v any) []byte {
+	if b, ok := v.([]byte); ok {
+		return b
+	}
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Array || rv.Type().Elem().Kind() != reflect.Uint8 {
+		return nil
+	}
+	b := make([]byte, rv.Len())
+	reflect.Copy(reflect.ValueOf(b), rv)
+	return b
+}
+
+// byteArrayNBT 把字节切片转换为编码为 TAG_Byte_Array 的定长数组（切片会被编码为 TAG_List）
+func byteArrayNBT(b []byte) any {
+	v := reflect.New(reflect.ArrayOf(len(b), reflect.TypeOf(byte(0)))).Elem()
+	reflect.Copy(v, reflect.ValueOf(b))
+	return v.Interface()
+}
+
+// nbtLongArray 返回大端序 NBT TAG_Long_Array 的内容（解码结果是定长数组），不是长整数数组时返回 nil
+func nbtLongArray(v any) []int64 {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Array || rv.Type().Elem().Kind() != reflect.Int64 {
+		return nil
+	}
+	l := make([]int64, rv.Len())
+	reflect.Copy(reflect.ValueOf(l), rv)
+	return repairBigEndianLongs(l)
+}
+
+// repairBigEndianLongs 还原 gophertunnel 解码大端序 TAG_Long_Array 的结果：它按 4 字节步长
+// 而不是 8 字节翻转字节序，这里按相反顺序重做同样的交换（每次交换都是自身的逆）得到原始字节再按大端序读取
+func repairBigEndianLongs(l []int64) []int64 {
+	b := make([]byte, len(l)*8)
+	for i, v := range l {
+		binary.LittleEndian.PutUint64(b[i*8:], uint64(v))
+	}
+	for i := len(l) - 1; i >= 0; i-- {
+		off := i * 4
+		b[off], b[off+7] = b[off+7], b[off]
+		b[off+1], b[off+6] = b[off+6], b[off+1]
+		b[off+2], b[off+5] = b[off+5], b[off+2]
+		b[off+3], b[off+4] = b[off+4], b[off+3]
+	}
+	for i := range l {
+		l[i] = int64(binary.BigEndian.Uint64(b[i*8:]))
+	}
+	return l
+}
+
+// longArrayNBT 把 int64 切片转换为编码为 TAG_Long_Array 的定长数组
+func longArrayNBT(l []int64) any {
+	v := reflect.New(reflect.ArrayOf(len(l), reflect.TypeOf(int64(0)))).Elem()
+	reflect.Copy(v, reflect.ValueOf(l))
+	return v.Interface()
+}
+
+// finishStructureExport 从世界 start~end 导出结构后按目标格式补全输出文件中的方块：
+// schematic 改写 12 位方块 ID，Java 版调色板格式按周围方块补全 Java 版方块状态，
+// javaBlocks 是转换方块实体时得到的 Java 版方块
+func finishStructureExport(targetFormat string, out *os.File, w *world.BedrockWorld, start, end wsdefine.BlockPos, schematicIDs *blocks.SchematicIDMapping, javaBlocks javaBlockOverrides) error {
+	switch {
+	case targetFormat == schematicFormat:
+		return finishSchematicExport(out, w, start, end, schematicIDs, javaBlocks)
+	case isJavaPaletteFormat(targetFormat):
+		changed, err := applyJavaContext(out, targetFormat, w, start, end, javaBlocks)
+		if err != nil {
+			return fmt.Errorf("补全 Java 版方块状态失败: %w", err)
+		}
+		if changed > 0 {
+			fmt.Printf("已按周围方块补全 %d 个方块的 Java 版状态\n", changed)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/sandertv/gophertunnel/minecraft/nbt"
+)
+
+// writeTestNBTFile 把 root 编码为带根标签名称的 gzip 大端序 NBT 文件
+func writeTestNBTFile(t *testing.T, root map[string]any, rootName string) *os.File {
+	t.Helper()
+	data, err := nbt.MarshalEncoding(root, nbt.BigEndian)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	gz.Write(withNBTRootName(data, rootName))
+	gz.Close()
+	f, err := os.Create(filepath.Join(t.TempDir(), "test.nbt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { f.Close() })
+	if _, err := f.Write(buf.Bytes()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.Seek(0, 0); err != nil {
+		t.Fatal(err)
+	}
+	return f
+}
+
+func TestParseJavaBlockState(t *testing.T) {
+	got := parseJavaBlockState("minecraft:oak_stairs[facing=east, half=top]")
+	want := javaBlockState{Name: "minecraft:oak_stairs", Properties: map[string]string{"facing": "east", "half": "top"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("得到 %+v，应为 %+v", got, want)
+	}
+	if got := parseJavaBlockState("minecraft:stone"); got.Name != "minecraft:stone" || len(got.Properties) != 0 {
+		t.Errorf("得到 %+v", got)
+	}
+}
+
+func TestLitematicBits(t *testing.T) {
+	for size, want := range map[int]int{1: 2, 2: 2, 4: 2, 5: 3, 16: 4, 17: 5, 300: 9} {
+		if got := litematicBits(size); got != want {
+			t.Errorf("litematicBits(%d) = %d，应为 %d", size, got, want)
+		}
+	}
+}
+
+func TestLitematicValue(t *testing.T) {
+	// 5 位时第 12 个方块跨越两个 long
+	const bitsPerBlock, count = 5, 40
+	longs := make([]int64, (count*bitsPerBlock+63)/64)
+	for i := 0; i < count; i++ {
+		setLitematicValue(longs, i, bitsPerBlock, (i*7)%32)
+	}
+	for i := 0; i < count; i++ {
+		if got := litematicValue(longs, i, bitsPerBlock); got != (i*7)%32 {
+			t.Errorf("第 %d 个方块为 %d，应为 %d", i, got, (i*7)%32)
+		}
+	}
+}
+
+func TestLongArrayBigEndian(t *testing.T) {
+	longs := []int64{0x0102030405060708, -2, 0, 0x7fffffff00000001}
+	data, err := nbt.MarshalEncoding(map[string]any{"l": longArrayNBT(longs)}, nbt.BigEndian)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var root map[string]any
+	if err := nbt.UnmarshalEncoding(data, &root, nbt.BigEndian); err != nil {
+		t.Fatal(err)
+	}
+	if got := nbtLongArray(root["l"]); !reflect.DeepEqual(got, longs) {
+		t.Errorf("读回 %x，应为 %x", got, longs)
+	}
+}
+
+func TestSpongeRoundTrip(t *testing.T) {
+	// 2 × 1 × 1，调色板下标 200 需要两个字节的 varint
+	palette := map[string]any{"minecraft:air": int32(0)}
+	for i := 1; i < 200; i++ {
+		palette[fmt.Sprintf("minecraft:test_%d", i)] = int32(i)
+	}
+	palette["minecraft:chest[facing=north,type=single,waterlogged=false]"] = int32(200)
+	palette["minecraft:oak_log[axis=x]"] = int32(201)
+	f := writeTestNBTFile(t, map[string]any{
+		"Version": int32(2), "Width": int16(2), "Height": int16(1), "Length": int16(1),
+		"Palette": palette, "PaletteMax": int32(len(palette)),
+		"BlockData": byteArrayNBT([]byte{0xC8, 0x01, 0xC9, 0x01}),
+	}, "Schematic")
+
+	s, err := readJavaStructureFile(f, "SchemV2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	chest, ok := s.blockAt(0, 0, 0)
+	if !ok || chest.Name != "minecraft:chest" || chest.Properties["facing"] != "north" {
+		t.Fatalf("(0, 0, 0) 为 %+v", chest)
+	}
+	if log, _ := s.blockAt(1, 0, 0); log.Properties["axis"] != "x" {
+		t.Fatalf("(1, 0, 0) 为 %+v", log)
+	}
+	if _, ok := s.blockAt(2, 0, 0); ok {
+		t.Error("结构外的坐标不应有方块")
+	}
+
+	s.regions[0].palette[200].Properties["facing"] = "west"
+	if _, err := f.Seek(0, 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.write(f); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.Seek(0, 0); err != nil {
+		t.Fatal(err)
+	}
+	again, err := readJavaStructureFile(f, "SchemV2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again.rootName != "Schematic" {
+		t.Errorf("根标签名称为 %q", again.rootName)
+	}
+	if chest, _ := again.blockAt(0, 0, 0); chest.Properties["facing"] != "west" {
+		t.Errorf("写回后 (0, 0, 0) 为 %+v", chest)
+	}
+}
+
+func TestLitematicRegions(t *testing.T) {
+	vec := func(x, y, z int32) map[string]any { return map[string]any{"x": x, "y": y, "z": z} }
+	stone := map[string]any{"Name": "minecraft:stone"}
+	air := map[string]any{"Name": "minecraft:air"}
+	longs := make([]int64, 1)
+	// 区域 b 尺寸为负，从 (1, 0, 0) 向 x 负方向延伸两格，两格都是石头
+	setLitematicValue(longs, 0, 2, 1)
+	setLitematicValue(longs, 1, 2, 1)
+	f := writeTestNBTFile(t, map[string]any{
+		"MinecraftDataVersion": int32(3465),
+		"Regions": map[string]any{
+			"a": map[string]any{
+				"Position": vec(2, 0, 0), "Size": vec(1, 1, 1),
+				"BlockStatePalette": []any{air, map[string]any{"Name": "minecraft:oak_log", "Properties": map[string]any{"axis": "z"}}},
+				"BlockStates":       longArrayNBT([]int64{1}),
+			},
+			"b": map[string]any{
+				"Position": vec(1, 0, 0), "Size": vec(-2, 1, 1),
+				"BlockStatePalette": []any{air, stone},
+				"BlockStates":       longArrayNBT(longs),
+			},
+		},
+	}, "")
+
+	l, err := readJavaStructureFile(f, "Litematic")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for x, want := range []string{"minecraft:stone", "minecraft:stone", "minecraft:oak_log"} {
+		if got, ok := l.blockAt(x, 0, 0); !ok || got.Name != want {
+			t.Errorf("(%d, 0, 0) 为 %+v，应为 %s", x, got, want)
+		}
+	}
+	if log, _ := l.blockAt(2, 0, 0); log.Properties["axis"] != "z" {
+		t.Errorf("原木的方块状态为 %v", log.Properties)
+	}
+}
@@ -0,0 +1,296 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/TriM-Organization/bedrock-world-operator/world/leveldat"
+
+	wsdefine "github.com/Yeah114/WaterStructure/define"
+)
+
+// level.dat 中 Generator 的取值
+const levelGeneratorFlat = 2
+
+// levelDefaultFlatLayers 超平坦世界的默认方块层（从下到上）
+var levelDefaultFlatLayers = "bedrock,2*dirt,grass_block"
+
+// levelGameModes 游戏模式名称
+var levelGameModes = map[string]int32{
+	"survival": 0, "s": 0, "0": 0, "生存": 0,
+	"creative": 1, "c": 1, "1": 1, "创造": 1,
+	"adventure": 2, "a": 2, "2": 2, "冒险": 2,
+}
+
+// levelTimes 时间名称（游戏刻）
+var levelTimes = map[string]int64{
+	"day":      1000,
+	"noon":     6000,
+	"sunset":   12000,
+	"night":    13000,
+	"midnight": 18000,
+	"sunrise":  23000,
+}
+
+// levelGameRules 可以通过 --gamerule 设置的游戏规则（level.dat 中的字段名）
+var levelGameRules = []string{
+	"commandblockoutput", "commandblocksenabled", "dodaylightcycle", "doentitydrops",
+	"dofiretick", "doimmediaterespawn", "doinsomnia", "dolimitedcrafting", "domobloot",
+	"domobspawning", "dotiledrops", "doweathercycle", "drowningdamage", "falldamage",
+	"firedamage", "freezedamage", "functioncommandlimit", "keepinventory",
+	"maxcommandchainlength", "mobgriefing", "naturalregeneration",
+	"playerssleepingpercentage", "projectilescanbreakblocks", "pvp", "randomtickspeed",
+	"recipesunlock", "respawnblocksexplode", "sendcommandfeedback", "showbordereffect",
+	"showcoordinates", "showdaysplayed", "showdeathmessages", "showrecipemessages",
+	"spawnradius", "tntexplodes", "tntexplosiondropdecay",
+}
+
+// levelSettings 生成 MCWorld 时写入 level.dat 的设置，未设置的项保持默认
+type levelSettings struct {
+	GameMode    *int32             // 游戏模式: 0 生存 1 创造 2 冒险
+	Cheats      *bool              // 是否允许作弊
+	FlatLayers  string             // 超平坦方块层（如 bedrock,2*dirt,grass_block），空表示不修改生成器
+	Spawn       *wsdefine.BlockPos // 出生点，nil 表示自动放在建筑旁边
+	Time        *int64             // 时间（游戏刻）
+	LockTime    bool               // 锁定时间（关闭昼夜循环）
+	LockWeather bool               // 锁定为晴天（关闭天气循环）
+	GameRules   map[string]string  // 游戏规则
+}
+
+// parseOption 解析单个 level.dat 选项，返回消耗的参数个数（0 表示不是 level.dat 选项）
+func (s *levelSettings) parseOption(options []string, i int) (int, error) {
+	value := func() (string, error) {
+		if i+1 >= len(options) {
+			return "", fmt.Errorf("选项 %s 缺少参数", options[i])
+		}
+		return options[i+1], nil
+	}
+
+	switch options[i] {
+	case "--gamemode":
+		name, err := value()
+		if err != nil {
+			return 0, err
+		}
+		mode, ok := levelGameModes[strings.ToLower(name)]
+		if !ok {
+			return 0, fmt.Errorf("未知的游戏模式: %s（可选: survival, creative, adventure）", name)
+		}
+		s.GameMode = &mode
+		return 2, nil
+	case "--cheats", "--no-cheats":
+		cheats := options[i] == "--cheats"
+		s.Cheats = &cheats
+	case "--flat":
+		s.FlatLayers = levelDefaultFlatLayers
+	case "--flat-layers":
+		layers, err := value()
+		if err != nil {
+			return 0, err
+		}
+		if _, err := parseFlatWorldLayers(layers); err != nil {
+			return 0, err
+		}
+		s.FlatLayers = layers
+		return 2, nil
+	case "--spawn":
+		v, err := value()
+		if err != nil {
+			return 0, err
+		}
+		pos, err := parseBlockPos(v)
+		if err != nil {
+			return 0, err
+		}
+		s.Spawn = &pos
+		return 2, nil
+	case "--time":
+		v, err := value()
+		if err != nil {
+			return 0, err
+		}
+		t, ok := levelTimes[strings.ToLower(v)]
+		if !ok {
+			if t, err = strconv.ParseInt(v, 10, 64); err != nil || t < 0 {
+				return 0, fmt.Errorf("未知的时间: %s（可选: day, noon, sunset, night, midnight, sunrise 或游戏刻）", v)
+			}
+		}
+		s.Time = &t
+		return 2, nil
+	case "--lock-time":
+		s.LockTime = true
+	case "--lock-weather":
+		s.LockWeather = true
+	case "--gamerule":
+		v, err := value()
+		if err != nil {
+			return 0, err
+		}
+		name, ruleValue, ok := strings.Cut(v, "=")
+		if !ok {
+			return 0, fmt.Errorf("游戏规则格式不正确，需要 名称=值: %s", v)
+		}
+		if s.GameRules == nil {
+			s.GameRules = make(map[string]string)
+		}
+		s.GameRules[strings.ToLower(strings.TrimSpace(name))] = strings.TrimSpace(ruleValue)
+		return 2, nil
+	default:
+		return 0, nil
+	}
+	return 1, nil
+}
+
+// apply 将设置写入 level.dat，start/end 为建筑范围（用于自动放置出生点）
+func (s *levelSettings) apply(ldat *leveldat.Data, start, end wsdefine.BlockPos) error {
+	if s.GameMode != nil {
+		ldat.GameType = *s.GameMode
+	}
+	if s.Cheats != nil {
+		ldat.CheatsEnabled = *s.Cheats
+		ldat.CommandsEnabled = *s.Cheats
+	}
+	if s.FlatLayers != "" {
+		layers, err := parseFlatWorldLayers(s.FlatLayers)
+		if err != nil {
+			return err
+		}
+		if ldat.FlatWorldLayers, err = flatWorldLayersJSON(layers); err != nil {
+			return err
+		}
+		ldat.Generator = levelGeneratorFlat
+	}
+
+	// 默认出生在建筑 X 负方向一侧，Z 居中，与建筑底部同高
+	spawn := wsdefine.BlockPos{start.X() - 3, start.Y(), (start.Z() + end.Z()) / 2}
+	if s.Spawn != nil {
+		spawn = *s.Spawn
+	}
+	ldat.SpawnX, ldat.SpawnY, ldat.SpawnZ = spawn.X(), spawn.Y(), spawn.Z()
+
+	if s.Time != nil {
+		ldat.Time = *s.Time
+	}
+	if s.LockTime {
+		ldat.DoDayLightCycle = false
+	}
+	if s.LockWeather {
+		ldat.DoWeatherCycle = false
+		ldat.RainLevel, ldat.RainTime = 0, 0
+		ldat.LightningLevel, ldat.LightningTime = 0, 0
+	}
+
+	names := make([]string, 0, len(s.GameRules))
+	for name := range s.GameRules {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if err := setLevelGameRule(ldat, name, s.GameRules[name]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// setLevelGameRule 按 nbt 字段名设置 level.dat 中的游戏规则
+func setLevelGameRule(ldat *leveldat.Data, name, value string) error {
+	known := false
+	for _, rule := range levelGameRules {
+		if rule == name {
+			known = true
+			break
+		}
+	}
+	if !known {
+		return fmt.Errorf("未知的游戏规则: %s", name)
+	}
+
+	v := reflect.ValueOf(ldat).Elem()
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		if t.Field(i).Tag.Get("nbt") != name {
+			continue
+		}
+		field := v.Field(i)
+		switch field.Kind() {
+		case reflect.Bool:
+			b, err := strconv.ParseBool(value)
+			if err != nil {
+				return fmt.Errorf("游戏规则 %s 需要 true/false: %s", name, value)
+			}
+			field.SetBool(b)
+		case reflect.Int32:
+			n, err := strconv.ParseInt(value, 10, 32)
+			if err != nil {
+				return fmt.Errorf("游戏规则 %s 需要整数: %s", name, value)
+			}
+			field.SetInt(n)
+		default:
+			return fmt.Errorf("不支持的游戏规则类型: %s", name)
+		}
+		return nil
+	}
+	return fmt.Errorf("level.dat 中没有游戏规则: %s", name)
+}
+
+// flatWorldLayer 超平坦世界的一个方块层
+type flatWorldLayer struct {
+	BlockName string `json:"block_name"`
+	Count     int    `json:"count"`
+}
+
+// parseFlatWorldLayers 解析 "bedrock,2*dirt,grass_block" 格式的超平坦方块层（从下到上）
+func parseFlatWorldLayers(layers string) ([]flatWorldLayer, error) {
+	var result []flatWorldLayer
+	for _, layer := range strings.Split(layers, ",") {
+		layer = strings.TrimSpace(layer)
+		if layer == "" {
+			continue
+		}
+		count := 1
+		if n, name, ok := strings.Cut(layer, "*"); ok {
+			c, err := strconv.Atoi(strings.TrimSpace(n))
+			if err != nil || c <= 0 {
+				return nil, fmt.Errorf("方块层数量必须是正整数: %s", layer)
+			}
+			count, layer = c, strings.TrimSpace(name)
+		}
+		if !strings.Contains(layer, ":") {
+			layer = "minecraft:" + layer
+		}
+		result = append(result, flatWorldLayer{BlockName: layer, Count: count})
+	}
+	if len(result) == 0 {
+		return nil, fmt.Errorf("超平坦方块层不能为空")
+	}
+	return result, nil
+}
+
+// flatWorldHeight 返回超平坦方块层的总厚度
+func flatWorldHeight(layers []flatWorldLayer) int32 {
+	var height int32
+	for _, layer := range layers {
+		height += int32(layer.Count)
+	}
+	return height
+}
+
+// flatWorldLayersJSON 生成 level.dat 中 FlatWorldLayers 的 JSON
+func flatWorldLayersJSON(layers []flatWorldLayer) (string, error) {
+	data, err := json.Marshal(map[string]any{
+		"biome_id":          1,
+		"block_layers":      layers,
+		"encoding_version":  6,
+		"structure_options": nil,
+		"world_version":     "version.post_1_18",
+	})
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
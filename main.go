@@ -18,20 +18,10 @@ import (
 	bwo_define "github.com/TriM-Organization/bedrock-world-operator/define"
 	"github.com/TriM-Organization/bedrock-world-operator/world"
 	"github.com/disintegration/imaging"
-	"github.com/mholt/archiver/v3"
-	"image/color"
-	"image/draw"
-	"image/png"
-	"sort"
-
-	"golang.org/x/image/font"
-	"golang.org/x/image/font/basicfont"
-	"golang.org/x/image/math/fixed"
 
 	"github.com/Yeah114/blocks"
 	wsdefine "github.com/Yeah114/WaterStructure/define"
 	wsmapart "github.com/Yeah114/WaterStructure/utils/map_art"
-	wsnetease "github.com/Yeah114/WaterStructure/utils/netease_world"
 	wsstructure "github.com/Yeah114/WaterStructure/structure"
 )
 
@@ -42,11 +32,21 @@ func isCommand(arg string) bool {
 	commands := []string{
 		"convert", "c",
 		"mapart", "m",
+		"wall", "w",
+		"paste",
 		"encrypt", "e",
 		"decrypt", "d",
+		"crypt-status",
+		"pack",
+		"validate",
+		"inspect", "i",
+		"players",
 		"list", "l",
 		"parse", "p",
 		"quota", "q",
+		"roundtrip",
+		"palette",
+		"block", "b",
 		"help", "h", "-h", "--help",
 	}
 	for _, cmd := range commands {
@@ -121,12 +121,14 @@ func handleDirectory(dirPath string) {
 			choice, err := reader.ReadString('\n')
 			if err != nil {
 				fmt.Fprintf(os.Stderr, "读取输入失败: %v\n", err)
+				cleanupMCWorldSession()
 				os.Exit(1)
 			}
 			choice = strings.TrimSpace(choice)
 
 			if choice == "q" || choice == "Q" {
 				fmt.Println("退出")
+				cleanupMCWorldSession()
 				os.Exit(0)
 			}
 
@@ -160,6 +162,7 @@ func handleDirectory(dirPath string) {
 			// 图片文件
 			fmt.Println("图片文件需要指定世界文件，请使用命令方式:")
 			fmt.Printf("  %s mapart %s <世界文件/目录> [选项]\n", os.Args[0], selectedFile)
+			fmt.Printf("  %s wall %s <世界文件/目录> [选项]\n", os.Args[0], selectedFile)
 			selectedFile = ""
 			continue
 		} else {
@@ -232,9 +235,10 @@ func showStructureFileOptionsLoop(filePath string, reader *bufio.Reader) bool {
 	fmt.Println("2. 解析文件（生成报告图片）")
 	fmt.Println("3. 计算额度")
 	fmt.Println("4. 文件优化")
-	fmt.Println("5. 切换文件")
-	fmt.Println("6. 退出")
-	fmt.Print("请选择 (1-6): ")
+	fmt.Println("5. 粘贴到已有世界")
+	fmt.Println("6. 切换文件")
+	fmt.Println("7. 退出")
+	fmt.Print("请选择 (1-7): ")
 
 	choice, err := reader.ReadString('\n')
 	if err != nil {
@@ -269,7 +273,52 @@ func showStructureFileOptionsLoop(filePath string, reader *bufio.Reader) bool {
 			useFast = true
 		}
 
-		if err := convertStructure(filePath, targetFormat, outputPath, useFast); err != nil {
+		// 只有读取或生成 MCWorld 时维度才有意义
+		placement := worldPlacement{Dimension: bwo_define.DimensionIDOverworld}
+		ext := strings.ToLower(filepath.Ext(filePath))
+		if targetFormat == wsstructure.NameMCWorld || ext == ".mcworld" || ext == ".zip" {
+			fmt.Print("请输入维度 (overworld/nether/end，默认overworld): ")
+			dimensionInput, _ := reader.ReadString('\n')
+			if placement.Dimension, err = parseDimension(dimensionInput); err != nil {
+				fmt.Fprintf(os.Stderr, "错误: %v\n", err)
+				return true
+			}
+		}
+		if targetFormat == wsstructure.NameMCWorld {
+			fmt.Print("请输入放置坐标 x,y,z（留空放在维度底部，输入 offset 按源结构偏移放置）: ")
+			originInput, _ := reader.ReadString('\n')
+			switch originInput = strings.TrimSpace(originInput); {
+			case originInput == "":
+			case strings.EqualFold(originInput, "offset"):
+				placement.UseOffset = true
+			default:
+				origin, err := parseBlockPos(originInput)
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "错误: %v\n", err)
+					return true
+				}
+				placement.Origin = &origin
+			}
+
+			fmt.Println("请输入 level.dat 选项（留空跳过，格式: --gamemode creative --cheats --flat --time noon --lock-time --lock-weather --gamerule keepinventory=true）:")
+			fmt.Print("> ")
+			levelInput, _ := reader.ReadString('\n')
+			levelOptions := strings.Fields(levelInput)
+			for i := 0; i < len(levelOptions); i++ {
+				consumed, err := placement.Level.parseOption(levelOptions, i)
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "错误: %v\n", err)
+					return true
+				}
+				if consumed == 0 {
+					fmt.Fprintf(os.Stderr, "错误: 未知的选项: %s\n", levelOptions[i])
+					return true
+				}
+				i += consumed - 1
+			}
+		}
+
+		if err := convertWithFidelityReport(filePath, targetFormat, outputPath, useFast, placement, ""); err != nil {
 			fmt.Fprintf(os.Stderr, "转换失败: %v\n", err)
 		} else {
 			fmt.Println("✓ 转换完成！")
@@ -298,12 +347,18 @@ func showStructureFileOptionsLoop(filePath string, reader *bufio.Reader) bool {
 		return true // 继续当前文件
 
 	case "5":
+		// 粘贴到已有世界
+		handlePasteStructure(filePath, reader)
+		return true // 继续当前文件
+
+	case "6":
 		// 切换文件
 		return false
 
-	case "6":
+	case "7":
 		// 退出
 		fmt.Println("退出")
+		cleanupMCWorldSession()
 		os.Exit(0)
 
 	default:
@@ -325,10 +380,15 @@ func showMCWorldFileOptionsLoop(filePath string, reader *bufio.Reader) bool {
 	fmt.Println("检测到MCWorld文件，请选择功能：")
 	fmt.Println("1. 加密")
 	fmt.Println("2. 解密")
-	fmt.Println("3. 导出结构方块保存的结构")
-	fmt.Println("4. 切换文件")
-	fmt.Println("5. 退出")
-	fmt.Print("请选择 (1-5): ")
+	fmt.Println("3. 查看加密状态")
+	fmt.Println("4. 查看世界信息")
+	fmt.Println("5. 导出结构方块保存的结构")
+	fmt.Println("6. 按坐标导出区域")
+	fmt.Println("7. 导出玩家和实体数据 (JSON)")
+	fmt.Println("8. 检查能否导入游戏")
+	fmt.Println("9. 切换文件")
+	fmt.Println("10. 退出")
+	fmt.Print("请选择 (1-10): ")
 
 	choice, err := reader.ReadString('\n')
 	if err != nil {
@@ -348,7 +408,13 @@ func showMCWorldFileOptionsLoop(filePath string, reader *bufio.Reader) bool {
 		}
 		outputPath = strings.TrimSpace(outputPath)
 
-		if err := neteaseCrypt(filePath, outputPath, true); err != nil {
+		key, err := promptNeteaseKey(reader)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "加密失败: %v\n", err)
+			return true
+		}
+
+		if err := neteaseCrypt(filePath, outputPath, true, key); err != nil {
 			fmt.Fprintf(os.Stderr, "加密失败: %v\n", err)
 		} else {
 			fmt.Println("✓ 加密完成！")
@@ -365,7 +431,13 @@ func showMCWorldFileOptionsLoop(filePath string, reader *bufio.Reader) bool {
 		}
 		outputPath = strings.TrimSpace(outputPath)
 
-		if err := neteaseCrypt(filePath, outputPath, false); err != nil {
+		key, err := promptNeteaseKey(reader)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "解密失败: %v\n", err)
+			return true
+		}
+
+		if err := neteaseCrypt(filePath, outputPath, false, key); err != nil {
 			fmt.Fprintf(os.Stderr, "解密失败: %v\n", err)
 		} else {
 			fmt.Println("✓ 解密完成！")
@@ -373,17 +445,57 @@ func showMCWorldFileOptionsLoop(filePath string, reader *bufio.Reader) bool {
 		return true // 继续当前文件
 
 	case "3":
+		// 查看加密状态
+		if err := cryptStatus(filePath); err != nil {
+			fmt.Fprintf(os.Stderr, "检测失败: %v\n", err)
+		}
+		return true // 继续当前文件
+
+	case "4":
+		// 查看世界信息
+		if err := inspectWorld(filePath); err != nil {
+			fmt.Fprintf(os.Stderr, "检查失败: %v\n", err)
+		}
+		return true // 继续当前文件
+
+	case "5":
 		// 导出结构方块保存的结构
 		handleMCWorldStructureExport(filePath, reader)
 		return true // 继续当前文件
 
-	case "4":
+	case "6":
+		// 按坐标导出区域
+		handleMCWorldExport(filePath, reader)
+		return true // 继续当前文件
+
+	case "7":
+		// 导出玩家和实体数据
+		fmt.Print("请输入输出文件路径（留空自动生成）: ")
+		outputPath, err := reader.ReadString('\n')
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "读取输入失败: %v\n", err)
+			return true
+		}
+		if err := exportWorldAudit(filePath, strings.TrimSpace(outputPath), true); err != nil {
+			fmt.Fprintf(os.Stderr, "导出失败: %v\n", err)
+		}
+		return true // 继续当前文件
+
+	case "8":
+		// 检查能否导入游戏
+		if err := validateMCWorld(filePath); err != nil {
+			fmt.Fprintf(os.Stderr, "检查失败: %v\n", err)
+		}
+		return true // 继续当前文件
+
+	case "9":
 		// 切换文件
 		return false
 
-	case "5":
+	case "10":
 		// 退出
 		fmt.Println("退出")
+		cleanupMCWorldSession()
 		os.Exit(0)
 
 	default:
@@ -394,6 +506,14 @@ func showMCWorldFileOptionsLoop(filePath string, reader *bufio.Reader) bool {
 }
 
 func main() {
+	// 自定义转换记录对所有命令和交互模式生效
+	args, err := applyRecordFlags(os.Args)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "错误: %v\n", err)
+		os.Exit(1)
+	}
+	os.Args = args
+
 	// 如果提供了命令行参数，使用命令模式
 	if len(os.Args) >= 2 {
 		firstArg := os.Args[1]
@@ -414,22 +534,88 @@ func handleCommandMode(command string) {
 	case "convert", "c":
 		if len(os.Args) < 4 {
 			fmt.Fprintf(os.Stderr, "错误: 转换命令需要输入文件和目标格式\n")
-			fmt.Fprintf(os.Stderr, "用法: %s convert <输入文件> <目标格式> [输出文件] [--fast]\n", os.Args[0])
+			fmt.Fprintf(os.Stderr, "用法: %s convert <输入文件> <目标格式> [输出文件] [--fast] [--dimension <维度>] [--origin <x,y,z> | --use-offset] [--report <文件.json>] [--palette <调色板>]\n", os.Args[0])
 			fmt.Fprintf(os.Stderr, "      --fast: 使用快速模式（多线程，适合大文件）\n")
+			fmt.Fprintf(os.Stderr, "      --dimension: 从 MCWorld 读取或写入 MCWorld 时使用的维度: overworld(默认), nether, end\n")
+			fmt.Fprintf(os.Stderr, "      --origin: 写入 MCWorld 时结构最小角落的方块坐标（默认维度底部 0,最低高度,0，不需要按子区块对齐）\n")
+			fmt.Fprintf(os.Stderr, "      --use-offset: 写入 MCWorld 时按源结构记录的偏移放置\n")
+			fmt.Fprintf(os.Stderr, "      --report: 把方块匹配情况（精确、模糊、找不到）的完整报告写为 JSON\n")
+			fmt.Fprintf(os.Stderr, "      --palette <调色板>: 输出 MCStructure/MCWorld 时使用的方块调色板（默认内置的 nemc）\n")
+			fmt.Fprintf(os.Stderr, "      --palette-dir <目录>: 额外方块调色板所在的目录（默认读取环境变量 %s）\n", paletteDirEnv)
+			fmt.Fprintf(os.Stderr, "      生成 MCWorld 的 level.dat 选项:\n")
+			fmt.Fprintf(os.Stderr, "        --gamemode <survival|creative|adventure>  --cheats / --no-cheats\n")
+			fmt.Fprintf(os.Stderr, "        --flat  --flat-layers <bedrock,2*dirt,grass_block>  超平坦世界（默认放在地面上）\n")
+			fmt.Fprintf(os.Stderr, "        --spawn <x,y,z>  出生点（默认自动放在建筑旁边）\n")
+			fmt.Fprintf(os.Stderr, "        --time <day|noon|night|midnight|游戏刻>  --lock-time  --lock-weather\n")
+			fmt.Fprintf(os.Stderr, "        --gamerule <名称=值>  游戏规则，可重复，如 --gamerule keepinventory=true\n")
 			os.Exit(1)
 		}
 		inputPath := os.Args[2]
 		targetFormat := os.Args[3]
-		var outputPath string
+		var outputPath, reportPath, paletteName, paletteDir string
 		useFast := false
+		placement := worldPlacement{Dimension: bwo_define.DimensionIDOverworld}
 		for i := 4; i < len(os.Args); i++ {
-			if os.Args[i] == "--fast" {
+			// 生成 MCWorld 的 level.dat 选项
+			consumed, err := placement.Level.parseOption(os.Args, i)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "错误: %v\n", err)
+				os.Exit(1)
+			}
+			if consumed > 0 {
+				i += consumed - 1
+				continue
+			}
+
+			switch {
+			case os.Args[i] == "--fast":
 				useFast = true
-			} else if outputPath == "" {
+			case os.Args[i] == "--use-offset":
+				placement.UseOffset = true
+			case os.Args[i] == "--dimension" || os.Args[i] == "--dim" || os.Args[i] == "--origin" || os.Args[i] == "--report" ||
+				os.Args[i] == "--palette" || os.Args[i] == "--palette-dir":
+				if i+1 >= len(os.Args) {
+					fmt.Fprintf(os.Stderr, "错误: 选项 %s 缺少参数\n", os.Args[i])
+					os.Exit(1)
+				}
+				var err error
+				if os.Args[i] == "--report" {
+					reportPath = os.Args[i+1]
+				} else if os.Args[i] == "--palette" {
+					paletteName = os.Args[i+1]
+				} else if os.Args[i] == "--palette-dir" {
+					paletteDir = os.Args[i+1]
+				} else if os.Args[i] == "--origin" {
+					var origin wsdefine.BlockPos
+					origin, err = parseBlockPos(os.Args[i+1])
+					placement.Origin = &origin
+				} else {
+					placement.Dimension, err = parseDimension(os.Args[i+1])
+				}
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "错误: %v\n", err)
+					os.Exit(1)
+				}
+				i++
+			case outputPath == "":
 				outputPath = os.Args[i]
 			}
 		}
-		if err := convertStructure(inputPath, targetFormat, outputPath, useFast); err != nil {
+		if paletteName != "" {
+			if err := loadUserPalettes(paletteDir); err != nil {
+				fmt.Fprintf(os.Stderr, "错误: %v\n", err)
+				os.Exit(1)
+			}
+			p, err := findPalette(paletteName)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "错误: %v\n", err)
+				os.Exit(1)
+			}
+			if p.Name() != blocks.NEMC_PALETTE_NAME {
+				placement.Palette = p
+			}
+		}
+		if err := convertWithFidelityReport(inputPath, targetFormat, outputPath, useFast, placement, reportPath); err != nil {
 			fmt.Fprintf(os.Stderr, "转换失败: %v\n", err)
 			os.Exit(1)
 		}
@@ -448,6 +634,14 @@ func handleCommandMode(command string) {
 			fmt.Fprintf(os.Stderr, "  --2d              强制2D模式（平面）\n")
 			fmt.Fprintf(os.Stderr, "  --no-ref          禁用参考列\n")
 			fmt.Fprintf(os.Stderr, "  --max3d <高度>    最大3D高度（默认0，无限制）\n")
+			fmt.Fprintf(os.Stderr, "图片预处理:\n")
+			fmt.Fprintf(os.Stderr, "  --crop <模式>     裁剪模式: none(默认), fit(等比缩放并填充背景), fill(拉伸), crop(居中裁剪)\n")
+			fmt.Fprintf(os.Stderr, "  --filter <滤镜>   重采样滤镜: nearest, box, linear, catmullrom, lanczos(默认) 等\n")
+			fmt.Fprintf(os.Stderr, "  --bg <RRGGBB>     fit 模式的背景色（默认FFFFFF）\n")
+			fmt.Fprintf(os.Stderr, "  --brightness <值> 亮度调整（-100~100）\n")
+			fmt.Fprintf(os.Stderr, "  --contrast <值>   对比度调整（-100~100）\n")
+			fmt.Fprintf(os.Stderr, "  --saturation <值> 饱和度调整（-100~500）\n")
+			fmt.Fprintf(os.Stderr, "  --sharpen <值>    锐化强度（0~10）\n")
 			os.Exit(1)
 		}
 		imagePath := os.Args[2]
@@ -472,18 +666,78 @@ func handleCommandMode(command string) {
 		}
 		fmt.Println("✓ 地图画转换完成！")
 
+	case "wall", "w":
+		if len(os.Args) < 4 {
+			fmt.Fprintf(os.Stderr, "错误: 像素画墙命令需要图片文件和世界文件\n")
+			fmt.Fprintf(os.Stderr, "用法: %s wall <图片文件> <世界文件/目录> [输出文件] [选项]\n", os.Args[0])
+			fmt.Fprintf(os.Stderr, "选项:\n")
+			fmt.Fprintf(os.Stderr, "  --x/--y/--z <坐标> 墙面左下角方块坐标（默认 0 -60 0）\n")
+			fmt.Fprintf(os.Stderr, "  --facing <朝向>   观看方向: south(默认，XY平面), north(XY平面), east(ZY平面), west(ZY平面)\n")
+			fmt.Fprintf(os.Stderr, "  --width <宽度>    墙面宽度（方块，默认按图片尺寸）\n")
+			fmt.Fprintf(os.Stderr, "  --height <高度>   墙面高度（方块，只指定一边时保持比例）\n")
+			fmt.Fprintf(os.Stderr, "  --palette <组>    方块组，逗号分隔: wool, concrete, terracotta, concrete_powder, misc, all\n")
+			fmt.Fprintf(os.Stderr, "                    （默认 wool,concrete,terracotta,misc）\n")
+			fmt.Fprintf(os.Stderr, "  --dither          启用抖动\n")
+			fmt.Fprintf(os.Stderr, "  --dimension <维度> 写入的维度: overworld(默认), nether, end\n")
+			fmt.Fprintf(os.Stderr, "  同时支持 mapart 的图片预处理选项（--crop --filter --brightness 等）\n")
+			os.Exit(1)
+		}
+		imagePath := os.Args[2]
+		worldPath := os.Args[3]
+		var outputPath string
+		options := os.Args[4:]
+		if len(options) > 0 && !strings.HasPrefix(options[0], "--") {
+			outputPath = options[0]
+			options = options[1:]
+		}
+		if err := convertPixelWall(imagePath, worldPath, outputPath, options); err != nil {
+			fmt.Fprintf(os.Stderr, "像素画墙生成失败: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Println("✓ 像素画墙生成完成！")
+
+	case "paste":
+		if len(os.Args) < 4 {
+			fmt.Fprintf(os.Stderr, "错误: 粘贴命令需要结构文件和世界文件\n")
+			fmt.Fprintf(os.Stderr, "用法: %s paste <结构文件> <世界文件/目录> [输出文件] [选项]\n", os.Args[0])
+			fmt.Fprintf(os.Stderr, "选项:\n")
+			fmt.Fprintf(os.Stderr, "  --x/--y/--z <坐标> 结构最小角落放置的方块坐标（默认 0 -60 0，不需要按子区块对齐）\n")
+			fmt.Fprintf(os.Stderr, "  --dimension <维度> 写入的维度: overworld(默认), nether, end\n")
+			fmt.Fprintf(os.Stderr, "  --skip-air        跳过结构中的空气，保留世界中原有的方块\n")
+			fmt.Fprintf(os.Stderr, "  --fast            使用快速模式读取结构\n")
+			os.Exit(1)
+		}
+		srcPath := os.Args[2]
+		worldPath := os.Args[3]
+		var outputPath string
+		options := os.Args[4:]
+		if len(options) > 0 && !strings.HasPrefix(options[0], "--") {
+			outputPath = options[0]
+			options = options[1:]
+		}
+		if err := pasteStructure(srcPath, worldPath, outputPath, options); err != nil {
+			fmt.Fprintf(os.Stderr, "粘贴失败: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Println("✓ 粘贴完成！")
+
 	case "encrypt", "e":
 		if len(os.Args) < 3 {
 			fmt.Fprintf(os.Stderr, "错误: 加密命令需要世界文件或目录\n")
-			fmt.Fprintf(os.Stderr, "用法: %s encrypt <世界文件/目录> [输出文件]\n", os.Args[0])
+			fmt.Fprintf(os.Stderr, "用法: %s encrypt <世界文件/目录> [输出文件] [--key <十六进制> | --key-file <文件> | --key-env <变量名>]\n", os.Args[0])
 			os.Exit(1)
 		}
 		worldPath := os.Args[2]
+		key, rest, err := parseNeteaseKeyArgs(os.Args[3:])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "错误: %v\n", err)
+			os.Exit(1)
+		}
 		var outputPath string
-		if len(os.Args) >= 4 {
-			outputPath = os.Args[3]
+		if len(rest) >= 1 {
+			outputPath = rest[0]
 		}
-		if err := neteaseCrypt(worldPath, outputPath, true); err != nil {
+		if err := neteaseCrypt(worldPath, outputPath, true, key); err != nil {
 			fmt.Fprintf(os.Stderr, "加密失败: %v\n", err)
 			os.Exit(1)
 		}
@@ -492,20 +746,110 @@ func handleCommandMode(command string) {
 	case "decrypt", "d":
 		if len(os.Args) < 3 {
 			fmt.Fprintf(os.Stderr, "错误: 解密命令需要世界文件或目录\n")
-			fmt.Fprintf(os.Stderr, "用法: %s decrypt <世界文件/目录> [输出文件]\n", os.Args[0])
+			fmt.Fprintf(os.Stderr, "用法: %s decrypt <世界文件/目录> [输出文件] [--key <十六进制> | --key-file <文件> | --key-env <变量名>]\n", os.Args[0])
 			os.Exit(1)
 		}
 		worldPath := os.Args[2]
+		key, rest, err := parseNeteaseKeyArgs(os.Args[3:])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "错误: %v\n", err)
+			os.Exit(1)
+		}
 		var outputPath string
-		if len(os.Args) >= 4 {
-			outputPath = os.Args[3]
+		if len(rest) >= 1 {
+			outputPath = rest[0]
 		}
-		if err := neteaseCrypt(worldPath, outputPath, false); err != nil {
+		if err := neteaseCrypt(worldPath, outputPath, false, key); err != nil {
 			fmt.Fprintf(os.Stderr, "解密失败: %v\n", err)
 			os.Exit(1)
 		}
 		fmt.Println("✓ 解密完成！")
 
+	case "crypt-status":
+		if len(os.Args) < 3 {
+			fmt.Fprintf(os.Stderr, "错误: crypt-status 命令需要世界文件或目录\n")
+			fmt.Fprintf(os.Stderr, "用法: %s crypt-status <世界文件/目录>\n", os.Args[0])
+			os.Exit(1)
+		}
+		if err := cryptStatus(os.Args[2]); err != nil {
+			fmt.Fprintf(os.Stderr, "检测失败: %v\n", err)
+			os.Exit(1)
+		}
+
+	case "pack":
+		if len(os.Args) < 3 {
+			fmt.Fprintf(os.Stderr, "错误: pack 命令需要世界目录\n")
+			fmt.Fprintf(os.Stderr, "用法: %s pack <世界目录> [输出文件.mcworld] [--level <0-9|store>]\n", os.Args[0])
+			os.Exit(1)
+		}
+		worldDir, outputPath := os.Args[2], ""
+		for i := 3; i < len(os.Args); i++ {
+			switch {
+			case os.Args[i] == "--level" && i+1 < len(os.Args):
+				level, err := parseCompressLevel(os.Args[i+1])
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "错误: %v\n", err)
+					os.Exit(1)
+				}
+				mcworldCompressLevel = level
+				i++
+			case !strings.HasPrefix(os.Args[i], "--") && outputPath == "":
+				outputPath = os.Args[i]
+			default:
+				fmt.Fprintf(os.Stderr, "错误: 未知的选项: %s\n", os.Args[i])
+				os.Exit(1)
+			}
+		}
+		if err := packMCWorld(worldDir, outputPath); err != nil {
+			fmt.Fprintf(os.Stderr, "错误: %v\n", err)
+			os.Exit(1)
+		}
+
+	case "validate":
+		if len(os.Args) < 3 {
+			fmt.Fprintf(os.Stderr, "错误: validate 命令需要 .mcworld 文件\n")
+			fmt.Fprintf(os.Stderr, "用法: %s validate <文件.mcworld>\n", os.Args[0])
+			os.Exit(1)
+		}
+		if err := validateMCWorld(os.Args[2]); err != nil {
+			fmt.Fprintf(os.Stderr, "检查失败: %v\n", err)
+			os.Exit(1)
+		}
+
+	case "players":
+		if len(os.Args) < 3 {
+			fmt.Fprintf(os.Stderr, "错误: players 命令需要世界文件或目录\n")
+			fmt.Fprintf(os.Stderr, "用法: %s players <世界文件/目录> [输出文件.json] [--no-entities]\n", os.Args[0])
+			os.Exit(1)
+		}
+		worldPath, outputPath, withEntities := os.Args[2], "", true
+		for _, arg := range os.Args[3:] {
+			switch {
+			case arg == "--no-entities":
+				withEntities = false
+			case !strings.HasPrefix(arg, "--") && outputPath == "":
+				outputPath = arg
+			default:
+				fmt.Fprintf(os.Stderr, "错误: 未知的选项: %s\n", arg)
+				os.Exit(1)
+			}
+		}
+		if err := exportWorldAudit(worldPath, outputPath, withEntities); err != nil {
+			fmt.Fprintf(os.Stderr, "导出失败: %v\n", err)
+			os.Exit(1)
+		}
+
+	case "inspect", "i":
+		if len(os.Args) < 3 {
+			fmt.Fprintf(os.Stderr, "错误: inspect 命令需要世界文件或目录\n")
+			fmt.Fprintf(os.Stderr, "用法: %s inspect <世界文件/目录>\n", os.Args[0])
+			os.Exit(1)
+		}
+		if err := inspectWorld(os.Args[2]); err != nil {
+			fmt.Fprintf(os.Stderr, "检查失败: %v\n", err)
+			os.Exit(1)
+		}
+
 	case "list", "l":
 		listFormats()
 
@@ -534,6 +878,98 @@ func handleCommandMode(command string) {
 			os.Exit(1)
 		}
 
+	case "roundtrip":
+		if len(os.Args) < 3 {
+			fmt.Fprintf(os.Stderr, "错误: 往返检查命令需要结构文件\n")
+			fmt.Fprintf(os.Stderr, "用法: %s roundtrip <结构文件> [格式...] [--fast]\n", os.Args[0])
+			fmt.Fprintf(os.Stderr, "      不指定格式时检查所有支持的格式\n")
+			os.Exit(1)
+		}
+		filePath := os.Args[2]
+		var formats []string
+		useFast := false
+		for _, arg := range os.Args[3:] {
+			if arg == "--fast" {
+				useFast = true
+			} else {
+				formats = append(formats, arg)
+			}
+		}
+		if err := roundTripCheck(filePath, formats, useFast); err != nil {
+			fmt.Fprintf(os.Stderr, "往返检查失败: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Println("✓ 往返检查通过！")
+
+	case "palette":
+		usage := func() {
+			fmt.Fprintf(os.Stderr, "用法: %s palette list [--dir <调色板目录>]\n", os.Args[0])
+			fmt.Fprintf(os.Stderr, "      %s palette translate <源调色板> <目标调色板> <方块或运行时ID> [--dir <调色板目录>]\n", os.Args[0])
+			fmt.Fprintf(os.Stderr, "      %s palette dump <调色板> <输出文件>\n", os.Args[0])
+		}
+		var args []string
+		paletteDir := ""
+		for i := 2; i < len(os.Args); i++ {
+			if os.Args[i] == "--dir" && i+1 < len(os.Args) {
+				paletteDir = os.Args[i+1]
+				i++
+				continue
+			}
+			args = append(args, os.Args[i])
+		}
+		if len(args) == 0 {
+			usage()
+			os.Exit(1)
+		}
+		if err := loadUserPalettes(paletteDir); err != nil {
+			fmt.Fprintf(os.Stderr, "错误: %v\n", err)
+			os.Exit(1)
+		}
+
+		var err error
+		switch {
+		case args[0] == "list":
+			listPalettes()
+		case args[0] == "translate" && len(args) == 4:
+			err = translatePaletteBlock(args[1], args[2], args[3])
+		case args[0] == "dump" && len(args) == 3:
+			err = dumpPalette(args[1], args[2])
+		default:
+			usage()
+			os.Exit(1)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "错误: %v\n", err)
+			os.Exit(1)
+		}
+
+	case "block", "b":
+		if len(os.Args) < 3 {
+			fmt.Fprintf(os.Stderr, "用法: %s block <方块|运行时ID>\n", os.Args[0])
+			fmt.Fprintf(os.Stderr, "      %s block search <关键字> [--states]\n", os.Args[0])
+			fmt.Fprintf(os.Stderr, "      方块可以是基岩版字符串、Java 版字符串、\"名称 数据值\" 或运行时 ID\n")
+			os.Exit(1)
+		}
+		var err error
+		if os.Args[2] == "search" {
+			keyword, showStates := "", false
+			for _, arg := range os.Args[3:] {
+				if arg == "--states" {
+					showStates = true
+				} else {
+					keyword = strings.TrimSpace(keyword + " " + arg)
+				}
+			}
+			err = searchBlocks(keyword, showStates)
+		} else {
+			// 状态中可能有空格，未加引号时把剩余参数拼接起来
+			err = lookupBlock(strings.Join(os.Args[2:], " "))
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "错误: %v\n", err)
+			os.Exit(1)
+		}
+
 	case "help", "h", "-h", "--help":
 		printUsage()
 
@@ -547,6 +983,9 @@ func handleCommandMode(command string) {
 // showMainMenu 显示主菜单
 func showMainMenu() {
 	reader := bufio.NewReader(os.Stdin)
+	// 同一会话内的只读操作复用已解压的 .mcworld
+	enableMCWorldSession()
+	defer cleanupMCWorldSession()
 	
 	for {
 		fmt.Println()
@@ -554,16 +993,18 @@ func showMainMenu() {
 		fmt.Println("Minecraft 工具集 - 主菜单")
 		fmt.Println("=" + strings.Repeat("=", 70) + "=")
 		fmt.Println("1. 地图画转换")
-		fmt.Println("2. 存档加密")
-		fmt.Println("3. 存档解密")
-		fmt.Println("4. 文件功能")
-		fmt.Println("5. 退出")
+		fmt.Println("2. 像素画墙")
+		fmt.Println("3. 存档加密")
+		fmt.Println("4. 存档解密")
+		fmt.Println("5. 文件功能")
+		fmt.Println("6. 退出")
 		fmt.Println("=" + strings.Repeat("=", 70) + "=")
-		fmt.Print("请选择 (1-5): ")
+		fmt.Print("请选择 (1-6): ")
 
 		choice, err := reader.ReadString('\n')
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "读取输入失败: %v\n", err)
+			cleanupMCWorldSession()
 			os.Exit(1)
 		}
 		choice = strings.TrimSpace(choice)
@@ -572,13 +1013,16 @@ func showMainMenu() {
 		case "1":
 			handleMapArt(reader)
 		case "2":
-			handleEncrypt(reader)
+			handlePixelWall(reader)
 		case "3":
-			handleDecrypt(reader)
+			handleEncrypt(reader)
 		case "4":
-			handleFileFunction(reader)
+			handleDecrypt(reader)
 		case "5":
+			handleFileFunction(reader)
+		case "6":
 			fmt.Println("退出")
+			cleanupMCWorldSession()
 			os.Exit(0)
 		default:
 			fmt.Fprintf(os.Stderr, "无效的选择，请重新输入\n")
@@ -626,6 +1070,7 @@ func handleMapArt(reader *bufio.Reader) {
 	// 解析选项
 	var options []string
 	fmt.Println("请输入选项（留空跳过，格式: --x 0 --y -4 --z 0 --width 1 --height 1 --2d --no-ref --max3d 10）:")
+	fmt.Println("图片预处理选项: --crop fit|fill|crop --filter lanczos --brightness 10 --contrast 10 --saturation 20 --sharpen 1")
 	fmt.Print("> ")
 	optionsStr, err := reader.ReadString('\n')
 	if err == nil {
@@ -642,6 +1087,60 @@ func handleMapArt(reader *bufio.Reader) {
 	}
 }
 
+// handlePixelWall 处理像素画墙生成
+func handlePixelWall(reader *bufio.Reader) {
+	fmt.Println()
+	fmt.Println("像素画墙")
+	fmt.Println("请输入图片文件路径:")
+	fmt.Print("> ")
+	imagePath, err := reader.ReadString('\n')
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "读取输入失败: %v\n", err)
+		return
+	}
+	imagePath = strings.TrimSpace(imagePath)
+
+	if _, err := os.Stat(imagePath); os.IsNotExist(err) {
+		fmt.Fprintf(os.Stderr, "错误: 文件不存在: %s\n", imagePath)
+		return
+	}
+
+	fmt.Println("请输入世界文件/目录路径:")
+	fmt.Print("> ")
+	worldPath, err := reader.ReadString('\n')
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "读取输入失败: %v\n", err)
+		return
+	}
+	worldPath = strings.TrimSpace(worldPath)
+
+	fmt.Println("请输入输出文件路径（留空自动生成）:")
+	fmt.Print("> ")
+	outputPath, err := reader.ReadString('\n')
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "读取输入失败: %v\n", err)
+		return
+	}
+	outputPath = strings.TrimSpace(outputPath)
+
+	var options []string
+	fmt.Println("请输入选项（留空跳过，格式: --x 0 --y -60 --z 0 --facing south --width 64 --palette wool,concrete --dither）:")
+	fmt.Print("> ")
+	optionsStr, err := reader.ReadString('\n')
+	if err == nil {
+		optionsStr = strings.TrimSpace(optionsStr)
+		if optionsStr != "" {
+			options = strings.Fields(optionsStr)
+		}
+	}
+
+	if err := convertPixelWall(imagePath, worldPath, outputPath, options); err != nil {
+		fmt.Fprintf(os.Stderr, "像素画墙生成失败: %v\n", err)
+	} else {
+		fmt.Println("✓ 像素画墙生成完成！")
+	}
+}
+
 // handleEncrypt 处理存档加密
 func handleEncrypt(reader *bufio.Reader) {
 	fmt.Println()
@@ -664,7 +1163,13 @@ func handleEncrypt(reader *bufio.Reader) {
 	}
 	outputPath = strings.TrimSpace(outputPath)
 
-	if err := neteaseCrypt(worldPath, outputPath, true); err != nil {
+	key, err := promptNeteaseKey(reader)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "加密失败: %v\n", err)
+		return
+	}
+
+	if err := neteaseCrypt(worldPath, outputPath, true, key); err != nil {
 		fmt.Fprintf(os.Stderr, "加密失败: %v\n", err)
 	} else {
 		fmt.Println("✓ 加密完成！")
@@ -693,7 +1198,13 @@ func handleDecrypt(reader *bufio.Reader) {
 	}
 	outputPath = strings.TrimSpace(outputPath)
 
-	if err := neteaseCrypt(worldPath, outputPath, false); err != nil {
+	key, err := promptNeteaseKey(reader)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "解密失败: %v\n", err)
+		return
+	}
+
+	if err := neteaseCrypt(worldPath, outputPath, false, key); err != nil {
 		fmt.Fprintf(os.Stderr, "解密失败: %v\n", err)
 	} else {
 		fmt.Println("✓ 解密完成！")
@@ -705,17 +1216,13 @@ func handleMCWorldStructureExport(mcworldPath string, reader *bufio.Reader) {
 	fmt.Println()
 	fmt.Println("导出结构方块保存的结构")
 
-	// 解压MCWorld
-	extractDir, cleanup, err := unarchiveMCWorldToTempDir(mcworldPath)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "解压MCWorld失败: %v\n", err)
-		return
-	}
-	defer cleanup()
-
-	// 查找structures目录
-	structuresDir := filepath.Join(extractDir, "structures")
-	structures, err := listMCWorldStructures(structuresDir)
+	// 读取LevelDB和structures目录中的结构模板
+	var structures []worldStructureTemplate
+	err := readWorldPath(mcworldPath, func(worldDir string) error {
+		var err error
+		structures, err = loadWorldStructureTemplates(worldDir)
+		return err
+	})
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "读取结构列表失败: %v\n", err)
 		return
@@ -730,11 +1237,11 @@ func handleMCWorldStructureExport(mcworldPath string, reader *bufio.Reader) {
 	fmt.Println()
 	fmt.Println("=" + strings.Repeat("=", 70) + "=")
 	fmt.Println("结构方块保存的结构列表:")
-	for i, name := range structures {
-		fmt.Printf("  %d. %s\n", i+1, name)
+	for i, t := range structures {
+		fmt.Printf("  %d. %s (%s)\n", i+1, t.Name, t.Source)
 	}
 	fmt.Println("=" + strings.Repeat("=", 70) + "=")
-	fmt.Print("请选择结构序号: ")
+	fmt.Print("请选择结构序号（输入 all 导出全部）: ")
 
 	choice, err := reader.ReadString('\n')
 	if err != nil {
@@ -742,13 +1249,61 @@ func handleMCWorldStructureExport(mcworldPath string, reader *bufio.Reader) {
 		return
 	}
 	choice = strings.TrimSpace(choice)
-	index, err := strconv.Atoi(choice)
-	if err != nil || index < 1 || index > len(structures) {
-		fmt.Fprintf(os.Stderr, "无效的序号\n")
+	exportAll := strings.EqualFold(choice, "all")
+	var selectedStructure worldStructureTemplate
+	if !exportAll {
+		index, err := strconv.Atoi(choice)
+		if err != nil || index < 1 || index > len(structures) {
+			fmt.Fprintf(os.Stderr, "无效的序号\n")
+			return
+		}
+		selectedStructure = structures[index-1]
+	}
+
+	// 选择目标格式
+	fmt.Print("请输入目标格式（留空保持 .mcstructure 原样导出）: ")
+	targetFormat, err := reader.ReadString('\n')
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "读取输入失败: %v\n", err)
 		return
 	}
+	targetFormat = strings.TrimSpace(targetFormat)
+	useFast := false
+	if targetFormat != "" {
+		if _, ok := wsstructure.StructureNamePool[targetFormat]; !ok {
+			fmt.Fprintf(os.Stderr, "不支持的目标格式: %s\n使用 'list' 命令查看支持的格式\n", targetFormat)
+			return
+		}
+		fmt.Print("是否使用快速模式？(y/n，默认n): ")
+		fastChoice, _ := reader.ReadString('\n')
+		if strings.TrimSpace(strings.ToLower(fastChoice)) == "y" {
+			useFast = true
+		}
+	}
 
-	selectedStructure := structures[index-1]
+	baseName := strings.TrimSuffix(filepath.Base(mcworldPath), filepath.Ext(mcworldPath))
+
+	if exportAll {
+		// 输入输出目录
+		fmt.Println("请输入输出目录（留空自动生成）:")
+		fmt.Print("> ")
+		outputDir, err := reader.ReadString('\n')
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "读取输入失败: %v\n", err)
+			return
+		}
+		outputDir = strings.TrimSpace(outputDir)
+		if outputDir == "" {
+			outputDir = filepath.Join(filepath.Dir(mcworldPath), baseName+"_structures")
+		}
+
+		exported, err := exportStructureTemplates(structures, targetFormat, outputDir, useFast)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "导出失败: %v\n", err)
+		}
+		fmt.Printf("✓ 已导出 %d/%d 个结构到: %s\n", exported, len(structures), outputDir)
+		return
+	}
 
 	// 输入输出文件路径
 	fmt.Println("请输入输出文件路径（留空自动生成）:")
@@ -762,19 +1317,12 @@ func handleMCWorldStructureExport(mcworldPath string, reader *bufio.Reader) {
 
 	// 自动生成输出文件名
 	if outputPath == "" {
-		baseName := strings.TrimSuffix(filepath.Base(mcworldPath), filepath.Ext(mcworldPath))
-		outputPath = filepath.Join(filepath.Dir(mcworldPath), baseName+"_"+selectedStructure+".mcstructure")
+		renamed := selectedStructure
+		renamed.Name = baseName + "_" + selectedStructure.Name
+		outputPath = structureTemplateOutputPath(filepath.Dir(mcworldPath), renamed, targetFormat)
 	}
 
-	// 确保输出目录存在
-	if err := os.MkdirAll(filepath.Dir(outputPath), 0755); err != nil {
-		fmt.Fprintf(os.Stderr, "错误: 无法创建输出目录: %v\n", err)
-		return
-	}
-
-	// 复制结构文件
-	sourceFile := filepath.Join(structuresDir, selectedStructure+".mcstructure")
-	if err := copyFile(sourceFile, outputPath); err != nil {
+	if err := exportStructureTemplate(selectedStructure, targetFormat, outputPath, useFast); err != nil {
 		fmt.Fprintf(os.Stderr, "导出失败: %v\n", err)
 	} else {
 		fmt.Printf("✓ 导出完成！输出文件: %s\n", outputPath)
@@ -820,48 +1368,85 @@ func handleMCWorldExport(mcworldPath string, reader *bufio.Reader) {
 	fmt.Println()
 	fmt.Println("MCWorld导出")
 
-	// 输入起始坐标
-	fmt.Println("请输入起始坐标 (格式: x y z，例如: 0 -64 0):")
-	fmt.Print("> ")
-	startCoordStr, err := reader.ReadString('\n')
+	// 选择维度
+	fmt.Print("请选择维度 (1. 主世界 2. 下界 3. 末地，默认1): ")
+	dimensionChoice, err := reader.ReadString('\n')
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "读取输入失败: %v\n", err)
 		return
 	}
-	startCoordStr = strings.TrimSpace(startCoordStr)
-	startCoords := strings.Fields(startCoordStr)
-	if len(startCoords) != 3 {
-		fmt.Fprintf(os.Stderr, "错误: 坐标格式不正确，需要3个数字\n")
-		return
+	dimensionChoice = strings.TrimSpace(dimensionChoice)
+	if n, err := strconv.Atoi(dimensionChoice); err == nil {
+		dimensionChoice = strconv.Itoa(n - 1)
 	}
-	startX, err1 := strconv.ParseInt(startCoords[0], 10, 32)
-	startY, err2 := strconv.ParseInt(startCoords[1], 10, 32)
-	startZ, err3 := strconv.ParseInt(startCoords[2], 10, 32)
-	if err1 != nil || err2 != nil || err3 != nil {
-		fmt.Fprintf(os.Stderr, "错误: 坐标格式不正确，必须是整数\n")
+	dimension, err := parseDimension(dimensionChoice)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "错误: %v\n", err)
 		return
 	}
 
-	// 输入终止坐标
-	fmt.Println("请输入终止坐标 (格式: x y z，例如: 15 15 15):")
-	fmt.Print("> ")
-	endCoordStr, err := reader.ReadString('\n')
+	// 选择导出范围
+	fmt.Print("请选择导出范围 (1. 手动输入坐标 2. 自动检测建筑范围，默认1): ")
+	modeChoice, err := reader.ReadString('\n')
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "读取输入失败: %v\n", err)
 		return
 	}
-	endCoordStr = strings.TrimSpace(endCoordStr)
-	endCoords := strings.Fields(endCoordStr)
-	if len(endCoords) != 3 {
-		fmt.Fprintf(os.Stderr, "错误: 坐标格式不正确，需要3个数字\n")
-		return
-	}
-	endX, err1 := strconv.ParseInt(endCoords[0], 10, 32)
-	endY, err2 := strconv.ParseInt(endCoords[1], 10, 32)
-	endZ, err3 := strconv.ParseInt(endCoords[2], 10, 32)
-	if err1 != nil || err2 != nil || err3 != nil {
-		fmt.Fprintf(os.Stderr, "错误: 坐标格式不正确，必须是整数\n")
-		return
+
+	var startPos, endPos wsdefine.BlockPos
+	if strings.TrimSpace(modeChoice) == "2" {
+		startPos, endPos, err = promptAutoBounds(mcworldPath, dimension, reader)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "自动检测失败: %v\n", err)
+			return
+		}
+	} else {
+		// 输入起始坐标
+		fmt.Println("请输入起始坐标 (格式: x y z，例如: 0 -64 0):")
+		fmt.Print("> ")
+		startCoordStr, err := reader.ReadString('\n')
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "读取输入失败: %v\n", err)
+			return
+		}
+		startCoordStr = strings.TrimSpace(startCoordStr)
+		startCoords := strings.Fields(startCoordStr)
+		if len(startCoords) != 3 {
+			fmt.Fprintf(os.Stderr, "错误: 坐标格式不正确，需要3个数字\n")
+			return
+		}
+		startX, err1 := strconv.ParseInt(startCoords[0], 10, 32)
+		startY, err2 := strconv.ParseInt(startCoords[1], 10, 32)
+		startZ, err3 := strconv.ParseInt(startCoords[2], 10, 32)
+		if err1 != nil || err2 != nil || err3 != nil {
+			fmt.Fprintf(os.Stderr, "错误: 坐标格式不正确，必须是整数\n")
+			return
+		}
+
+		// 输入终止坐标
+		fmt.Println("请输入终止坐标 (格式: x y z，例如: 15 15 15):")
+		fmt.Print("> ")
+		endCoordStr, err := reader.ReadString('\n')
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "读取输入失败: %v\n", err)
+			return
+		}
+		endCoordStr = strings.TrimSpace(endCoordStr)
+		endCoords := strings.Fields(endCoordStr)
+		if len(endCoords) != 3 {
+			fmt.Fprintf(os.Stderr, "错误: 坐标格式不正确，需要3个数字\n")
+			return
+		}
+		endX, err1 := strconv.ParseInt(endCoords[0], 10, 32)
+		endY, err2 := strconv.ParseInt(endCoords[1], 10, 32)
+		endZ, err3 := strconv.ParseInt(endCoords[2], 10, 32)
+		if err1 != nil || err2 != nil || err3 != nil {
+			fmt.Fprintf(os.Stderr, "错误: 坐标格式不正确，必须是整数\n")
+			return
+		}
+
+		startPos = wsdefine.BlockPos{int32(startX), int32(startY), int32(startZ)}
+		endPos = wsdefine.BlockPos{int32(endX), int32(endY), int32(endZ)}
 	}
 
 	// 显示支持的格式列表
@@ -915,7 +1500,7 @@ func handleMCWorldExport(mcworldPath string, reader *bufio.Reader) {
 	}
 
 	// 执行导出
-	if err := exportFromMCWorld(mcworldPath, outputPath, targetFormat, targetFactory, int32(startX), int32(startY), int32(startZ), int32(endX), int32(endY), int32(endZ)); err != nil {
+	if err := exportFromMCWorld(mcworldPath, outputPath, targetFormat, targetFactory, dimension, startPos.X(), startPos.Y(), startPos.Z(), endPos.X(), endPos.Y(), endPos.Z()); err != nil {
 		fmt.Fprintf(os.Stderr, "导出失败: %v\n", err)
 	} else {
 		fmt.Printf("✓ 导出完成！输出文件: %s\n", outputPath)
@@ -923,23 +1508,20 @@ func handleMCWorldExport(mcworldPath string, reader *bufio.Reader) {
 }
 
 // exportFromMCWorld 从MCWorld导出结构文件
-func exportFromMCWorld(mcworldPath, outputPath, targetFormat string, targetFactory wsstructure.StructureFunc, startX, startY, startZ, endX, endY, endZ int32) error {
-	// 解压MCWorld
-	extractDir, cleanup, err := unarchiveMCWorldToTempDir(mcworldPath)
+func exportFromMCWorld(mcworldPath, outputPath, targetFormat string, targetFactory wsstructure.StructureFunc, dimension bwo_define.Dimension, startX, startY, startZ, endX, endY, endZ int32) error {
+	// 解压MCWorld（只解压 db 和 level.dat）
+	extractDir, release, err := extractMCWorldForRead(mcworldPath)
 	if err != nil {
 		return fmt.Errorf("解压MCWorld失败: %w", err)
 	}
-	defer cleanup()
+	defer release()
 
-	// 打开世界
-	bw, err := world.Open(extractDir, nil)
+	// 只读打开世界：解压目录在会话内共享，不能改写其中的 db 和 level.dat
+	bw, err := openWorldReadOnly(extractDir)
 	if err != nil {
-		return fmt.Errorf("打开世界失败: %w", err)
+		return err
 	}
-	defer func() {
-		_ = bw.CloseWorld()
-		_ = bw.Close()
-	}()
+	defer bw.Close()
 
 	// 创建输出文件
 	outputFile, err := os.OpenFile(outputPath, os.O_CREATE|os.O_RDWR|os.O_TRUNC, 0644)
@@ -952,11 +1534,35 @@ func exportFromMCWorld(mcworldPath, outputPath, targetFormat string, targetFacto
 	startPos := wsdefine.BlockPos{startX, startY, startZ}
 	endPos := wsdefine.BlockPos{endX, endY, endZ}
 
+	// 下界/末地先投影到临时世界的主世界再导出
+	// 导出为 Java 版格式时需要转换方块实体，同样先投影，避免修改源世界
+	source := bw
+	toJava := isJavaStructureFormat(targetFormat)
+	if dimension != bwo_define.DimensionIDOverworld || toJava {
+		projected, cleanupProjected, err := projectDimensionToOverworld(bw, dimension, startPos, endPos)
+		if err != nil {
+			return fmt.Errorf("读取%s失败: %w", dimensionName(dimension), err)
+		}
+		defer cleanupProjected()
+		source = projected
+	}
+	var javaBlocks javaBlockOverrides
+	if toJava {
+		translated, overrides, err := translateBlockEntities(source, startPos, endPos, false, nil)
+		if err != nil {
+			return fmt.Errorf("转换方块实体失败: %w", err)
+		}
+		if translated > 0 {
+			fmt.Printf("已转换 %d 个方块实体为 Java 版格式\n", translated)
+		}
+		javaBlocks = overrides
+	}
+
 	// 导出结构
 	targetStruct := targetFactory()
-	fmt.Println("正在导出...")
+	fmt.Printf("正在导出%s...\n", dimensionName(dimension))
 	if err := targetStruct.FromMCWorld(
-		bw,
+		source,
 		outputFile,
 		startPos,
 		endPos,
@@ -969,6 +1575,9 @@ func exportFromMCWorld(mcworldPath, outputPath, targetFormat string, targetFacto
 	); err != nil {
 		return fmt.Errorf("导出结构失败: %w", err)
 	}
+	if err := finishStructureExport(targetFormat, outputFile, source, startPos, endPos, nil, javaBlocks); err != nil {
+		return err
+	}
 
 	return nil
 }
@@ -1011,19 +1620,59 @@ func printUsage() {
 	fmt.Println()
 	fmt.Println("命令:")
 	fmt.Println("  convert, c    - 转换结构文件格式")
-	fmt.Println("                用法: convert <输入文件> <目标格式> [输出文件]")
+	fmt.Println("                用法: convert <输入文件> <目标格式> [输出文件] [--fast] [--dimension <维度>]")
+	fmt.Println("                      [--origin <x,y,z> | --use-offset] [--report <文件.json>]")
+	fmt.Println("                维度: overworld(默认), nether, end，用于从 MCWorld 读取或写入 MCWorld")
+	fmt.Println("                --origin/--use-offset: 写入 MCWorld 时的放置坐标，可以是任意方块坐标")
+	fmt.Println("                --report: 导出方块匹配报告（转换结束时总会列出模糊匹配和找不到的方块）")
+	fmt.Println("                --palette <调色板> [--palette-dir <目录>]: 输出 MCStructure/MCWorld 时使用的方块调色板")
+	fmt.Println("                level.dat: --gamemode <模式> --cheats --flat --flat-layers <方块层> --spawn <x,y,z>")
+	fmt.Println("                      --time <时间> --lock-time --lock-weather --gamerule <名称=值>")
 	fmt.Println()
 	fmt.Println("  mapart, m    - 将图片转换为地图画")
 	fmt.Println("                用法: mapart <图片文件> <世界文件/目录> [选项]")
 	fmt.Println("                选项: --x <X坐标> --y <Y坐标> --z <Z坐标>")
 	fmt.Println("                      --width <地图宽度> --height <地图高度>")
 	fmt.Println("                      --2d (强制2D模式)")
+	fmt.Println("                预处理: --crop <fit|fill|crop> --filter <滤镜> --bg <RRGGBB>")
+	fmt.Println("                      --brightness <值> --contrast <值> --saturation <值> --sharpen <值>")
+	fmt.Println()
+	fmt.Println("  wall, w      - 将图片生成为竖直的像素画墙（使用完整方块调色板）")
+	fmt.Println("                用法: wall <图片文件> <世界文件/目录> [输出文件] [选项]")
+	fmt.Println("                选项: --x/--y/--z <左下角坐标> --facing <south|north|east|west>")
+	fmt.Println("                      --width <宽> --height <高> --palette <方块组> --dither")
+	fmt.Println("                      --dimension <overworld|nether|end>")
+	fmt.Println()
+	fmt.Println("  paste        - 将结构写入已有世界的任意方块坐标（保留周围地形，合并方块实体）")
+	fmt.Println("                用法: paste <结构文件> <世界文件/目录> [输出文件] [选项]")
+	fmt.Println("                选项: --x/--y/--z <最小角落坐标> --dimension <overworld|nether|end>")
+	fmt.Println("                      --skip-air (保留结构中空气处的原有方块) --fast")
 	fmt.Println()
 	fmt.Println("  encrypt, e   - 加密网易版世界存档")
-	fmt.Println("                用法: encrypt <世界文件/目录>")
+	fmt.Println("                用法: encrypt <世界文件/目录> [输出文件] [密钥选项]")
 	fmt.Println()
 	fmt.Println("  decrypt, d   - 解密网易版世界存档")
-	fmt.Println("                用法: decrypt <世界文件/目录>")
+	fmt.Println("                用法: decrypt <世界文件/目录> [输出文件] [密钥选项]")
+	fmt.Println("                密钥选项: --key <十六进制> --key-file <文件> --key-env <变量名>")
+	fmt.Println("                未指定时读取环境变量 FATALDER_NETEASE_KEY，仍为空则使用默认密钥")
+	fmt.Println("                会自动检测加密状态，拒绝重复加密或解密未加密的存档")
+	fmt.Println("                处理世界目录时先在副本上操作并验证，原 db 保留为 db.backup-<时间>")
+	fmt.Println()
+	fmt.Println("  crypt-status - 查看网易版世界存档的加密状态（只读）")
+	fmt.Println("                用法: crypt-status <世界文件/目录>")
+	fmt.Println("                功能: 检查 db 中 CURRENT、MANIFEST、.ldb 等文件，逐个报告已加密/未加密/混合")
+	fmt.Println()
+	fmt.Println("  pack         - 将世界目录打包为可导入游戏的 .mcworld")
+	fmt.Println("                用法: pack <世界目录> [输出文件.mcworld] [--level <0-9|store>]")
+	fmt.Println("  validate     - 检查 .mcworld 能否被游戏导入（只读）")
+	fmt.Println("                用法: validate <文件.mcworld>")
+	fmt.Println()
+	fmt.Println("  inspect, i   - 查看世界信息（只读）")
+	fmt.Println("                用法: inspect <世界文件/目录>")
+	fmt.Println("                功能: 世界名称、游戏版本、各维度区块范围与数量、方块实体/实体数量、结构模板")
+	fmt.Println("  players      - 导出玩家和实体数据为 JSON（只读）")
+	fmt.Println("                用法: players <世界文件/目录> [输出文件.json] [--no-entities]")
+	fmt.Println("                功能: 背包、末影箱、盔甲、副手、位置、游戏模式和权限，以及实体的位置和物品")
 	fmt.Println()
 	fmt.Println("  parse, p     - 解析结构文件并生成报告图片")
 	fmt.Println("                用法: parse <文件路径>")
@@ -1033,20 +1682,53 @@ func printUsage() {
 	fmt.Println("                用法: quota <文件路径>")
 	fmt.Println("                功能: 统计方块数量、命令方块数量、NBT方块数量")
 	fmt.Println()
+	fmt.Println("  roundtrip    - 往返检查：转换为各个格式再读回，检查方块实体是否仍在相同的方块上")
+	fmt.Println("                用法: roundtrip <结构文件> [格式...] [--fast]")
+	fmt.Println("  palette      - 方块调色板：列出已加载的版本、在版本之间转换运行时 ID、导出调色板")
+	fmt.Println("                用法: palette list | palette translate <源> <目标> <方块或运行时ID> | palette dump <调色板> <输出文件>")
+	fmt.Println("                额外的调色板从 --dir 或环境变量 FATALDER_PALETTE_DIR 指定的目录加载")
+	fmt.Println()
+	fmt.Println("  block, b     - 查询方块的基岩版名称和状态、数据值、运行时 ID、Java 版方块和 Schematic ID")
+	fmt.Println("                用法: block <方块|运行时ID> | block search <关键字> [--states]")
+	fmt.Println("                方块可以是基岩版字符串、Java 版字符串、\"名称 数据值\" 或运行时 ID")
+	fmt.Println()
 	fmt.Println("  list, l      - 列出所有支持的格式")
 	fmt.Println()
 	fmt.Println("  help, h      - 显示帮助信息")
 	fmt.Println()
+	fmt.Println("全局选项（可用于任何命令和交互模式）:")
+	fmt.Println("  --records <文件>       加载自定义方块转换记录（覆盖内置映射，可重复）")
+	fmt.Println("  --java-records <文件>  加载自定义基岩版→Java 转换记录（可重复）")
+	fmt.Println("  --keep-records         冲突时保留内置映射，只补充缺失的映射")
+	fmt.Println()
 	fmt.Println("示例:")
 	fmt.Printf("  %s convert input.schematic MCStructure output.mcstructure\n", os.Args[0])
 	fmt.Printf("  %s convert input.schematic MCStructure output.mcstructure --fast\n", os.Args[0])
+	fmt.Printf("  %s convert old.schematic Litematic new.litematic --report blocks.json\n", os.Args[0])
+	fmt.Printf("  %s convert house.mcstructure MCStructure house_1_21.mcstructure --palette bedrock_1_21 --palette-dir ./palettes\n", os.Args[0])
 	fmt.Printf("  %s mapart image.jpg world.mcworld output.mapart.mcworld --width 2 --height 2\n", os.Args[0])
 	fmt.Printf("  %s mapart image.png world.mcworld --2d --no-ref --max3d 10\n", os.Args[0])
+	fmt.Printf("  %s mapart photo.jpg world.mcworld --crop crop --contrast 15 --saturation 20 --sharpen 1\n", os.Args[0])
+	fmt.Printf("  %s wall logo.png lobby.mcworld --x 10 --y -60 --z 0 --facing east --width 48 --dither\n", os.Args[0])
+	fmt.Printf("  %s paste house.schematic lobby.mcworld --x 103 --y -60 --z -27 --skip-air\n", os.Args[0])
 	fmt.Printf("  %s encrypt world.mcworld world.encrypted.mcworld\n", os.Args[0])
 	fmt.Printf("  %s decrypt world.mcworld world.decrypted.mcworld\n", os.Args[0])
 	fmt.Printf("  %s decrypt /sdcard/games/com.netease/minecraftWorlds/World1\n", os.Args[0])
+	fmt.Printf("  %s decrypt world.mcworld --key-file world.key\n", os.Args[0])
+	fmt.Printf("  %s crypt-status world.mcworld\n", os.Args[0])
+	fmt.Printf("  %s pack ./my_world my_world.mcworld --level 9\n", os.Args[0])
+	fmt.Printf("  %s validate my_world.mcworld\n", os.Args[0])
+	fmt.Printf("  %s inspect world.mcworld\n", os.Args[0])
+	fmt.Printf("  %s players world.mcworld world_players.json\n", os.Args[0])
 	fmt.Printf("  %s parse /storage/emulated/0/Download/文件.bdx\n", os.Args[0])
 	fmt.Printf("  %s quota /storage/emulated/0/Download/文件.bdx\n", os.Args[0])
+	fmt.Printf("  %s convert house.mcstructure MCWorld house.mcworld --origin 7,-61,3\n", os.Args[0])
+	fmt.Printf("  %s convert castle.litematic MCWorld showcase.mcworld --flat --gamemode creative --cheats --time noon --lock-time --lock-weather\n", os.Args[0])
+	fmt.Printf("  %s roundtrip chest_room.mcstructure Schematic Litematic MCStructure\n", os.Args[0])
+	fmt.Printf("  %s palette translate nemc bedrock_1_21 oak_log --dir ./palettes\n", os.Args[0])
+	fmt.Printf("  %s block 'wool 14'\n", os.Args[0])
+	fmt.Printf("  %s block search stairs\n", os.Args[0])
+	fmt.Printf("  %s convert old.schematic MCStructure fixed.mcstructure --records my_fixes.txt\n", os.Args[0])
 }
 
 func listFormats() {
@@ -1074,7 +1756,8 @@ func getSupportedFormats() []string {
 }
 
 // convertStructure 转换结构文件格式
-func convertStructure(srcPath, targetFormat, destPath string, useFast bool) error {
+// placement 指定从 MCWorld 源读取的维度，以及生成 MCWorld 时写入的维度和位置
+func convertStructure(srcPath, targetFormat, destPath string, useFast bool, placement worldPlacement) error {
 	srcFile, err := os.Open(srcPath)
 	if err != nil {
 		return fmt.Errorf("无法打开源文件: %w", err)
@@ -1093,6 +1776,13 @@ func convertStructure(srcPath, targetFormat, destPath string, useFast bool) erro
 	if !ok {
 		return fmt.Errorf("不支持的目标格式: %s\n使用 'list' 命令查看支持的格式", targetFormat)
 	}
+	if err := checkOutputPalette(placement.Palette, targetFormat); err != nil {
+		return err
+	}
+	var paletteTr *paletteTranslation
+	if placement.Palette != nil {
+		paletteTr = newPaletteTranslation(placement.Palette)
+	}
 
 	if destPath == "" {
 		ext := strings.ToLower(filepath.Ext(srcPath))
@@ -1110,13 +1800,22 @@ func convertStructure(srcPath, targetFormat, destPath string, useFast bool) erro
 	}
 	defer destFile.Close()
 
-	// 尝试从 MCWorld 源直接导出（优化路径）
-	if handled, err := tryExportFromMCWorldSource(srcPath, targetFormat, targetFactory, destFile); handled {
-		if err != nil {
-			return err
+	// 尝试从 MCWorld 源直接导出（优化路径）；MCWorld 到 MCWorld 是直接复制，需要转换调色板时走完整流程
+	if paletteTr == nil || targetFormat != wsstructure.NameMCWorld {
+		if handled, err := tryExportFromMCWorldSource(srcPath, targetFormat, targetFactory, destFile, placement.Dimension); handled {
+			if err != nil {
+				return err
+			}
+			if paletteTr != nil {
+				entries, err := paletteTr.translateMCStructureFile(destFile)
+				if err != nil {
+					return fmt.Errorf("转换方块调色板失败: %w", err)
+				}
+				paletteTr.printSummary(entries)
+			}
+			fmt.Printf("输出文件: %s\n", destPath)
+			return nil
 		}
-		fmt.Printf("输出文件: %s\n", destPath)
-		return nil
 	}
 
 	fmt.Println("开始转换...")
@@ -1138,12 +1837,30 @@ func convertStructure(srcPath, targetFormat, destPath string, useFast bool) erro
 	}
 	defer func() { _ = bedrockWorld.CloseWorld() }()
 
-	startSubChunkPos := wsdefine.SubChunkPos{0, -4, 0}
+	size := srcStruct.GetSize()
+	offset := srcStruct.GetOffsetPos()
+	if offset != (wsdefine.Offset{}) {
+		fmt.Printf("源结构偏移: (%d, %d, %d)\n", offset.X(), offset.Y(), offset.Z())
+	}
+
+	// 临时世界中结构的最小角落固定在 (0, structureBaseY, 0)，与读取器返回的坐标一致
+	startSubChunkPos := wsdefine.SubChunkPos{0, structureBaseY >> 4, 0}
+	startBlockPos := wsdefine.BlockPos{
+		startSubChunkPos.X() * 16,
+		startSubChunkPos.Y() * 16,
+		startSubChunkPos.Z() * 16,
+	}
+	endBlockPos := wsdefine.BlockPos{
+		startBlockPos.X() + int32(size.Width) - 1,
+		startBlockPos.Y() + int32(size.Height) - 1,
+		startBlockPos.Z() + int32(size.Length) - 1,
+	}
+
 	fmt.Println("步骤 1/2: 将源结构转换为临时世界...")
-	
+
 	if useFast {
 		// 使用快速模式（多线程）
-		if err := convertReaderToMCWorldFast(srcStruct, bedrockWorld, bwo_define.SubChunkPos(startSubChunkPos), func(int) {}, func() {}); err != nil {
+		if err := convertReaderToMCWorldFast(srcStruct, bedrockWorld, bwo_define.DimensionIDOverworld, bwo_define.SubChunkPos(startSubChunkPos), func(int) {}, func() {}); err != nil {
 			return fmt.Errorf("写入世界失败: %w", err)
 		}
 	} else {
@@ -1158,42 +1875,100 @@ func convertStructure(srcPath, targetFormat, destPath string, useFast bool) erro
 		}
 	}
 
-	size := srcStruct.GetSize()
-	
+	// schematic 的 AddBlocks 和自带的方块 ID 表由这里按 12 位 ID 重新写入，输出 schematic 时沿用其 ID 表
+	var schematicIDs *blocks.SchematicIDMapping
+	if srcStruct.Name() == schematicFormat {
+		if _, err := srcFile.Seek(0, io.SeekStart); err != nil {
+			return err
+		}
+		schematic, err := readSchematicFile(srcFile)
+		if err != nil {
+			return err
+		}
+		if _, err := applySchematicBlocks(schematic, bedrockWorld, startBlockPos); err != nil {
+			return fmt.Errorf("写入 schematic 方块失败: %w", err)
+		}
+		schematicIDs = schematic.idMapping()
+	}
+
+	// Java 版结构转换为基岩版格式时，将方块实体 NBT 转换为基岩版格式
+	srcJava, targetJava := isJavaStructureFormat(srcStruct.Name()), isJavaStructureFormat(targetFormat)
+	if srcJava && !targetJava {
+		javaBlockName, err := javaBlockNamesOf(srcFile, srcStruct.Name(), startBlockPos)
+		if err != nil {
+			return err
+		}
+		translated, _, err := translateBlockEntities(bedrockWorld, startBlockPos, endBlockPos, true, javaBlockName)
+		if err != nil {
+			return fmt.Errorf("转换方块实体失败: %w", err)
+		}
+		if translated > 0 {
+			fmt.Printf("已转换 %d 个方块实体为基岩版格式\n", translated)
+		}
+	}
+
 	// 如果目标格式是 MCWorld，设置世界名称并直接打包
 	if targetFormat == wsstructure.NameMCWorld {
+		// 放置位置或维度与临时世界不同时，逐方块复制到新世界，放置位置不需要按子区块对齐
+		origin := placement.origin(offset)
+		outWorld, outDir := bedrockWorld, worldDir
+		if origin != startBlockPos || placement.Dimension != bwo_define.DimensionIDOverworld {
+			outDir = filepath.Join(tmpDir, "placed")
+			placed, err := placeStructureWorld(bedrockWorld, startBlockPos, size, outDir, placement.Dimension, origin)
+			if err != nil {
+				return fmt.Errorf("放置结构失败: %w", err)
+			}
+			defer func() { _ = placed.CloseWorld() }()
+			outWorld = placed
+			fmt.Printf("结构已放置到%s (%d, %d, %d)\n", dimensionName(placement.Dimension), origin.X(), origin.Y(), origin.Z())
+		}
+		originEnd := wsdefine.BlockPos{
+			origin.X() + int32(size.Width) - 1,
+			origin.Y() + int32(size.Height) - 1,
+			origin.Z() + int32(size.Length) - 1,
+		}
+
 		structureName := strings.TrimSuffix(filepath.Base(srcPath), filepath.Ext(srcPath))
-		worldName := fmt.Sprintf("%s@[0,-64,0]~[%d,%d,%d]",
+		worldName := fmt.Sprintf("%s@[%d,%d,%d]~[%d,%d,%d]",
 			structureName,
-			size.Width-1,
-			size.Height-64-1,
-			size.Length-1,
+			origin.X(), origin.Y(), origin.Z(),
+			originEnd.X(), originEnd.Y(), originEnd.Z(),
 		)
-		bedrockWorld.LevelDat().LevelName = worldName
-		if err := bedrockWorld.CloseWorld(); err != nil {
+		outWorld.LevelDat().LevelName = worldName
+		if err := placement.Level.apply(outWorld.LevelDat(), origin, originEnd); err != nil {
+			return fmt.Errorf("设置 level.dat 失败: %w", err)
+		}
+		if err := outWorld.CloseWorld(); err != nil {
 			return fmt.Errorf("关闭世界失败: %w", err)
 		}
-		if err := archiveDirAsMCWorld(worldDir, destPath); err != nil {
+		if paletteTr != nil {
+			entries, err := paletteTr.translateWorldPalette(outDir)
+			if err != nil {
+				return fmt.Errorf("转换方块调色板失败: %w", err)
+			}
+			paletteTr.printSummary(entries)
+		}
+		if err := archiveDirAsMCWorld(outDir, destPath); err != nil {
 			return fmt.Errorf("打包MCWorld失败: %w", err)
 		}
 		fmt.Printf("输出文件: %s\n", destPath)
 		fmt.Printf("世界名称: %s\n", worldName)
 		return nil
 	}
-	
-	// 其他格式：从临时世界导出
-	startBlockPos := wsdefine.BlockPos{
-		startSubChunkPos.X() * 16,
-		startSubChunkPos.Y() * 16,
-		startSubChunkPos.Z() * 16,
-	}
-	endBlockPos := wsdefine.BlockPos{
-		startBlockPos.X() + int32(size.Width) - 1,
-		startBlockPos.Y() + int32(size.Height) - 1,
-		startBlockPos.Z() + int32(size.Length) - 1,
-	}
 
+	// 其他格式：从临时世界导出
 	fmt.Println("步骤 2/2: 从临时世界导出为目标格式...")
+	var javaBlocks javaBlockOverrides
+	if targetJava && !srcJava {
+		translated, overrides, err := translateBlockEntities(bedrockWorld, startBlockPos, endBlockPos, false, nil)
+		if err != nil {
+			return fmt.Errorf("转换方块实体失败: %w", err)
+		}
+		if translated > 0 {
+			fmt.Printf("已转换 %d 个方块实体为 Java 版格式\n", translated)
+		}
+		javaBlocks = overrides
+	}
 	targetStruct := targetFactory()
 	if err := targetStruct.FromMCWorld(
 		bedrockWorld,
@@ -1205,6 +1980,16 @@ func convertStructure(srcPath, targetFormat, destPath string, useFast bool) erro
 	); err != nil {
 		return fmt.Errorf("导出结构失败: %w", err)
 	}
+	if err := finishStructureExport(targetFormat, destFile, bedrockWorld, startBlockPos, endBlockPos, schematicIDs, javaBlocks); err != nil {
+		return err
+	}
+	if paletteTr != nil {
+		entries, err := paletteTr.translateMCStructureFile(destFile)
+		if err != nil {
+			return fmt.Errorf("转换方块调色板失败: %w", err)
+		}
+		paletteTr.printSummary(entries)
+	}
 
 	fmt.Printf("输出文件: %s\n", destPath)
 	return nil
@@ -1212,7 +1997,7 @@ func convertStructure(srcPath, targetFormat, destPath string, useFast bool) erro
 
 // convertMapArt 将图片转换为地图画
 func convertMapArt(imagePath, worldPath, outputPath string, options []string) error {
-	img, err := imaging.Open(imagePath)
+	img, err := imaging.Open(imagePath, imaging.AutoOrientation(true))
 	if err != nil {
 		return fmt.Errorf("无法打开图片: %w", err)
 	}
@@ -1226,8 +2011,17 @@ func convertMapArt(imagePath, worldPath, outputPath string, options []string) er
 		DisableReferenceColumn: false,
 		Max3DHeight:      0,
 	}
+	preprocess := newMapArtPreprocess()
 
 	for i := 0; i < len(options); i++ {
+		consumed, err := preprocess.parseOption(options, i)
+		if err != nil {
+			return err
+		}
+		if consumed > 0 {
+			i += consumed - 1
+			continue
+		}
 		switch options[i] {
 		case "--x":
 			if i+1 < len(options) {
@@ -1272,192 +2066,72 @@ func convertMapArt(imagePath, worldPath, outputPath string, options []string) er
 		}
 	}
 
-	info, err := os.Stat(worldPath)
-	if err != nil {
-		return fmt.Errorf("无法访问世界路径: %w", err)
+	if preprocess.changed() {
+		fmt.Println("正在预处理图片...")
+		img = preprocess.apply(img, opts.MapWidth*mapArtPixelsPerMap, opts.MapHeight*mapArtPixelsPerMap)
 	}
 
-	var worldDir string
-	var cleanup func()
-	var isTemp bool
-
-	if !info.IsDir() {
-		// 是 .mcworld 文件
-		worldDir, cleanup, err = unarchiveMCWorldToTempDir(worldPath)
+	return editWorldPath(worldPath, outputPath, ".mapart.mcworld", func(worldDir string) error {
+		fmt.Println("正在生成地图画...")
+		minPos, maxPos, err := writeMapArtToWorldDir(worldDir, img, opts)
 		if err != nil {
-			return fmt.Errorf("无法解压世界文件: %w", err)
-		}
-		isTemp = true
-		defer cleanup()
-	} else {
-		worldDir = worldPath
-		isTemp = false
-	}
-
-	fmt.Println("正在生成地图画...")
-	minPos, maxPos, err := writeMapArtToWorldDir(worldDir, img, opts)
-	if err != nil {
-		return err
-	}
-
-	fmt.Printf("写入范围: (%d,%d,%d) ~ (%d,%d,%d)\n", minPos[0], minPos[1], minPos[2], maxPos[0], maxPos[1], maxPos[2])
-
-	if isTemp {
-		// 如果是临时目录，需要重新打包
-		if outputPath == "" {
-			outputPath = strings.TrimSuffix(worldPath, filepath.Ext(worldPath)) + ".mapart.mcworld"
-		}
-		if !strings.HasSuffix(strings.ToLower(outputPath), ".mcworld") {
-			outputPath += ".mcworld"
-		}
-		fmt.Printf("正在打包为: %s\n", outputPath)
-		if err := archiveDirAsMCWorld(worldDir, outputPath); err != nil {
-			return fmt.Errorf("打包失败: %w", err)
+			return err
 		}
-		fmt.Printf("地图画已写入: %s\n", outputPath)
-	} else {
-		fmt.Printf("地图画已写入: %s\n", worldDir)
-	}
-
-	return nil
-}
-
-func writeMapArtToWorldDir(worldDir string, img image.Image, opts *wsmapart.Options) (minPos [3]int32, maxPos [3]int32, err error) {
-	bedrockWorld, err := world.Open(worldDir, nil)
-	if err != nil {
-		return [3]int32{}, [3]int32{}, fmt.Errorf("无法打开世界: %w", err)
-	}
-	defer func() { _ = bedrockWorld.CloseWorld() }()
-
-	return wsmapart.GenerateMapArtToWorld(bedrockWorld, img, opts)
+		fmt.Printf("写入范围: (%d,%d,%d) ~ (%d,%d,%d)\n", minPos[0], minPos[1], minPos[2], maxPos[0], maxPos[1], maxPos[2])
+		return nil
+	})
 }
 
-// neteaseCrypt 网易版世界加密/解密
-func neteaseCrypt(worldPath, outputPath string, encrypt bool) error {
+// editWorldPath 在世界目录或 .mcworld 文件上执行修改
+// 如果是 .mcworld 文件，会先把 db 和 level.dat 解压到临时目录，修改完成后重新打包到 outputPath（留空则使用 defaultSuffix 自动生成）
+func editWorldPath(worldPath, outputPath, defaultSuffix string, edit func(worldDir string) error) error {
 	info, err := os.Stat(worldPath)
 	if err != nil {
-		return fmt.Errorf("无法访问路径: %w", err)
+		return fmt.Errorf("无法访问世界路径: %w", err)
 	}
 
-	var dbDir string
-	var isTemp bool
-	var cleanup func()
-
-	if !info.IsDir() {
-		// 是 .mcworld 文件
-		worldDir, cleanup, err := unarchiveMCWorldToTempDir(worldPath)
-		if err != nil {
-			return fmt.Errorf("无法解压世界文件: %w", err)
-		}
-		defer cleanup()
-		isTemp = true
-
-		dbDir = filepath.Join(worldDir, "db")
-		if _, err := os.Stat(dbDir); err != nil {
-			return fmt.Errorf("缺少 db 目录: %w", err)
-		}
-	} else {
-		// 是世界目录
-		if filepath.Base(worldPath) == "db" {
-			dbDir = worldPath
-		} else {
-			dbDir = filepath.Join(worldPath, "db")
-		}
-
-		if _, err := os.Stat(filepath.Join(dbDir, "CURRENT")); err != nil {
-			return fmt.Errorf("无效的 db 目录: %w", err)
+	if info.IsDir() {
+		if err := edit(worldPath); err != nil {
+			return err
 		}
-		isTemp = false
-	}
-
-	action := "解密"
-	if encrypt {
-		action = "加密"
-	}
-
-	fmt.Printf("正在%s数据库: %s\n", action, dbDir)
-
-	if encrypt {
-		err = wsnetease.Encrypt(dbDir, nil)
-	} else {
-		err = wsnetease.Decrypt(dbDir, nil)
+		fmt.Printf("已写入: %s\n", worldPath)
+		return nil
 	}
 
+	// 是 .mcworld 文件：只解压 db 和 level.dat，其余文件打包时从原压缩包直接复制
+	worldDir, cleanup, err := unarchiveMCWorldData(worldPath)
 	if err != nil {
-		return fmt.Errorf("%s失败: %w", action, err)
-	}
-
-	if isTemp {
-		// 如果是临时目录，需要重新打包
-		if outputPath == "" {
-			outputPath = strings.TrimSuffix(worldPath, filepath.Ext(worldPath))
-			if encrypt {
-				outputPath += ".encrypted.mcworld"
-			} else {
-				outputPath += ".decrypted.mcworld"
-			}
-		}
-		if !strings.HasSuffix(strings.ToLower(outputPath), ".mcworld") {
-			outputPath += ".mcworld"
-		}
-		fmt.Printf("正在打包为: %s\n", outputPath)
-		worldDir := filepath.Dir(dbDir)
-		if err := archiveDirAsMCWorld(worldDir, outputPath); err != nil {
-			return fmt.Errorf("打包失败: %w", err)
-		}
-		fmt.Printf("%s完成: %s\n", action, outputPath)
-	} else {
-		fmt.Printf("%s完成: %s\n", action, dbDir)
+		return fmt.Errorf("无法解压世界文件: %w", err)
 	}
+	defer cleanup()
 
-	return nil
-}
-
-func unarchiveMCWorldToTempDir(mcworldPath string) (string, func(), error) {
-	tempDir, err := os.MkdirTemp("", "fatalder-mcworld-*")
-	if err != nil {
-		return "", nil, err
+	if err := edit(worldDir); err != nil {
+		return err
 	}
-	cleanup := func() { _ = os.RemoveAll(tempDir) }
 
-	z := archiver.Zip{}
-	if err := z.Unarchive(mcworldPath, tempDir); err != nil {
-		cleanup()
-		return "", nil, err
+	// 临时目录需要重新打包
+	if outputPath == "" {
+		outputPath = strings.TrimSuffix(worldPath, filepath.Ext(worldPath)) + defaultSuffix
 	}
-	return tempDir, cleanup, nil
-}
-
-func archiveDirAsMCWorld(worldDir string, outPath string) error {
-	entries, err := os.ReadDir(worldDir)
-	if err != nil {
-		return err
+	if !strings.HasSuffix(strings.ToLower(outputPath), ".mcworld") {
+		outputPath += ".mcworld"
 	}
-	var inputs []string
-	for _, entry := range entries {
-		inputs = append(inputs, filepath.Join(worldDir, entry.Name()))
+	fmt.Printf("正在打包为: %s\n", outputPath)
+	if err := repackMCWorld(worldPath, worldDir, outputPath); err != nil {
+		return fmt.Errorf("打包失败: %w", err)
 	}
+	fmt.Printf("已写入: %s\n", outputPath)
+	return nil
+}
 
-	tmpDir, err := os.MkdirTemp("", "fatalder-mcworld-zip-*")
+func writeMapArtToWorldDir(worldDir string, img image.Image, opts *wsmapart.Options) (minPos [3]int32, maxPos [3]int32, err error) {
+	bedrockWorld, err := world.Open(worldDir, nil)
 	if err != nil {
-		return err
-	}
-	defer os.RemoveAll(tmpDir)
-
-	tmpZip := filepath.Join(tmpDir, "world.zip")
-	z := archiver.Zip{}
-	if err := z.Archive(inputs, tmpZip); err != nil {
-		return err
+		return [3]int32{}, [3]int32{}, fmt.Errorf("无法打开世界: %w", err)
 	}
+	defer func() { _ = bedrockWorld.CloseWorld() }()
 
-	if err := os.RemoveAll(outPath); err != nil && !os.IsNotExist(err) {
-		return err
-	}
-	if err := os.Rename(tmpZip, outPath); err != nil {
-		// 如果重命名失败，尝试复制
-		return copyFile(tmpZip, outPath)
-	}
-	return nil
+	return wsmapart.GenerateMapArtToWorld(bedrockWorld, img, opts)
 }
 
 func copyFile(src, dst string) error {
@@ -1481,12 +2155,31 @@ func copyFile(src, dst string) error {
 	return err
 }
 
+// copyDir 递归复制目录
+func copyDir(src, dst string) error {
+	return filepath.WalkDir(src, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+		if d.IsDir() {
+			return os.MkdirAll(target, 0755)
+		}
+		return copyFile(path, target)
+	})
+}
+
 // tryExportFromMCWorldSource 尝试直接从 MCWorld 源导出（优化路径）
 func tryExportFromMCWorldSource(
 	structurePath string,
 	targetFormat string,
 	targetFactory wsstructure.StructureFunc,
 	targetFile *os.File,
+	dimension bwo_define.Dimension,
 ) (handled bool, err error) {
 	ext := strings.ToLower(filepath.Ext(structurePath))
 	if ext != ".mcworld" && ext != ".zip" {
@@ -1507,20 +2200,18 @@ func tryExportFromMCWorldSource(
 	}
 
 	// 从 MCWorld 提取并转换
-	extractDir, cleanup, err := unarchiveMCWorldToTempDir(structurePath)
+	extractDir, release, err := extractMCWorldForRead(structurePath)
 	if err != nil {
 		return false, nil
 	}
-	defer cleanup()
+	defer release()
 
-	bw, err := world.Open(extractDir, nil)
+	// 只读打开世界：解压目录在会话内共享，不能改写其中的 db 和 level.dat
+	bw, err := openWorldReadOnly(extractDir)
 	if err != nil {
 		return false, nil
 	}
-	defer func() {
-		_ = bw.CloseWorld()
-		_ = bw.Close()
-	}()
+	defer bw.Close()
 
 	// 尝试从文件名或世界名称解析坐标
 	startPos, endPos, ok := parseSelectionBounds(structurePath)
@@ -1531,9 +2222,29 @@ func tryExportFromMCWorldSource(
 		return true, fmt.Errorf("无法从文件名或世界名称中解析坐标信息，请使用完整转换流程")
 	}
 
+	// 导出为 Java 版格式时需要转换方块实体，同样先投影到临时世界，避免修改源世界
+	source := bw
+	toJava := isJavaStructureFormat(targetFormat)
+	if dimension != bwo_define.DimensionIDOverworld || toJava {
+		projected, cleanupProjected, err := projectDimensionToOverworld(bw, dimension, startPos, endPos)
+		if err != nil {
+			return true, fmt.Errorf("读取%s失败: %w", dimensionName(dimension), err)
+		}
+		defer cleanupProjected()
+		source = projected
+	}
+	var javaBlocks javaBlockOverrides
+	if toJava {
+		_, overrides, err := translateBlockEntities(source, startPos, endPos, false, nil)
+		if err != nil {
+			return true, fmt.Errorf("转换方块实体失败: %w", err)
+		}
+		javaBlocks = overrides
+	}
+
 	targetStruct := targetFactory()
 	if err := targetStruct.FromMCWorld(
-		bw,
+		source,
 		targetFile,
 		startPos,
 		endPos,
@@ -1542,6 +2253,9 @@ func tryExportFromMCWorldSource(
 	); err != nil {
 		return true, err
 	}
+	if err := finishStructureExport(targetFormat, targetFile, source, startPos, endPos, nil, javaBlocks); err != nil {
+		return true, err
+	}
 	return true, nil
 }
 
@@ -1591,7 +2305,8 @@ func maxInt32(a, b int32) int32 {
 }
 
 // convertReaderToMCWorldFast 快速转换模式（多线程批量处理）
-func convertReaderToMCWorldFast(reader wsstructure.Structure, bedrockWorld *world.BedrockWorld, startSubChunkPos bwo_define.SubChunkPos, startCallback func(int), progressCallback func()) error {
+// 结构的最小角落写入 startSubChunkPos 子区块的原点，dimension 为写入的维度
+func convertReaderToMCWorldFast(reader wsstructure.Structure, bedrockWorld *world.BedrockWorld, dimension bwo_define.Dimension, startSubChunkPos bwo_define.SubChunkPos, startCallback func(int), progressCallback func()) error {
 	if reader == nil {
 		return errors.New("reader is nil")
 	}
@@ -1680,6 +2395,8 @@ func convertReaderToMCWorldFast(reader wsstructure.Structure, bedrockWorld *worl
 	chunkOffsetX := startSubChunkPos.X()
 	chunkOffsetZ := startSubChunkPos.Z()
 	blockYOffset := startSubChunkPos.Y() * 16
+	// 读取器返回的区块和 NBT 坐标中，结构底部位于主世界底部（Y=-64），需要移动到 blockYOffset
+	yShift := blockYOffset - structureBaseY
 
 	for res := range resultCh {
 		if res.err != nil {
@@ -1689,9 +2406,13 @@ func convertReaderToMCWorldFast(reader wsstructure.Structure, bedrockWorld *worl
 		for _, pos := range res.positions {
 			chunkData, ok := res.chunks[pos]
 			if ok && chunkData != nil {
+				chunkData, err := shiftChunkToDimension(chunkData, dimension, yShift)
+				if err != nil {
+					return err
+				}
 				chunkData.Compact()
 				targetPos := bwo_define.ChunkPos{pos.X() + chunkOffsetX, pos.Z() + chunkOffsetZ}
-				if err := bedrockWorld.SaveChunk(bwo_define.DimensionIDOverworld, targetPos, chunkData); err != nil {
+				if err := bedrockWorld.SaveChunk(dimension, targetPos, chunkData); err != nil {
 					return err
 				}
 			}
@@ -1715,16 +2436,17 @@ func convertReaderToMCWorldFast(reader wsstructure.Structure, bedrockWorld *worl
 				for k, v := range n {
 					m[k] = v
 				}
-				m["x"] = absChunkX + bpos.X()
-				m["y"] = blockYOffset + bpos.Y() + 64
-				m["z"] = absChunkZ + bpos.Z()
+				// NBT 的 X/Z 可能是结构内坐标，也可能是区块内坐标，只取区块内部分
+				m["x"] = absChunkX + bpos.X()&15
+				m["y"] = bpos.Y() + yShift
+				m["z"] = absChunkZ + bpos.Z()&15
 				list = append(list, m)
 			}
 			if len(list) == 0 {
 				continue
 			}
 			targetPos := bwo_define.ChunkPos{cpos.X() + chunkOffsetX, cpos.Z() + chunkOffsetZ}
-			if err := bedrockWorld.SaveNBT(bwo_define.DimensionIDOverworld, targetPos, list); err != nil {
+			if err := bedrockWorld.SaveNBT(dimension, targetPos, list); err != nil {
 				return err
 			}
 		}
@@ -1733,6 +2455,51 @@ func convertReaderToMCWorldFast(reader wsstructure.Structure, bedrockWorld *worl
 	return nil
 }
 
+// handlePasteStructure 处理将结构粘贴到已有世界
+func handlePasteStructure(filePath string, reader *bufio.Reader) {
+	fmt.Println()
+	fmt.Println("粘贴到已有世界")
+	fmt.Println("请输入世界文件/目录路径:")
+	fmt.Print("> ")
+	worldPath, err := reader.ReadString('\n')
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "读取输入失败: %v\n", err)
+		return
+	}
+	worldPath = strings.TrimSpace(worldPath)
+
+	if _, err := os.Stat(worldPath); os.IsNotExist(err) {
+		fmt.Fprintf(os.Stderr, "错误: 世界不存在: %s\n", worldPath)
+		return
+	}
+
+	fmt.Println("请输入输出文件路径（留空自动生成，世界目录会直接修改）:")
+	fmt.Print("> ")
+	outputPath, err := reader.ReadString('\n')
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "读取输入失败: %v\n", err)
+		return
+	}
+	outputPath = strings.TrimSpace(outputPath)
+
+	var options []string
+	fmt.Println("请输入选项（留空跳过，格式: --x 0 --y -60 --z 0 --dimension overworld --skip-air --fast）:")
+	fmt.Print("> ")
+	optionsStr, err := reader.ReadString('\n')
+	if err == nil {
+		optionsStr = strings.TrimSpace(optionsStr)
+		if optionsStr != "" {
+			options = strings.Fields(optionsStr)
+		}
+	}
+
+	if err := pasteStructure(filePath, worldPath, outputPath, options); err != nil {
+		fmt.Fprintf(os.Stderr, "粘贴失败: %v\n", err)
+	} else {
+		fmt.Println("✓ 粘贴完成！")
+	}
+}
+
 // handleFileOptimization 处理文件优化菜单
 func handleFileOptimization(filePath string, reader *bufio.Reader) {
 	for {
@@ -1905,15 +2672,15 @@ func replaceBlocksInFile(filePath, oldBlockName, newBlockName, outputPath string
 	defer reader.Close()
 
 	// 获取目标方块的RuntimeID
-	newRuntimeID, found := blocks.BlockStrToRuntimeID(newBlockName)
-	if !found {
-		return 0, fmt.Errorf("无法识别方块: %s", newBlockName)
+	newRuntimeID, err := resolveBlock(newBlockName)
+	if err != nil {
+		return 0, err
 	}
 
 	// 获取旧方块的RuntimeID（用于匹配）
-	oldRuntimeID, found := blocks.BlockStrToRuntimeID(oldBlockName)
-	if !found {
-		return 0, fmt.Errorf("无法识别方块: %s", oldBlockName)
+	oldRuntimeID, err := resolveBlock(oldBlockName)
+	if err != nil {
+		return 0, err
 	}
 
 	// 创建临时MCWorld
@@ -2001,7 +2768,7 @@ func replaceBlocksInFile(filePath, oldBlockName, newBlockName, outputPath string
 		targetFormat = "MCStructure" // 默认使用MCStructure
 	}
 
-	return exportFromMCWorld(worldDir, filePath, outputPath, targetFormat, replacedCount)
+	return exportWorldToStructure(worldDir, filePath, outputPath, targetFormat, replacedCount)
 }
 
 // addDenyBlocksToFile 添加拒绝方块
@@ -2115,12 +2882,12 @@ func addDenyBlocksToFile(filePath, outputPath string) error {
 		targetFormat = "MCStructure"
 	}
 
-	_, err = exportFromMCWorld(worldDir, filePath, outputPath, targetFormat, 0)
+	_, err = exportWorldToStructure(worldDir, filePath, outputPath, targetFormat, 0)
 	return err
 }
 
-// exportFromMCWorld 从MCWorld导出结构
-func exportFromMCWorld(worldDir, originalPath, outputPath, targetFormat string, operationCount int) (int, error) {
+// exportWorldToStructure 把修改后的临时世界导出为结构文件
+func exportWorldToStructure(worldDir, originalPath, outputPath, targetFormat string, operationCount int) (int, error) {
 	// 打开临时世界
 	bedrockWorld, err := world.Open(worldDir, nil)
 	if err != nil {
@@ -0,0 +1,192 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/disintegration/imaging"
+)
+
+// 每张地图的像素边长
+const mapArtPixelsPerMap = 128
+
+// mapArtPreprocess 地图画图片预处理选项（在调色板匹配之前执行）
+type mapArtPreprocess struct {
+	CropMode   string // none / fit / fill / crop
+	Filter     string // 重采样滤镜名称
+	Background color.NRGBA
+	Brightness float64 // -100 ~ 100
+	Contrast   float64 // -100 ~ 100
+	Saturation float64 // -100 ~ 500
+	Sharpen    float64 // 锐化强度（高斯 sigma，0 表示不锐化）
+}
+
+// newMapArtPreprocess 返回默认的预处理选项（不做任何处理）
+func newMapArtPreprocess() *mapArtPreprocess {
+	return &mapArtPreprocess{
+		CropMode:   "none",
+		Filter:     "lanczos",
+		Background: color.NRGBA{255, 255, 255, 255},
+	}
+}
+
+// mapArtResampleFilters 支持的重采样滤镜
+var mapArtResampleFilters = map[string]imaging.ResampleFilter{
+	"nearest":    imaging.NearestNeighbor,
+	"box":        imaging.Box,
+	"linear":     imaging.Linear,
+	"hermite":    imaging.Hermite,
+	"mitchell":   imaging.MitchellNetravali,
+	"catmullrom": imaging.CatmullRom,
+	"bspline":    imaging.BSpline,
+	"gaussian":   imaging.Gaussian,
+	"lanczos":    imaging.Lanczos,
+}
+
+// parseOption 解析单个预处理选项，返回消耗的参数个数（0 表示不是预处理选项）
+func (p *mapArtPreprocess) parseOption(options []string, i int) (int, error) {
+	value := func() (string, error) {
+		if i+1 >= len(options) {
+			return "", fmt.Errorf("选项 %s 缺少参数", options[i])
+		}
+		return options[i+1], nil
+	}
+	number := func(min, max float64) (float64, error) {
+		s, err := value()
+		if err != nil {
+			return 0, err
+		}
+		v, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return 0, fmt.Errorf("选项 %s 的参数必须是数字: %s", options[i], s)
+		}
+		if v < min || v > max {
+			return 0, fmt.Errorf("选项 %s 的参数超出范围 [%g, %g]: %g", options[i], min, max, v)
+		}
+		return v, nil
+	}
+
+	var err error
+	switch options[i] {
+	case "--crop":
+		var mode string
+		if mode, err = value(); err != nil {
+			return 0, err
+		}
+		mode = strings.ToLower(mode)
+		switch mode {
+		case "none", "fit", "fill", "crop":
+			p.CropMode = mode
+		case "center", "centre", "center-crop", "centre-crop":
+			p.CropMode = "crop"
+		default:
+			return 0, fmt.Errorf("未知的裁剪模式: %s（可选: none, fit, fill, crop）", mode)
+		}
+	case "--filter":
+		var name string
+		if name, err = value(); err != nil {
+			return 0, err
+		}
+		name = strings.ToLower(name)
+		if _, ok := mapArtResampleFilters[name]; !ok {
+			return 0, fmt.Errorf("未知的重采样滤镜: %s（可选: %s）", name, strings.Join(mapArtFilterNames(), ", "))
+		}
+		p.Filter = name
+	case "--bg":
+		var s string
+		if s, err = value(); err != nil {
+			return 0, err
+		}
+		if p.Background, err = parseHexColor(s); err != nil {
+			return 0, err
+		}
+	case "--brightness":
+		if p.Brightness, err = number(-100, 100); err != nil {
+			return 0, err
+		}
+	case "--contrast":
+		if p.Contrast, err = number(-100, 100); err != nil {
+			return 0, err
+		}
+	case "--saturation":
+		if p.Saturation, err = number(-100, 500); err != nil {
+			return 0, err
+		}
+	case "--sharpen":
+		if p.Sharpen, err = number(0, 10); err != nil {
+			return 0, err
+		}
+	default:
+		return 0, nil
+	}
+	return 2, nil
+}
+
+// apply 按 裁剪/缩放 -> 亮度 -> 对比度 -> 饱和度 -> 锐化 的顺序处理图片，width/height 为目标像素尺寸
+func (p *mapArtPreprocess) apply(img image.Image, width, height int) image.Image {
+	filter := p.resampleFilter()
+
+	switch p.CropMode {
+	case "fit":
+		// 等比缩放到目标尺寸以内，空白处用背景色填充
+		fitted := imaging.Fit(img, width, height, filter)
+		img = imaging.PasteCenter(imaging.New(width, height, p.Background), fitted)
+	case "fill":
+		// 拉伸到目标尺寸（不保持比例）
+		img = imaging.Resize(img, width, height, filter)
+	case "crop":
+		// 等比缩放后居中裁剪
+		img = imaging.Fill(img, width, height, imaging.Center, filter)
+	}
+
+	if p.Brightness != 0 {
+		img = imaging.AdjustBrightness(img, p.Brightness)
+	}
+	if p.Contrast != 0 {
+		img = imaging.AdjustContrast(img, p.Contrast)
+	}
+	if p.Saturation != 0 {
+		img = imaging.AdjustSaturation(img, p.Saturation)
+	}
+	if p.Sharpen > 0 {
+		img = imaging.Sharpen(img, p.Sharpen)
+	}
+	return img
+}
+
+// resampleFilter 返回当前选择的重采样滤镜
+func (p *mapArtPreprocess) resampleFilter() imaging.ResampleFilter {
+	return mapArtResampleFilters[p.Filter]
+}
+
+// changed 判断是否需要执行预处理
+func (p *mapArtPreprocess) changed() bool {
+	return p.CropMode != "none" || p.Brightness != 0 || p.Contrast != 0 || p.Saturation != 0 || p.Sharpen > 0
+}
+
+// mapArtFilterNames 返回排序后的滤镜名称
+func mapArtFilterNames() []string {
+	names := make([]string, 0, len(mapArtResampleFilters))
+	for name := range mapArtResampleFilters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// parseHexColor 解析 RRGGBB 或 #RRGGBB 格式的颜色
+func parseHexColor(s string) (color.NRGBA, error) {
+	s = strings.TrimPrefix(strings.TrimSpace(s), "#")
+	if len(s) != 6 {
+		return color.NRGBA{}, fmt.Errorf("无效的颜色: %s（格式: RRGGBB）", s)
+	}
+	v, err := strconv.ParseUint(s, 16, 32)
+	if err != nil {
+		return color.NRGBA{}, fmt.Errorf("无效的颜色: %s（格式: RRGGBB）", s)
+	}
+	return color.NRGBA{uint8(v >> 16), uint8(v >> 8), uint8(v), 255}, nil
+}
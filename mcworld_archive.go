@@ -0,0 +1,326 @@
+package main
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// mcworldArchive 只读打开的 .mcworld 压缩包，可以不解压直接读取其中的文件
+type mcworldArchive struct {
+	zr   *zip.ReadCloser
+	root string // 世界根目录在压缩包中的前缀（部分压缩包外面多包了一层目录），以 / 结尾或为空
+}
+
+// openMCWorldArchive 打开 .mcworld 压缩包并定位世界根目录（level.dat 或 db/ 所在的目录）
+func openMCWorldArchive(archivePath string) (*mcworldArchive, error) {
+	zr, err := zip.OpenReader(archivePath)
+	if err != nil {
+		return nil, fmt.Errorf("无法打开压缩包: %w", err)
+	}
+
+	root, found := "", false
+	for _, f := range zr.File {
+		name := f.Name
+		var dir string
+		switch {
+		case path.Base(name) == "level.dat":
+			dir = strings.TrimSuffix(name, "level.dat")
+		case strings.HasSuffix(name, "/db/CURRENT") || name == "db/CURRENT":
+			dir = strings.TrimSuffix(name, "db/CURRENT")
+		default:
+			continue
+		}
+		// 取最浅的一层，避免误用嵌套在世界里的其他存档
+		if !found || len(dir) < len(root) {
+			root, found = dir, true
+		}
+	}
+	if !found {
+		_ = zr.Close()
+		return nil, fmt.Errorf("压缩包中没有 level.dat 或 db 目录，不是有效的 MCWorld")
+	}
+	return &mcworldArchive{zr: zr, root: root}, nil
+}
+
+// Close 关闭压缩包
+func (a *mcworldArchive) Close() error {
+	return a.zr.Close()
+}
+
+// relPath 返回压缩包条目相对世界根目录的路径，不在世界根目录下时返回 false
+func (a *mcworldArchive) relPath(f *zip.File) (string, bool) {
+	if !strings.HasPrefix(f.Name, a.root) {
+		return "", false
+	}
+	rel := strings.TrimPrefix(f.Name, a.root)
+	return rel, rel != ""
+}
+
+// readFile 读取世界根目录下的文件（路径使用 /）
+func (a *mcworldArchive) readFile(name string) ([]byte, error) {
+	rc, err := a.zr.Open(a.root + name)
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	return io.ReadAll(rc)
+}
+
+// listStructures 列出 structures/ 目录中的结构名称（不含 .mcstructure 扩展名）
+func (a *mcworldArchive) listStructures() []string {
+	var structures []string
+	for _, f := range a.zr.File {
+		rel, ok := a.relPath(f)
+		if !ok || f.FileInfo().IsDir() || path.Dir(rel) != "structures" {
+			continue
+		}
+		if strings.HasSuffix(strings.ToLower(rel), ".mcstructure") {
+			structures = append(structures, strings.TrimSuffix(path.Base(rel), ".mcstructure"))
+		}
+	}
+	sort.Strings(structures)
+	return structures
+}
+
+// isWorldDataEntry 判断条目是否属于打开世界所需的数据：db/ 目录和根目录下的文件（level.dat、levelname.txt 等）
+// 资源包、行为包、structures/ 等目录不需要解压
+func isWorldDataEntry(rel string) bool {
+	return strings.HasPrefix(rel, "db/") || !strings.Contains(rel, "/")
+}
+
+// extract 将满足 filter 的条目解压到 destDir
+func (a *mcworldArchive) extract(destDir string, filter func(rel string) bool) error {
+	for _, f := range a.zr.File {
+		rel, ok := a.relPath(f)
+		if !ok || !filter(rel) {
+			continue
+		}
+		target := filepath.Join(destDir, filepath.FromSlash(rel))
+		// 防止压缩包中的 ../ 写到目标目录之外
+		if !strings.HasPrefix(target, filepath.Clean(destDir)+string(os.PathSeparator)) {
+			return fmt.Errorf("压缩包中存在非法路径: %s", f.Name)
+		}
+		if f.FileInfo().IsDir() {
+			if err := os.MkdirAll(target, 0755); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := extractZipFile(f, target); err != nil {
+			return fmt.Errorf("解压 %s 失败: %w", f.Name, err)
+		}
+	}
+	return nil
+}
+
+// extractZipFile 解压单个压缩包条目
+func extractZipFile(f *zip.File, target string) error {
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return err
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	out, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, rc); err != nil {
+		_ = out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// unarchiveMCWorldData 只解压打开世界所需的数据（db/ 和 level.dat 等）到新的临时目录
+func unarchiveMCWorldData(mcworldPath string) (string, func(), error) {
+	archive, err := openMCWorldArchive(mcworldPath)
+	if err != nil {
+		return "", nil, err
+	}
+	defer archive.Close()
+
+	tempDir, err := os.MkdirTemp("", "fatalder-mcworld-*")
+	if err != nil {
+		return "", nil, err
+	}
+	cleanup := func() { _ = os.RemoveAll(tempDir) }
+	if err := archive.extract(tempDir, isWorldDataEntry); err != nil {
+		cleanup()
+		return "", nil, err
+	}
+	return tempDir, cleanup, nil
+}
+
+// mcworldSession 交互模式下复用已解压的 .mcworld，避免每次操作都重新解压
+// 只用于只读操作（打开世界时使用 openWorldReadOnly）；修改世界的操作始终使用独立的临时目录
+var mcworldSession struct {
+	sync.Mutex
+	enabled bool
+	worlds  map[string]*sessionWorld // 压缩包绝对路径 → 解压结果（仅会话复用时）
+	dirs    map[string]string        // 正在使用的只读解压目录 → 压缩包绝对路径
+}
+
+// sessionWorld 会话中解压过的 .mcworld，压缩包大小或修改时间变化后失效
+type sessionWorld struct {
+	dir     string
+	size    int64
+	modTime time.Time
+}
+
+// enableMCWorldSession 开启会话内复用（交互模式使用），退出前需要调用 cleanupMCWorldSession
+func enableMCWorldSession() {
+	mcworldSession.Lock()
+	defer mcworldSession.Unlock()
+	mcworldSession.enabled = true
+}
+
+// cleanupMCWorldSession 删除会话中解压的所有临时目录
+func cleanupMCWorldSession() {
+	mcworldSession.Lock()
+	defer mcworldSession.Unlock()
+	for key, w := range mcworldSession.worlds {
+		_ = os.RemoveAll(w.dir)
+		delete(mcworldSession.dirs, w.dir)
+		delete(mcworldSession.worlds, key)
+	}
+}
+
+// extractMCWorldForRead 为只读操作准备 .mcworld 的世界目录（只包含 db/ 和 level.dat 等）
+// 返回的目录可能被同一会话的其他操作复用，只能用 openWorldDB、openWorldReadOnly 或 readLevelDat 读取
+// 开启会话复用时返回缓存的目录且 release 不会删除它，否则 release 删除临时目录
+func extractMCWorldForRead(mcworldPath string) (worldDir string, release func(), err error) {
+	absPath, err := filepath.Abs(mcworldPath)
+	if err != nil {
+		return "", nil, err
+	}
+	info, err := os.Stat(absPath)
+	if err != nil {
+		return "", nil, err
+	}
+
+	mcworldSession.Lock()
+	defer mcworldSession.Unlock()
+	if mcworldSession.dirs == nil {
+		mcworldSession.dirs = make(map[string]string)
+		mcworldSession.worlds = make(map[string]*sessionWorld)
+	}
+
+	if !mcworldSession.enabled {
+		dir, cleanup, err := unarchiveMCWorldData(absPath)
+		if err != nil {
+			return "", nil, err
+		}
+		mcworldSession.dirs[dir] = absPath
+		return dir, func() {
+			mcworldSession.Lock()
+			delete(mcworldSession.dirs, dir)
+			mcworldSession.Unlock()
+			cleanup()
+		}, nil
+	}
+
+	if w, ok := mcworldSession.worlds[absPath]; ok {
+		if w.size == info.Size() && w.modTime.Equal(info.ModTime()) {
+			return w.dir, func() {}, nil
+		}
+		// 压缩包已被修改，重新解压
+		_ = os.RemoveAll(w.dir)
+		delete(mcworldSession.dirs, w.dir)
+		delete(mcworldSession.worlds, absPath)
+	}
+
+	dir, _, err := unarchiveMCWorldData(absPath)
+	if err != nil {
+		return "", nil, err
+	}
+	mcworldSession.worlds[absPath] = &sessionWorld{dir: dir, size: info.Size(), modTime: info.ModTime()}
+	mcworldSession.dirs[dir] = absPath
+	return dir, func() {}, nil
+}
+
+// mcworldArchiveFor 返回只读解压目录对应的 .mcworld 路径
+func mcworldArchiveFor(worldDir string) (string, bool) {
+	mcworldSession.Lock()
+	defer mcworldSession.Unlock()
+	archivePath, ok := mcworldSession.dirs[worldDir]
+	return archivePath, ok
+}
+
+// listWorldStructures 列出世界 structures/ 目录中的结构
+// 世界来自 .mcworld 时直接读取压缩包，structures/ 不会被解压
+func listWorldStructures(worldDir string) ([]string, error) {
+	if archivePath, ok := mcworldArchiveFor(worldDir); ok {
+		archive, err := openMCWorldArchive(archivePath)
+		if err != nil {
+			return nil, err
+		}
+		defer archive.Close()
+		return archive.listStructures(), nil
+	}
+	return listMCWorldStructures(filepath.Join(worldDir, "structures"))
+}
+
+// readWorldStructure 读取世界 structures/ 目录中的结构文件
+func readWorldStructure(worldDir, name string) ([]byte, error) {
+	if archivePath, ok := mcworldArchiveFor(worldDir); ok {
+		archive, err := openMCWorldArchive(archivePath)
+		if err != nil {
+			return nil, err
+		}
+		defer archive.Close()
+		return archive.readFile("structures/" + name + ".mcstructure")
+	}
+	return os.ReadFile(filepath.Join(worldDir, "structures", name+".mcstructure"))
+}
+
+// repackMCWorld 将修改后的世界数据重新打包为 .mcworld
+// worldDir 中的文件（db/、level.dat 等）覆盖原压缩包中的同名文件，其余条目从原压缩包直接复制，不需要解压和重新压缩
+func repackMCWorld(srcArchive, worldDir, outPath string) error {
+	archive, err := openMCWorldArchive(srcArchive)
+	if err != nil {
+		return err
+	}
+	defer archive.Close()
+
+	return writeMCWorldAtomically(outPath, func(zw *zip.Writer) error {
+		// 先写入修改后的世界数据
+		written, err := addWorldDirToZip(zw, worldDir, mcworldCompressLevel)
+		if err != nil {
+			return err
+		}
+
+		// 再原样复制其余条目；旧的 db/ 文件可能已被压缩合并，不能复制回去
+		for _, f := range archive.zr.File {
+			rel, ok := archive.relPath(f)
+			if !ok || f.FileInfo().IsDir() || written[rel] || strings.HasPrefix(rel, "db/") || isMCWorldJunk(rel) {
+				continue
+			}
+			header := f.FileHeader
+			header.Name = rel
+			r, err := f.OpenRaw()
+			if err != nil {
+				return err
+			}
+			w, err := zw.CreateRaw(&header)
+			if err != nil {
+				return err
+			}
+			if _, err := io.Copy(w, r); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,332 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/flate"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// mcworldCompressLevel 打包 .mcworld 时使用的压缩级别（0 表示只存储不压缩）
+var mcworldCompressLevel = flate.DefaultCompression
+
+// mcworldJunkNames 打包时跳过的文件：LevelDB 锁文件和操作系统生成的文件
+var mcworldJunkNames = map[string]bool{
+	"LOCK":        true,
+	".DS_Store":   true,
+	"Thumbs.db":   true,
+	"desktop.ini": true,
+}
+
+// isMCWorldJunk 判断世界内的相对路径（使用 /）是否为不应打包的文件
+func isMCWorldJunk(rel string) bool {
+	for _, part := range strings.Split(rel, "/") {
+		if part == "__MACOSX" {
+			return true
+		}
+	}
+	base := path.Base(rel)
+	return mcworldJunkNames[base] || strings.HasPrefix(base, "._")
+}
+
+// parseCompressLevel 解析压缩级别：0-9，或 store（不压缩）
+func parseCompressLevel(s string) (int, error) {
+	if strings.EqualFold(s, "store") {
+		return flate.NoCompression, nil
+	}
+	level, err := strconv.Atoi(s)
+	if err != nil || level < flate.NoCompression || level > flate.BestCompression {
+		return 0, fmt.Errorf("压缩级别必须是 0-9 或 store: %s", s)
+	}
+	return level, nil
+}
+
+// newMCWorldZipWriter 创建使用指定压缩级别的 zip 写入器
+func newMCWorldZipWriter(w io.Writer, level int) *zip.Writer {
+	zw := zip.NewWriter(w)
+	zw.RegisterCompressor(zip.Deflate, func(out io.Writer) (io.WriteCloser, error) {
+		return flate.NewWriter(out, level)
+	})
+	return zw
+}
+
+// mcworldFileHeader 返回世界内文件的 zip 文件头，压缩级别为 0 时只存储
+func mcworldFileHeader(rel string, info os.FileInfo, level int) (*zip.FileHeader, error) {
+	header, err := zip.FileInfoHeader(info)
+	if err != nil {
+		return nil, err
+	}
+	header.Name = rel
+	header.Method = zip.Deflate
+	if level == flate.NoCompression {
+		header.Method = zip.Store
+	}
+	return header, nil
+}
+
+// archiveDirAsMCWorld 将世界目录打包为 .mcworld
+// level.dat 和 db/ 位于压缩包根目录，路径使用 /，跳过锁文件等无关文件
+func archiveDirAsMCWorld(worldDir string, outPath string) error {
+	if _, err := os.Stat(filepath.Join(worldDir, "level.dat")); err != nil {
+		return fmt.Errorf("世界目录中缺少 level.dat: %w", err)
+	}
+	return writeMCWorldAtomically(outPath, func(zw *zip.Writer) error {
+		_, err := addWorldDirToZip(zw, worldDir, mcworldCompressLevel)
+		return err
+	})
+}
+
+// writeMCWorldAtomically 先写入同目录的临时文件，成功后再替换 outPath，避免留下不完整的压缩包
+func writeMCWorldAtomically(outPath string, write func(zw *zip.Writer) error) error {
+	if info, err := os.Stat(outPath); err == nil && info.IsDir() {
+		return fmt.Errorf("输出路径是一个目录: %s", outPath)
+	}
+	if err := os.MkdirAll(filepath.Dir(outPath), 0755); err != nil {
+		return err
+	}
+	tmpFile, err := os.CreateTemp(filepath.Dir(outPath), ".fatalder-pack-*.mcworld")
+	if err != nil {
+		return err
+	}
+	tmpPath := tmpFile.Name()
+	defer os.Remove(tmpPath)
+
+	zw := newMCWorldZipWriter(tmpFile, mcworldCompressLevel)
+	if err := write(zw); err != nil {
+		_ = tmpFile.Close()
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		_ = tmpFile.Close()
+		return err
+	}
+	if err := tmpFile.Close(); err != nil {
+		return err
+	}
+	// 直接覆盖目标文件，替换是原子的：失败时原文件保持不变
+	return os.Rename(tmpPath, outPath)
+}
+
+// addWorldDirToZip 将世界目录中的文件写入压缩包根目录，返回写入的相对路径
+func addWorldDirToZip(zw *zip.Writer, worldDir string, level int) (map[string]bool, error) {
+	written := make(map[string]bool)
+	err := filepath.WalkDir(worldDir, func(p string, d os.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(worldDir, p)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if isMCWorldJunk(rel) {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		header, err := mcworldFileHeader(rel, info, level)
+		if err != nil {
+			return err
+		}
+		w, err := zw.CreateHeader(header)
+		if err != nil {
+			return err
+		}
+		f, err := os.Open(p)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		if _, err := io.Copy(w, f); err != nil {
+			return err
+		}
+		written[rel] = true
+		return nil
+	})
+	return written, err
+}
+
+// packMCWorld 将世界目录打包为 .mcworld（pack 命令）
+func packMCWorld(worldDir, outPath string) error {
+	info, err := os.Stat(worldDir)
+	if err != nil {
+		return fmt.Errorf("无法访问世界目录: %w", err)
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("不是目录: %s", worldDir)
+	}
+	if filepath.Base(worldDir) == "db" {
+		worldDir = filepath.Dir(worldDir)
+	}
+	if outPath == "" {
+		outPath = strings.TrimSuffix(filepath.Clean(worldDir), string(os.PathSeparator)) + ".mcworld"
+	}
+	if !strings.HasSuffix(strings.ToLower(outPath), ".mcworld") {
+		outPath += ".mcworld"
+	}
+
+	fmt.Printf("正在打包: %s\n", worldDir)
+	if err := archiveDirAsMCWorld(worldDir, outPath); err != nil {
+		return fmt.Errorf("打包失败: %w", err)
+	}
+	fmt.Printf("已写入: %s\n", outPath)
+	fmt.Println()
+	return validateMCWorld(outPath)
+}
+
+// mcworldIssue .mcworld 检查发现的问题
+type mcworldIssue struct {
+	Fatal   bool // true 表示游戏会拒绝导入，false 只是警告
+	Message string
+}
+
+// checkMCWorldArchive 检查 .mcworld 压缩包是否能被游戏导入
+func checkMCWorldArchive(mcworldPath string) ([]mcworldIssue, error) {
+	zr, err := zip.OpenReader(mcworldPath)
+	if err != nil {
+		return nil, fmt.Errorf("不是有效的 zip 压缩包: %w", err)
+	}
+	defer zr.Close()
+
+	var issues []mcworldIssue
+	fatal := func(format string, args ...any) {
+		issues = append(issues, mcworldIssue{Fatal: true, Message: fmt.Sprintf(format, args...)})
+	}
+	warn := func(format string, args ...any) {
+		issues = append(issues, mcworldIssue{Message: fmt.Sprintf(format, args...)})
+	}
+
+	files := make(map[string]*zip.File)
+	var junk []string
+	for _, f := range zr.File {
+		name := f.Name
+		if strings.Contains(name, "\\") {
+			fatal("路径使用了反斜杠: %s", name)
+			name = strings.ReplaceAll(name, "\\", "/")
+		}
+		if strings.HasPrefix(name, "/") || strings.Contains("/"+name+"/", "/../") {
+			fatal("非法路径: %s", f.Name)
+		}
+		if f.Flags&0x1 != 0 {
+			fatal("文件设置了密码: %s", f.Name)
+		}
+		if f.Method != zip.Store && f.Method != zip.Deflate {
+			fatal("不支持的压缩方式 %d: %s", f.Method, f.Name)
+		}
+		if f.FileInfo().IsDir() {
+			continue
+		}
+		if files[name] != nil {
+			fatal("重复的文件: %s", name)
+		}
+		files[name] = f
+		if isMCWorldJunk(name) {
+			junk = append(junk, name)
+		}
+	}
+
+	// level.dat 必须位于根目录
+	levelDat := files["level.dat"]
+	if levelDat == nil {
+		nested := ""
+		for name := range files {
+			if path.Base(name) == "level.dat" && (nested == "" || len(name) < len(nested)) {
+				nested = name
+			}
+		}
+		if nested != "" {
+			fatal("level.dat 不在压缩包根目录（位于 %s），需要去掉外层目录重新打包", nested)
+		} else {
+			fatal("缺少 level.dat")
+		}
+	} else if err := checkLevelDatHeader(levelDat); err != nil {
+		fatal("level.dat 损坏: %v", err)
+	}
+
+	// db/ 必须包含 CURRENT 和它指向的 MANIFEST
+	if current := files["db/CURRENT"]; current == nil {
+		fatal("缺少 db/CURRENT")
+	} else if data, err := readZipFile(current); err != nil {
+		fatal("无法读取 db/CURRENT: %v", err)
+	} else if bytes.HasPrefix(data, neteaseEncryptedMagic) {
+		fatal("数据库已加密（网易版），需要先解密")
+	} else {
+		manifest := strings.TrimSpace(string(data))
+		if !strings.HasPrefix(manifest, "MANIFEST-") {
+			fatal("db/CURRENT 内容无效: %q", manifest)
+		} else if files["db/"+manifest] == nil {
+			fatal("db/CURRENT 指向的 %s 不存在", manifest)
+		}
+	}
+
+	if _, state, _, err := detectMCWorldCrypt(mcworldPath); err == nil && state == cryptStateMixed {
+		fatal("数据库部分文件已加密，存档可能在加密/解密过程中被中断")
+	}
+	if len(junk) > 0 {
+		warn("包含 %d 个无关文件（如 %s），建议重新打包", len(junk), junk[0])
+	}
+	if files["levelname.txt"] == nil {
+		warn("缺少 levelname.txt，游戏中将显示 level.dat 中的名称")
+	}
+	return issues, nil
+}
+
+// checkLevelDatHeader 检查 level.dat 的 8 字节文件头（版本 + 数据长度）
+func checkLevelDatHeader(f *zip.File) error {
+	data, err := readZipFile(f)
+	if err != nil {
+		return err
+	}
+	if len(data) < 8 {
+		return fmt.Errorf("文件过短 (%d 字节)", len(data))
+	}
+	length := binary.LittleEndian.Uint32(data[4:8])
+	if int(length) != len(data)-8 {
+		return fmt.Errorf("文件头记录的长度 %d 与实际长度 %d 不符", length, len(data)-8)
+	}
+	return nil
+}
+
+// readZipFile 读取压缩包条目的全部内容
+func readZipFile(f *zip.File) ([]byte, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	return io.ReadAll(rc)
+}
+
+// validateMCWorld 检查 .mcworld 并输出结果，存在会导致导入失败的问题时返回错误
+func validateMCWorld(mcworldPath string) error {
+	issues, err := checkMCWorldArchive(mcworldPath)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("检查: %s\n", mcworldPath)
+	fatalCount := 0
+	for _, issue := range issues {
+		if issue.Fatal {
+			fatalCount++
+			fmt.Printf("  ✗ %s\n", issue.Message)
+		} else {
+			fmt.Printf("  ! %s\n", issue.Message)
+		}
+	}
+	if fatalCount > 0 {
+		return fmt.Errorf("发现 %d 个会导致导入失败的问题", fatalCount)
+	}
+	fmt.Println("  ✓ 可以导入游戏")
+	return nil
+}
@@ -0,0 +1,273 @@
+package block_entity
+
+import (
+	"strings"
+
+	"github.com/Yeah114/blocks"
+)
+
+// bedrock enchantment ids, java uses the names (with namespace)
+var enchantmentIDs = map[string]int16{
+	"protection":            0,
+	"fire_protection":       1,
+	"feather_falling":       2,
+	"blast_protection":      3,
+	"projectile_protection": 4,
+	"thorns":                5,
+	"respiration":           6,
+	"depth_strider":         7,
+	"aqua_affinity":         8,
+	"sharpness":             9,
+	"smite":                 10,
+	"bane_of_arthropods":    11,
+	"knockback":             12,
+	"fire_aspect":           13,
+	"looting":               14,
+	"efficiency":            15,
+	"silk_touch":            16,
+	"unbreaking":            17,
+	"fortune":               18,
+	"power":                 19,
+	"punch":                 20,
+	"flame":                 21,
+	"infinity":              22,
+	"luck_of_the_sea":       23,
+	"lure":                  24,
+	"frost_walker":          25,
+	"mending":               26,
+	"binding_curse":         27,
+	"vanishing_curse":       28,
+	"impaling":              29,
+	"riptide":               30,
+	"loyalty":               31,
+	"channeling":            32,
+	"multishot":             33,
+	"piercing":              34,
+	"quick_charge":          35,
+	"soul_speed":            36,
+	"swift_sneak":           37,
+	"wind_burst":            38,
+	"density":               39,
+	"breach":                40,
+}
+
+var enchantmentNames = func() map[int16]string {
+	names := make(map[int16]string, len(enchantmentIDs))
+	for name, id := range enchantmentIDs {
+		names[id] = name
+	}
+	return names
+}()
+
+// JavaItemToBedrock translates a java item stack (`id`/`Count`/`tag`, or `id`/`count`/`components` since 1.20.5)
+// to the bedrock layout (`Name`/`Count`/`Damage`/`tag`). Items already in the bedrock layout are returned unchanged.
+// Item ids use the netease names and data values, matching the blocks of MC_CURRENT, newer bedrock versions upgrade them when loading
+func JavaItemToBedrock(item map[string]any) map[string]any {
+	javaName, ok := item["id"].(string)
+	if !ok {
+		return item
+	}
+	name, data, _ := blocks.JavaItemToNetEase(javaName)
+	// 1.20.5+ omits count when it is 1, including stacks in minecraft:container
+	count, ok := numberOf(item["Count"])
+	if !ok {
+		if count, ok = numberOf(item["count"]); !ok {
+			count = 1
+		}
+	}
+	out := map[string]any{
+		"Name":        name,
+		"Count":       uint8(count),
+		"Damage":      data,
+		"WasPickedUp": uint8(0),
+	}
+	if slot, ok := numberOf(item["Slot"]); ok {
+		out["Slot"] = uint8(slot)
+	}
+
+	tag := map[string]any{}
+	if javaTag, ok := item["tag"].(map[string]any); ok {
+		if damage, ok := numberOf(javaTag["Damage"]); ok && damage > 0 {
+			tag["Damage"] = int32(damage)
+		}
+		if display, ok := javaTag["display"].(map[string]any); ok {
+			if d := javaDisplayToBedrock(display["Name"], display["Lore"]); d != nil {
+				tag["display"] = d
+			}
+		}
+		if ench := javaEnchantmentsToBedrock(javaTag["Enchantments"]); ench != nil {
+			tag["ench"] = ench
+		}
+		if items, ok := javaTag["BlockEntityTag"].(map[string]any); ok {
+			if list := translateItemList(items["Items"], JavaItemToBedrock); list != nil {
+				tag["Items"] = list
+			}
+		}
+	}
+	if components, ok := item["components"].(map[string]any); ok {
+		if damage, ok := numberOf(components["minecraft:damage"]); ok && damage > 0 {
+			tag["Damage"] = int32(damage)
+		}
+		if d := javaDisplayToBedrock(components["minecraft:custom_name"], components["minecraft:lore"]); d != nil {
+			tag["display"] = d
+		}
+		if enchantments, ok := components["minecraft:enchantments"].(map[string]any); ok {
+			levels := enchantments
+			if l, ok := enchantments["levels"].(map[string]any); ok {
+				levels = l
+			}
+			var list []any
+			for name, lvl := range levels {
+				if level, ok := numberOf(lvl); ok {
+					list = append(list, map[string]any{"id": name, "lvl": int16(level)})
+				}
+			}
+			if ench := javaEnchantmentsToBedrock(list); ench != nil {
+				tag["ench"] = ench
+			}
+		}
+		if container, ok := components["minecraft:container"].([]any); ok {
+			var list []any
+			for _, e := range container {
+				slotted, ok := e.(map[string]any)
+				if !ok {
+					continue
+				}
+				if stack, ok := slotted["item"].(map[string]any); ok {
+					bedrock := JavaItemToBedrock(stack)
+					if slot, ok := numberOf(slotted["slot"]); ok {
+						bedrock["Slot"] = uint8(slot)
+					}
+					list = append(list, bedrock)
+				}
+			}
+			if list != nil {
+				tag["Items"] = list
+			}
+		}
+	}
+	if len(tag) > 0 {
+		out["tag"] = tag
+	}
+	return out
+}
+
+// BedrockItemToJava translates a bedrock item stack to the java 1.20.4 layout (`id`/`Count`/`tag`),
+// which newer java versions upgrade when loading. Items already in the java layout are returned unchanged
+func BedrockItemToJava(item map[string]any) map[string]any {
+	bedrockName, ok := item["Name"].(string)
+	if !ok {
+		return item
+	}
+	data, _ := numberOf(item["Damage"])
+	name, _ := blocks.BedrockItemToJava(bedrockName, int16(data))
+	count, _ := numberOf(item["Count"])
+	out := map[string]any{
+		"id":    name,
+		"Count": uint8(count),
+	}
+	if slot, ok := numberOf(item["Slot"]); ok {
+		out["Slot"] = uint8(slot)
+	}
+
+	tag := map[string]any{}
+	if bedrockTag, ok := item["tag"].(map[string]any); ok {
+		if damage, ok := numberOf(bedrockTag["Damage"]); ok && damage > 0 {
+			tag["Damage"] = int32(damage)
+		}
+		if display, ok := bedrockTag["display"].(map[string]any); ok {
+			javaDisplay := map[string]any{}
+			if name, ok := display["Name"].(string); ok {
+				javaDisplay["Name"] = JSONText(name)
+			}
+			if lore, ok := display["Lore"].([]any); ok {
+				lines := make([]any, 0, len(lore))
+				for _, line := range lore {
+					if s, ok := line.(string); ok {
+						lines = append(lines, JSONText(s))
+					}
+				}
+				javaDisplay["Lore"] = lines
+			}
+			if len(javaDisplay) > 0 {
+				tag["display"] = javaDisplay
+			}
+		}
+		if ench, ok := bedrockTag["ench"].([]any); ok {
+			var list []any
+			for _, e := range ench {
+				m, ok := e.(map[string]any)
+				if !ok {
+					continue
+				}
+				id, _ := numberOf(m["id"])
+				lvl, _ := numberOf(m["lvl"])
+				if name, ok := enchantmentNames[int16(id)]; ok {
+					list = append(list, map[string]any{"id": "minecraft:" + name, "lvl": int16(lvl)})
+				}
+			}
+			if list != nil {
+				tag["Enchantments"] = list
+			}
+		}
+		if list := translateItemList(bedrockTag["Items"], BedrockItemToJava); list != nil {
+			tag["BlockEntityTag"] = map[string]any{"Items": list}
+		}
+	}
+	if len(tag) > 0 {
+		out["tag"] = tag
+	}
+	return out
+}
+
+func javaDisplayToBedrock(name, lore any) map[string]any {
+	display := map[string]any{}
+	if name != nil {
+		display["Name"] = PlainText(name)
+	}
+	if lines, ok := lore.([]any); ok {
+		plain := make([]any, 0, len(lines))
+		for _, line := range lines {
+			plain = append(plain, PlainText(line))
+		}
+		display["Lore"] = plain
+	}
+	if len(display) == 0 {
+		return nil
+	}
+	return display
+}
+
+func javaEnchantmentsToBedrock(enchantments any) []any {
+	list, ok := enchantments.([]any)
+	if !ok {
+		return nil
+	}
+	var ench []any
+	for _, e := range list {
+		m, ok := e.(map[string]any)
+		if !ok {
+			continue
+		}
+		name, _ := m["id"].(string)
+		lvl, _ := numberOf(m["lvl"])
+		if id, ok := enchantmentIDs[strings.TrimPrefix(name, "minecraft:")]; ok {
+			ench = append(ench, map[string]any{"id": id, "lvl": int16(lvl)})
+		}
+	}
+	return ench
+}
+
+func translateItemList(items any, translate func(map[string]any) map[string]any) []any {
+	list, ok := items.([]any)
+	if !ok {
+		return nil
+	}
+	out := make([]any, 0, len(list))
+	for _, e := range list {
+		if m, ok := e.(map[string]any); ok {
+			out = append(out, translate(m))
+		}
+	}
+	return out
+}
@@ -0,0 +1,61 @@
+package block_entity
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// PlainText converts a java text component (a json string, or a compound/list since java 1.21.5)
+// to plain text, strings which are not json are returned as is
+func PlainText(component any) string {
+	switch c := component.(type) {
+	case string:
+		trimmed := strings.TrimSpace(c)
+		if trimmed == "" || !strings.ContainsAny(trimmed[:1], `{["`) {
+			return c
+		}
+		var parsed any
+		if err := json.Unmarshal([]byte(trimmed), &parsed); err != nil {
+			return c
+		}
+		return componentText(parsed)
+	default:
+		return componentText(c)
+	}
+}
+
+func componentText(component any) string {
+	switch c := component.(type) {
+	case string:
+		return c
+	case []any:
+		var sb strings.Builder
+		for _, e := range c {
+			sb.WriteString(componentText(e))
+		}
+		return sb.String()
+	case map[string]any:
+		var sb strings.Builder
+		if text, ok := c["text"]; ok {
+			sb.WriteString(componentText(text))
+		} else if key, ok := c["translate"].(string); ok {
+			sb.WriteString(key)
+		} else if selector, ok := c["selector"].(string); ok {
+			sb.WriteString(selector)
+		}
+		if extra, ok := c["extra"]; ok {
+			sb.WriteString(componentText(extra))
+		}
+		return sb.String()
+	case nil:
+		return ""
+	default:
+		return ""
+	}
+}
+
+// JSONText converts plain text to a java json text component
+func JSONText(text string) string {
+	data, _ := json.Marshal(map[string]string{"text": text})
+	return string(data)
+}
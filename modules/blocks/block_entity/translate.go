@@ -0,0 +1,626 @@
+// Package block_entity translates block entity NBT between the java and bedrock layouts.
+// Java output uses the 1.20.4 layout, which newer java versions upgrade when loading;
+// java input may be in the pre-1.13 (MCEdit), 1.13 ~ 1.20.4 or the 1.20.5+ (item components) layout.
+package block_entity
+
+import (
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/Yeah114/blocks"
+	"github.com/Yeah114/blocks/describe"
+)
+
+// bedrock block entity id -> java block entity ids, the first one is used if the block does not decide
+var bedrockToJavaIDs = map[string][]string{
+	"Banner":                {"banner"},
+	"Barrel":                {"barrel"},
+	"Beacon":                {"beacon"},
+	"Bed":                   {"bed"},
+	"Beehive":               {"beehive"},
+	"Bell":                  {"bell"},
+	"BlastFurnace":          {"blast_furnace"},
+	"BrewingStand":          {"brewing_stand"},
+	"BrushableBlock":        {"brushable_block"},
+	"CalibratedSculkSensor": {"calibrated_sculk_sensor"},
+	"Campfire":              {"campfire"},
+	"Chest":                 {"chest", "trapped_chest"},
+	"ChiseledBookshelf":     {"chiseled_bookshelf"},
+	"CommandBlock":          {"command_block"},
+	"Comparator":            {"comparator"},
+	"Conduit":               {"conduit"},
+	"Crafter":               {"crafter"},
+	"DaylightDetector":      {"daylight_detector"},
+	"DecoratedPot":          {"decorated_pot"},
+	"Dispenser":             {"dispenser"},
+	"Dropper":               {"dropper"},
+	"EnchantTable":          {"enchanting_table"},
+	"EndGateway":            {"end_gateway"},
+	"EndPortal":             {"end_portal"},
+	"EnderChest":            {"ender_chest"},
+	"Furnace":               {"furnace"},
+	"HangingSign":           {"hanging_sign"},
+	"Hopper":                {"hopper"},
+	"JigsawBlock":           {"jigsaw"},
+	"Jukebox":               {"jukebox"},
+	"Lectern":               {"lectern"},
+	"MobSpawner":            {"mob_spawner"},
+	"PistonArm":             {"piston"},
+	"SculkCatalyst":         {"sculk_catalyst"},
+	"SculkSensor":           {"sculk_sensor"},
+	"SculkShrieker":         {"sculk_shrieker"},
+	"ShulkerBox":            {"shulker_box"},
+	"Sign":                  {"sign"},
+	"Skull":                 {"skull"},
+	"Smoker":                {"smoker"},
+	"StructureBlock":        {"structure_block"},
+	"TrialSpawner":          {"trial_spawner"},
+	"Vault":                 {"vault"},
+}
+
+// java block entity id (lower case, without namespace) -> bedrock block entity id,
+// including the CamelCase ids of pre-1.13 java and the bedrock ids themselves
+var javaToBedrockIDs = func() map[string]string {
+	ids := map[string]string{
+		"control":       "CommandBlock",
+		"trap":          "Dispenser",
+		"music":         "Music",
+		"noteblock":     "Music",
+		"recordplayer":  "Jukebox",
+		"airportal":     "EndPortal",
+		"dldetector":    "DaylightDetector",
+		"enchanttable":  "EnchantTable",
+		"cauldron":      "BrewingStand",
+		"flowerpot":     "FlowerPot",
+		"flower_pot":    "FlowerPot",
+		"ender_chest":   "EnderChest",
+		"enchant_table": "EnchantTable",
+	}
+	for bedrock, javaIDs := range bedrockToJavaIDs {
+		ids[strings.ToLower(bedrock)] = bedrock
+		for _, java := range javaIDs {
+			ids[java] = bedrock
+		}
+	}
+	return ids
+}()
+
+// bedrock sign text colors (ARGB) of the java dye colors
+var signTextColors = map[string]int32{
+	"white":      -986896,
+	"orange":     -425955,
+	"magenta":    -3715395,
+	"light_blue": -12930086,
+	"yellow":     -75715,
+	"lime":       -8337633,
+	"pink":       -816214,
+	"gray":       -12103854,
+	"light_gray": -6447721,
+	"cyan":       -15295332,
+	"purple":     -7785800,
+	"blue":       -12827478,
+	"brown":      -8170446,
+	"green":      -10585066,
+	"red":        -5231066,
+	"black":      -16777216,
+}
+
+// java dye colors in the order of their ids, bedrock banners use the reverse order
+var dyeColors = []string{
+	"white", "orange", "magenta", "light_blue", "yellow", "lime", "pink", "gray",
+	"light_gray", "cyan", "purple", "blue", "brown", "green", "red", "black",
+}
+
+// java 1.20.5+ banner pattern names -> pattern codes used by bedrock and older java
+var bannerPatternCodes = map[string]string{
+	"base": "b", "stripe_bottom": "bs", "stripe_top": "ts", "stripe_left": "ls", "stripe_right": "rs",
+	"stripe_center": "cs", "stripe_middle": "ms", "stripe_downright": "drs", "stripe_downleft": "dls",
+	"small_stripes": "ss", "cross": "cr", "straight_cross": "sc", "triangle_bottom": "bt", "triangle_top": "tt",
+	"triangles_bottom": "bts", "triangles_top": "tts", "diagonal_left": "ld", "diagonal_up_right": "rd",
+	"diagonal_up_left": "lud", "diagonal_right": "rud", "circle": "mc", "rhombus": "mr",
+	"half_vertical": "vh", "half_horizontal": "hh", "half_vertical_right": "vhr",
+	"half_horizontal_bottom": "hhb", "border": "bo", "curly_border": "cbo", "gradient": "gra",
+	"gradient_up": "gru", "bricks": "bri", "globe": "glb", "creeper": "cre", "skull": "sku",
+	"flower": "flo", "mojang": "moj", "piglin": "pig", "flow": "flw", "guster": "gus",
+}
+
+// bedrock skull types by block name
+var skullTypes = map[string]uint8{
+	"skeleton_skull":        0,
+	"wither_skeleton_skull": 1,
+	"zombie_head":           2,
+	"player_head":           3,
+	"creeper_head":          4,
+	"dragon_head":           5,
+	"piglin_head":           6,
+}
+
+var skullNames = func() map[uint8]string {
+	names := make(map[uint8]string, len(skullTypes))
+	for name, t := range skullTypes {
+		names[t] = name
+	}
+	return names
+}()
+
+// bedrockCommandVersion is the command version written to command blocks,
+// newer games upgrade the commands of older versions when loading
+const bedrockCommandVersion = 36
+
+// JavaToBedrock translates a java block entity to the bedrock layout, block is the bedrock block at its position
+// (may be nil) and javaBlockName the java block it was converted from ("" if unknown), which decides the skull
+// type and the banner base color. Unknown keys are kept, block entities already in the bedrock layout are returned unchanged
+func JavaToBedrock(javaNBT map[string]any, block *describe.Block, javaBlockName string) map[string]any {
+	if _, ok := javaNBT["isMovable"]; ok {
+		// written by bedrock
+		return javaNBT
+	}
+	rawID, _ := javaNBT["id"].(string)
+	id, ok := javaToBedrockIDs[strings.ToLower(strings.TrimPrefix(rawID, "minecraft:"))]
+	if !ok {
+		return javaNBT
+	}
+	legacy := !strings.Contains(rawID, ":")
+	out := make(map[string]any, len(javaNBT))
+	for k, v := range javaNBT {
+		out[k] = v
+	}
+	out["id"] = id
+	out["isMovable"] = uint8(1)
+
+	if name, ok := out["CustomName"]; ok {
+		out["CustomName"] = PlainText(name)
+	}
+	if list := translateItemList(out["Items"], JavaItemToBedrock); list != nil {
+		out["Items"] = list
+	}
+	switch id {
+	case "Sign", "HangingSign":
+		javaSignToBedrock(out)
+	case "CommandBlock":
+		javaCommandBlockToBedrock(out, block)
+	case "Banner":
+		javaBannerToBedrock(out, legacy, javaBlockName)
+	case "Skull":
+		javaSkullToBedrock(out, block, javaBlockName, legacy)
+	case "Jukebox":
+		if record, ok := out["RecordItem"].(map[string]any); ok {
+			out["RecordItem"] = JavaItemToBedrock(record)
+		}
+	case "Lectern":
+		if book, ok := out["Book"].(map[string]any); ok {
+			out["book"] = JavaItemToBedrock(book)
+			delete(out, "Book")
+		}
+	}
+	return out
+}
+
+// BedrockToJava translates a bedrock block entity to the java layout, javaBlock is the java block at its position
+// (name with optional [states], "" if unknown), used to pick between java ids sharing a bedrock id (e.g. chest and
+// trapped_chest). Java keeps the skull type and rotation and the banner base color in the block instead, so the
+// java block is returned with them applied (e.g. creeper_head[rotation=4], red_banner), other blocks unchanged
+func BedrockToJava(bedrockNBT map[string]any, javaBlock string) (javaNBT map[string]any, block string) {
+	id, _ := bedrockNBT["id"].(string)
+	javaIDs, ok := bedrockToJavaIDs[id]
+	if !ok {
+		return bedrockNBT, javaBlock
+	}
+	out := make(map[string]any, len(bedrockNBT))
+	for k, v := range bedrockNBT {
+		out[k] = v
+	}
+	delete(out, "isMovable")
+	javaID := javaIDs[0]
+	javaBlockName, _ := blocks.ParseJavaBlockString(javaBlock)
+	blockName := strings.TrimPrefix(javaBlockName, "minecraft:")
+	for _, candidate := range javaIDs {
+		if candidate == blockName {
+			javaID = candidate
+		}
+	}
+	out["id"] = "minecraft:" + javaID
+
+	if name, ok := out["CustomName"].(string); ok {
+		out["CustomName"] = JSONText(name)
+	}
+	if list := translateItemList(out["Items"], BedrockItemToJava); list != nil {
+		out["Items"] = list
+	}
+	switch id {
+	case "Sign", "HangingSign":
+		bedrockSignToJava(out)
+	case "CommandBlock":
+		bedrockCommandBlockToJava(out)
+	case "Banner":
+		javaBlock = bedrockBannerBlock(out, javaBlock)
+		bedrockBannerToJava(out)
+	case "Skull":
+		javaBlock = bedrockSkullBlock(out, javaBlock)
+		for _, k := range []string{"SkullType", "Rotation", "MouthMoving", "MouthTickCount", "DoingAnimation"} {
+			delete(out, k)
+		}
+	case "Jukebox":
+		if record, ok := out["RecordItem"].(map[string]any); ok {
+			out["RecordItem"] = BedrockItemToJava(record)
+		}
+	case "Lectern":
+		if book, ok := out["book"].(map[string]any); ok {
+			out["Book"] = BedrockItemToJava(book)
+			delete(out, "book")
+		}
+	}
+	return out, javaBlock
+}
+
+func javaSignToBedrock(out map[string]any) {
+	side := func(text map[string]any) map[string]any {
+		var lines []string
+		if messages, ok := text["messages"].([]any); ok {
+			for _, m := range messages {
+				lines = append(lines, PlainText(m))
+			}
+		}
+		color, _ := text["color"].(string)
+		glowing, _ := numberOf(text["has_glowing_text"])
+		return bedrockSignText(lines, color, glowing != 0)
+	}
+
+	if front, ok := out["front_text"].(map[string]any); ok {
+		out["FrontText"] = side(front)
+		delete(out, "front_text")
+	} else if _, ok := out["Text1"]; ok {
+		// before java 1.20
+		var lines []string
+		for _, k := range []string{"Text1", "Text2", "Text3", "Text4"} {
+			lines = append(lines, PlainText(out[k]))
+			delete(out, k)
+		}
+		color, _ := out["Color"].(string)
+		glowing, _ := numberOf(out["GlowingText"])
+		out["FrontText"] = bedrockSignText(lines, color, glowing != 0)
+		delete(out, "Color")
+		delete(out, "GlowingText")
+	}
+	if back, ok := out["back_text"].(map[string]any); ok {
+		out["BackText"] = side(back)
+		delete(out, "back_text")
+	}
+	if _, ok := out["FrontText"]; ok {
+		if _, ok := out["BackText"]; !ok {
+			out["BackText"] = bedrockSignText(nil, "", false)
+		}
+	}
+	if waxed, ok := numberOf(out["is_waxed"]); ok {
+		out["IsWaxed"] = uint8(waxed)
+		delete(out, "is_waxed")
+	}
+}
+
+func bedrockSignText(lines []string, color string, glowing bool) map[string]any {
+	// trailing empty lines are not stored by bedrock
+	for len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	argb, ok := signTextColors[color]
+	if !ok {
+		argb = signTextColors["black"]
+	}
+	return map[string]any{
+		"Text":              strings.Join(lines, "\n"),
+		"SignTextColor":     argb,
+		"IgnoreLighting":    boolByte(glowing),
+		"HideGlowOutline":   uint8(0),
+		"PersistFormatting": uint8(1),
+		"TextOwner":         "",
+	}
+}
+
+func bedrockSignToJava(out map[string]any) {
+	side := func(text map[string]any) map[string]any {
+		s, _ := text["Text"].(string)
+		lines := strings.Split(s, "\n")
+		messages := make([]any, 4)
+		for i := range messages {
+			line := ""
+			if i < len(lines) {
+				line = lines[i]
+			}
+			messages[i] = JSONText(line)
+		}
+		argb, _ := numberOf(text["SignTextColor"])
+		glowing, _ := numberOf(text["IgnoreLighting"])
+		return map[string]any{
+			"messages":         messages,
+			"color":            signColorName(int32(argb)),
+			"has_glowing_text": boolByte(glowing != 0),
+		}
+	}
+
+	if front, ok := out["FrontText"].(map[string]any); ok {
+		out["front_text"] = side(front)
+	} else if text, ok := out["Text"].(string); ok {
+		// before bedrock 1.19.80
+		out["front_text"] = side(map[string]any{"Text": text, "SignTextColor": out["SignTextColor"], "IgnoreLighting": out["IgnoreLighting"]})
+	}
+	if back, ok := out["BackText"].(map[string]any); ok {
+		out["back_text"] = side(back)
+	}
+	if waxed, ok := numberOf(out["IsWaxed"]); ok {
+		out["is_waxed"] = uint8(waxed)
+	}
+	for _, k := range []string{"FrontText", "BackText", "IsWaxed", "Text", "SignTextColor", "IgnoreLighting", "TextOwner", "PersistFormatting", "HideGlowOutline"} {
+		delete(out, k)
+	}
+}
+
+// signColorName returns the dye color closest to the bedrock sign text color
+func signColorName(argb int32) string {
+	best, bestDistance := "black", -1
+	for name, c := range signTextColors {
+		d := 0
+		for shift := 0; shift < 24; shift += 8 {
+			diff := int(uint32(c)>>shift&0xff) - int(uint32(argb)>>shift&0xff)
+			d += diff * diff
+		}
+		if bestDistance < 0 || d < bestDistance || d == bestDistance && name < best {
+			best, bestDistance = name, d
+		}
+	}
+	return best
+}
+
+func javaCommandBlockToBedrock(out map[string]any, block *describe.Block) {
+	mode, conditional := int32(0), false
+	if block != nil {
+		switch block.ShortName() {
+		case "repeating_command_block":
+			mode = 1
+		case "chain_command_block":
+			mode = 2
+		}
+		for _, p := range block.States() {
+			if p.Name == "conditional_bit" && p.Value.HasType(describe.PropValTypeUint8) {
+				conditional = p.Value.Uint8Val() != 0
+			}
+		}
+	}
+	auto, _ := numberOf(out["auto"])
+	if output, ok := out["LastOutput"]; ok {
+		out["LastOutput"] = PlainText(output)
+	}
+	if _, ok := out["CustomName"]; !ok {
+		out["CustomName"] = ""
+	}
+	setDefault(out, "Command", "")
+	setDefault(out, "TrackOutput", uint8(1))
+	setDefault(out, "SuccessCount", int32(0))
+	setDefault(out, "powered", uint8(0))
+	setDefault(out, "conditionMet", uint8(0))
+	setDefault(out, "TickDelay", int32(0))
+	setDefault(out, "ExecuteOnFirstTick", uint8(1))
+	out["auto"] = uint8(auto)
+	out["conditionalMode"] = boolByte(conditional)
+	out["LPCommandMode"] = mode
+	out["LPCondionalMode"] = boolByte(conditional)
+	out["LPRedstoneMode"] = uint8(auto)
+	setDefault(out, "Version", int32(bedrockCommandVersion))
+	delete(out, "UpdateLastExecution")
+}
+
+func bedrockCommandBlockToJava(out map[string]any) {
+	if output, ok := out["LastOutput"].(string); ok && output != "" {
+		out["LastOutput"] = JSONText(output)
+	} else {
+		delete(out, "LastOutput")
+	}
+	for _, k := range []string{"conditionalMode", "LPCommandMode", "LPCondionalMode", "LPRedstoneMode", "Version", "TickDelay", "ExecuteOnFirstTick", "LastOutputParams"} {
+		delete(out, k)
+	}
+	out["UpdateLastExecution"] = uint8(1)
+}
+
+// javaBannerToBedrock translates banner patterns, pre-1.13 java banners (legacy) use dye damage values
+// for colors like bedrock, later versions use color ids in the reverse order
+func javaBannerToBedrock(out map[string]any, legacy bool, javaBlockName string) {
+	bedrockColor := func(c int64) int32 {
+		if legacy {
+			return int32(c)
+		}
+		return int32(15 - c)
+	}
+	// the base color of modern java banners is in the block name (red_banner, red_wall_banner), white if unknown
+	if base, ok := numberOf(out["Base"]); ok && legacy {
+		out["Base"] = int32(base)
+	} else if color, ok := bannerColor(javaBlockName); ok {
+		out["Base"] = bedrockColor(color)
+	} else {
+		out["Base"] = int32(15)
+	}
+	out["Type"] = int32(0)
+
+	var patterns []any
+	if list, ok := out["Patterns"].([]any); ok {
+		for _, e := range list {
+			if m, ok := e.(map[string]any); ok {
+				color, _ := numberOf(m["Color"])
+				code, _ := m["Pattern"].(string)
+				patterns = append(patterns, map[string]any{"Pattern": code, "Color": bedrockColor(color)})
+			}
+		}
+	}
+	if list, ok := out["patterns"].([]any); ok {
+		// java 1.20.5+
+		for _, e := range list {
+			m, ok := e.(map[string]any)
+			if !ok {
+				continue
+			}
+			name, _ := m["pattern"].(string)
+			code, ok := bannerPatternCodes[strings.TrimPrefix(name, "minecraft:")]
+			if !ok {
+				continue
+			}
+			color, _ := m["color"].(string)
+			patterns = append(patterns, map[string]any{"Pattern": code, "Color": bedrockColor(dyeColorID(color))})
+		}
+		delete(out, "patterns")
+	}
+	if patterns != nil {
+		out["Patterns"] = patterns
+	}
+}
+
+func bedrockBannerToJava(out map[string]any) {
+	if list, ok := out["Patterns"].([]any); ok {
+		patterns := make([]any, 0, len(list))
+		for _, e := range list {
+			if m, ok := e.(map[string]any); ok {
+				color, _ := numberOf(m["Color"])
+				patterns = append(patterns, map[string]any{"Pattern": m["Pattern"], "Color": int32(15 - color)})
+			}
+		}
+		out["Patterns"] = patterns
+	}
+	delete(out, "Base")
+	delete(out, "Type")
+}
+
+// bannerColor returns the java color id of a banner block name
+func bannerColor(javaBlockName string) (int64, bool) {
+	name := strings.TrimPrefix(javaBlockName, "minecraft:")
+	color := strings.TrimSuffix(strings.TrimSuffix(name, "_banner"), "_wall")
+	if color == name {
+		return 0, false
+	}
+	for i, c := range dyeColors {
+		if c == color {
+			return int64(i), true
+		}
+	}
+	return 0, false
+}
+
+// bedrockBannerBlock returns the java banner of the bedrock base color, wall banners stay wall banners
+func bedrockBannerBlock(bedrockNBT map[string]any, javaBlock string) string {
+	base, ok := numberOf(bedrockNBT["Base"])
+	if !ok || base < 0 || base > 15 {
+		return javaBlock
+	}
+	name, props := blocks.ParseJavaBlockString(javaBlock)
+	suffix := "_banner"
+	if strings.HasSuffix(name, "_wall_banner") {
+		suffix = "_wall_banner"
+	}
+	return blocks.JavaBlockString("minecraft:"+dyeColors[15-base]+suffix, props)
+}
+
+// bedrockSkullBlock returns the java skull of the bedrock skull type, wall skulls keep their facing and
+// floor skulls get the rotation (16 steps, 0 facing south) of the bedrock Rotation in degrees
+func bedrockSkullBlock(bedrockNBT map[string]any, javaBlock string) string {
+	skullType, _ := numberOf(bedrockNBT["SkullType"])
+	skull, ok := skullNames[uint8(skullType)]
+	if !ok {
+		return javaBlock
+	}
+	name, props := blocks.ParseJavaBlockString(javaBlock)
+	if strings.Contains(name, "_wall_") {
+		i := strings.LastIndex(skull, "_")
+		return blocks.JavaBlockString("minecraft:"+skull[:i]+"_wall"+skull[i:], props)
+	}
+	var degrees float64
+	switch r := bedrockNBT["Rotation"].(type) {
+	case float32:
+		degrees = float64(r)
+	case float64:
+		degrees = r
+	}
+	props["rotation"] = strconv.Itoa((int(math.Round(degrees/22.5))%16 + 16) % 16)
+	return blocks.JavaBlockString("minecraft:"+skull, props)
+}
+
+// LegacyJavaBlockNBT returns the pre-1.13 block entity keys of what later java versions keep in the block:
+// SkullType and Rot of skulls, Base of banners, which MCEdit schematics still use. Nil for other blocks
+func LegacyJavaBlockNBT(javaBlock string) map[string]any {
+	name, props := blocks.ParseJavaBlockString(javaBlock)
+	if color, ok := bannerColor(name); ok {
+		return map[string]any{"Base": int32(15 - color)}
+	}
+	skullType, ok := skullTypes[strings.Replace(strings.TrimPrefix(name, "minecraft:"), "_wall_", "_", 1)]
+	if !ok {
+		return nil
+	}
+	rotation, _ := strconv.Atoi(props["rotation"])
+	return map[string]any{"SkullType": skullType, "Rot": uint8(rotation)}
+}
+
+// javaSkullToBedrock sets the bedrock skull type, bedrock has a single skull block so the type comes from
+// SkullType of pre-1.13 java skulls, the java block name (the wall variants without "_wall") or the bedrock block
+func javaSkullToBedrock(out map[string]any, block *describe.Block, javaBlockName string, legacy bool) {
+	skullType := uint8(0)
+	if t, ok := numberOf(out["SkullType"]); ok && legacy {
+		skullType = uint8(t)
+	} else if t, ok := skullTypes[strings.Replace(strings.TrimPrefix(javaBlockName, "minecraft:"), "_wall_", "_", 1)]; ok {
+		skullType = t
+	} else if block != nil {
+		if t, ok := skullTypes[block.ShortName()]; ok {
+			skullType = t
+		}
+	}
+	if _, ok := out["SkullOwner"]; ok {
+		skullType = skullTypes["player_head"]
+	}
+	if _, ok := out["profile"]; ok {
+		skullType = skullTypes["player_head"]
+	}
+	out["SkullType"] = skullType
+	setDefault(out, "Rotation", float32(0))
+	setDefault(out, "MouthMoving", uint8(0))
+	setDefault(out, "MouthTickCount", int32(0))
+}
+
+func dyeColorID(color string) int64 {
+	for i, c := range dyeColors {
+		if c == color {
+			return int64(i)
+		}
+	}
+	return 0
+}
+
+func setDefault(m map[string]any, key string, value any) {
+	if _, ok := m[key]; !ok {
+		m[key] = value
+	}
+}
+
+func boolByte(b bool) uint8 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func numberOf(v any) (int64, bool) {
+	switch n := v.(type) {
+	case int8:
+		return int64(n), true
+	case uint8:
+		return int64(n), true
+	case int16:
+		return int64(n), true
+	case int32:
+		return int64(n), true
+	case int64:
+		return n, true
+	case int:
+		return int64(n), true
+	case float32:
+		return int64(n), true
+	case float64:
+		return int64(n), true
+	default:
+		return 0, false
+	}
+}
@@ -0,0 +1,253 @@
+package block_entity
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestJavaSignToBedrock(t *testing.T) {
+	out := JavaToBedrock(map[string]any{
+		"id": "minecraft:sign",
+		"front_text": map[string]any{
+			"messages":         []any{`{"text":"Hello"}`, `"World"`, `""`, `""`},
+			"color":            "red",
+			"has_glowing_text": uint8(1),
+		},
+		"is_waxed": uint8(1),
+	}, nil, "")
+	if out["id"] != "Sign" {
+		t.Fatalf("id: got %v", out["id"])
+	}
+	front, _ := out["FrontText"].(map[string]any)
+	if front["Text"] != "Hello\nWorld" {
+		t.Errorf("text: got %q", front["Text"])
+	}
+	if front["SignTextColor"] != signTextColors["red"] || front["IgnoreLighting"] != uint8(1) {
+		t.Errorf("color/glowing: got %v %v", front["SignTextColor"], front["IgnoreLighting"])
+	}
+	if _, ok := out["BackText"]; !ok {
+		t.Error("missing BackText")
+	}
+	if out["IsWaxed"] != uint8(1) {
+		t.Errorf("IsWaxed: got %v", out["IsWaxed"])
+	}
+
+	legacy := JavaToBedrock(map[string]any{"id": "Sign", "Text1": `{"text":"old"}`, "Text2": "", "Text3": "", "Text4": ""}, nil, "")
+	if front, _ := legacy["FrontText"].(map[string]any); front["Text"] != "old" {
+		t.Errorf("pre-1.20 sign: got %v", legacy["FrontText"])
+	}
+}
+
+func TestBedrockSignToJava(t *testing.T) {
+	out, _ := BedrockToJava(map[string]any{
+		"id":        "Sign",
+		"FrontText": map[string]any{"Text": "a\nb", "SignTextColor": signTextColors["blue"], "IgnoreLighting": uint8(0)},
+	}, "minecraft:oak_sign")
+	front, _ := out["front_text"].(map[string]any)
+	want := []any{JSONText("a"), JSONText("b"), JSONText(""), JSONText("")}
+	if !reflect.DeepEqual(front["messages"], want) || front["color"] != "blue" {
+		t.Errorf("got %v", front)
+	}
+	if _, ok := out["FrontText"]; ok {
+		t.Error("bedrock keys kept")
+	}
+}
+
+func TestJavaSkullToBedrock(t *testing.T) {
+	cases := []struct {
+		nbt       map[string]any
+		javaBlock string
+		want      uint8
+	}{
+		{map[string]any{"id": "minecraft:skull"}, "minecraft:skeleton_skull", 0},
+		{map[string]any{"id": "minecraft:skull"}, "minecraft:wither_skeleton_wall_skull", 1},
+		{map[string]any{"id": "minecraft:skull"}, "minecraft:zombie_head", 2},
+		{map[string]any{"id": "minecraft:skull"}, "minecraft:creeper_wall_head", 4},
+		{map[string]any{"id": "minecraft:skull"}, "minecraft:dragon_head", 5},
+		{map[string]any{"id": "minecraft:skull"}, "minecraft:piglin_wall_head", 6},
+		{map[string]any{"id": "minecraft:skull", "profile": map[string]any{"name": "Steve"}}, "minecraft:player_head", 3},
+		{map[string]any{"id": "Skull", "SkullType": uint8(4)}, "", 4},
+	}
+	for _, c := range cases {
+		if got := JavaToBedrock(c.nbt, nil, c.javaBlock)["SkullType"]; got != c.want {
+			t.Errorf("%s %v: got %v, want %v", c.javaBlock, c.nbt, got, c.want)
+		}
+	}
+}
+
+func TestJavaBannerToBedrock(t *testing.T) {
+	cases := []struct {
+		nbt       map[string]any
+		javaBlock string
+		want      int32
+	}{
+		{map[string]any{"id": "minecraft:banner"}, "minecraft:red_banner", 1},
+		{map[string]any{"id": "minecraft:banner"}, "minecraft:light_gray_wall_banner", 7},
+		{map[string]any{"id": "minecraft:banner"}, "minecraft:black_banner", 0},
+		{map[string]any{"id": "minecraft:banner"}, "", 15},
+		{map[string]any{"id": "Banner", "Base": int32(4)}, "", 4},
+	}
+	for _, c := range cases {
+		if got := JavaToBedrock(c.nbt, nil, c.javaBlock)["Base"]; got != c.want {
+			t.Errorf("%s %v: got %v, want %v", c.javaBlock, c.nbt, got, c.want)
+		}
+	}
+
+	legacy := JavaToBedrock(map[string]any{
+		"id":       "Banner",
+		"Base":     int32(4),
+		"Patterns": []any{map[string]any{"Pattern": "cre", "Color": int32(10)}},
+	}, nil, "")
+	if legacy["Base"] != int32(4) || !reflect.DeepEqual(legacy["Patterns"], []any{map[string]any{"Pattern": "cre", "Color": int32(10)}}) {
+		t.Errorf("pre-1.13 banner: got %v", legacy)
+	}
+
+	out := JavaToBedrock(map[string]any{
+		"id":       "minecraft:banner",
+		"patterns": []any{map[string]any{"pattern": "minecraft:creeper", "color": "lime"}},
+	}, nil, "")
+	want := []any{map[string]any{"Pattern": "cre", "Color": int32(10)}}
+	if !reflect.DeepEqual(out["Patterns"], want) {
+		t.Errorf("1.20.5+ patterns: got %v, want %v", out["Patterns"], want)
+	}
+	if back, _ := BedrockToJava(out, ""); !reflect.DeepEqual(back["Patterns"], []any{map[string]any{"Pattern": "cre", "Color": int32(5)}}) {
+		t.Errorf("back to java: got %v", back["Patterns"])
+	}
+}
+
+func TestBedrockToJavaID(t *testing.T) {
+	chest := map[string]any{"id": "Chest", "isMovable": uint8(1), "Items": []any{}}
+	if got, _ := BedrockToJava(chest, "minecraft:trapped_chest[facing=north,type=single,waterlogged=false]"); got["id"] != "minecraft:trapped_chest" {
+		t.Errorf("trapped chest: got %v", got["id"])
+	}
+	if got, _ := BedrockToJava(chest, ""); got["id"] != "minecraft:chest" {
+		t.Errorf("chest: got %v", got["id"])
+	}
+}
+
+func TestBedrockToJavaBlock(t *testing.T) {
+	cases := []struct {
+		nbt       map[string]any
+		javaBlock string
+		want      string
+	}{
+		{map[string]any{"id": "Skull", "SkullType": uint8(4), "Rotation": float32(90)}, "skeleton_skull[powered=false,rotation=0]", "minecraft:creeper_head[powered=false,rotation=4]"},
+		{map[string]any{"id": "Skull", "SkullType": uint8(1), "Rotation": float32(-22.5)}, "", "minecraft:wither_skeleton_skull[rotation=15]"},
+		{map[string]any{"id": "Skull", "SkullType": uint8(5)}, "skeleton_wall_skull[facing=south,powered=false]", "minecraft:dragon_wall_head[facing=south,powered=false]"},
+		{map[string]any{"id": "Banner", "Base": int32(1)}, "white_banner[rotation=4]", "minecraft:red_banner[rotation=4]"},
+		{map[string]any{"id": "Banner", "Base": int32(7)}, "white_wall_banner[facing=north]", "minecraft:light_gray_wall_banner[facing=north]"},
+		{map[string]any{"id": "Chest"}, "chest[facing=north]", "chest[facing=north]"},
+	}
+	for _, c := range cases {
+		if _, got := BedrockToJava(c.nbt, c.javaBlock); got != c.want {
+			t.Errorf("%v on %s: got %s, want %s", c.nbt, c.javaBlock, got, c.want)
+		}
+	}
+}
+
+func TestLegacyJavaBlockNBT(t *testing.T) {
+	cases := map[string]map[string]any{
+		"minecraft:creeper_head[powered=false,rotation=4]": {"SkullType": uint8(4), "Rot": uint8(4)},
+		"minecraft:zombie_wall_head[facing=east]":          {"SkullType": uint8(2), "Rot": uint8(0)},
+		"minecraft:red_wall_banner[facing=north]":          {"Base": int32(1)},
+		"minecraft:oak_stairs[facing=east,half=top]":       nil,
+	}
+	for block, want := range cases {
+		if got := LegacyJavaBlockNBT(block); !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: got %v, want %v", block, got, want)
+		}
+	}
+}
+
+func TestJavaToBedrockKeepsBedrockNBT(t *testing.T) {
+	in := map[string]any{"id": "Chest", "isMovable": uint8(1)}
+	if out := JavaToBedrock(in, nil, ""); !reflect.DeepEqual(out, in) {
+		t.Errorf("got %v", out)
+	}
+}
+
+func TestJavaItemToBedrock(t *testing.T) {
+	out := JavaItemToBedrock(map[string]any{
+		"id":    "minecraft:diamond_sword",
+		"Count": int8(1),
+		"Slot":  int8(3),
+		"tag": map[string]any{
+			"Damage":       int32(5),
+			"display":      map[string]any{"Name": `{"text":"Blade"}`},
+			"Enchantments": []any{map[string]any{"id": "minecraft:sharpness", "lvl": int16(2)}},
+		},
+	})
+	tag, _ := out["tag"].(map[string]any)
+	if out["Name"] != "minecraft:diamond_sword" || out["Count"] != uint8(1) || out["Slot"] != uint8(3) || tag["Damage"] != int32(5) {
+		t.Errorf("got %v", out)
+	}
+	if display, _ := tag["display"].(map[string]any); display["Name"] != "Blade" {
+		t.Errorf("display: got %v", tag["display"])
+	}
+	if want := []any{map[string]any{"id": int16(9), "lvl": int16(2)}}; !reflect.DeepEqual(tag["ench"], want) {
+		t.Errorf("ench: got %v, want %v", tag["ench"], want)
+	}
+
+	components := JavaItemToBedrock(map[string]any{
+		"id":    "minecraft:shulker_box",
+		"count": int32(1),
+		"components": map[string]any{
+			"minecraft:container": []any{map[string]any{"slot": int32(2), "item": map[string]any{"id": "minecraft:stone", "count": int32(64)}}},
+		},
+	})
+	items, _ := components["tag"].(map[string]any)["Items"].([]any)
+	if len(items) != 1 || items[0].(map[string]any)["Slot"] != uint8(2) || items[0].(map[string]any)["Count"] != uint8(64) {
+		t.Errorf("1.20.5+ container: got %v", components)
+	}
+
+	single := JavaItemToBedrock(map[string]any{
+		"id": "minecraft:chest",
+		"components": map[string]any{
+			"minecraft:container": []any{map[string]any{"slot": int32(0), "item": map[string]any{"id": "minecraft:diamond_sword"}}},
+		},
+	})
+	if single["Count"] != uint8(1) {
+		t.Errorf("1.20.5+ item without count: got %v", single["Count"])
+	}
+	items, _ = single["tag"].(map[string]any)["Items"].([]any)
+	if len(items) != 1 || items[0].(map[string]any)["Count"] != uint8(1) {
+		t.Errorf("1.20.5+ container item without count: got %v", items)
+	}
+}
+
+func TestBedrockItemToJava(t *testing.T) {
+	out := BedrockItemToJava(map[string]any{
+		"Name":   "minecraft:stone",
+		"Count":  uint8(12),
+		"Damage": int16(0),
+		"tag": map[string]any{
+			"display": map[string]any{"Name": "Rock", "Lore": []any{"line"}},
+			"ench":    []any{map[string]any{"id": int16(16), "lvl": int16(1)}},
+		},
+	})
+	tag, _ := out["tag"].(map[string]any)
+	if out["id"] != "minecraft:stone" || out["Count"] != uint8(12) {
+		t.Errorf("got %v", out)
+	}
+	want := map[string]any{"Name": JSONText("Rock"), "Lore": []any{JSONText("line")}}
+	if !reflect.DeepEqual(tag["display"], want) {
+		t.Errorf("display: got %v, want %v", tag["display"], want)
+	}
+	if want := []any{map[string]any{"id": "minecraft:silk_touch", "lvl": int16(1)}}; !reflect.DeepEqual(tag["Enchantments"], want) {
+		t.Errorf("enchantments: got %v, want %v", tag["Enchantments"], want)
+	}
+}
+
+func TestPlainText(t *testing.T) {
+	cases := map[string]any{
+		"plain":   "plain",
+		"ab":      `{"text":"a","extra":[{"text":"b"}]}`,
+		"xy":      []any{"x", map[string]any{"text": "y"}},
+		"[broken": "[broken",
+	}
+	for want, in := range cases {
+		if got := PlainText(in); got != want {
+			t.Errorf("%v: got %q, want %q", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package convertor
+
+import (
+	"fmt"
+
+	"github.com/Yeah114/blocks/describe"
+)
+
+// RecordConflict describes a record whose name and state (or legacy value)
+// is already mapped to a different target
+type RecordConflict struct {
+	Name        string
+	State       string // snbt state or legacy value of the record
+	Existing    string // target before loading the record
+	New         string // target of the record
+	Overwritten bool   // true if the record replaced the existing target
+}
+
+func (c *RecordConflict) String() string {
+	action := "kept"
+	if c.Overwritten {
+		action = "overwritten"
+	}
+	return fmt.Sprintf("%v %v: %v -> %v (%v)", c.Name, c.State, c.Existing, c.New, action)
+}
+
+// LoadConvertRecordWithReport loads a record like LoadConvertRecord,
+// but returns conflicts and malformed records instead of panicking.
+// Without overwrite a conflicting record is not loaded and the conflict has Overwritten false
+func (c *ToNEMCConvertor) LoadConvertRecordWithReport(r *ConvertRecord, overwrite bool) (conflict *RecordConflict, err error) {
+	name := describe.BlockNameForSearch(r.Name)
+	if val, ok := r.GetLegacyValue(); ok {
+		if existing, found := c.PreciseMatchByLegacyValue(name, val); found && existing != r.RTID {
+			conflict = &RecordConflict{r.Name, r.SNBTStateOrValue, fmt.Sprint(existing), fmt.Sprint(r.RTID), overwrite}
+		}
+		if _, err := c.AddAnchorByLegacyValue(name, val, r.RTID, overwrite); err != nil && conflict == nil {
+			return nil, err
+		}
+		return conflict, nil
+	}
+
+	props, err := describe.PropsForSearchFromStr(r.SNBTStateOrValue)
+	if err != nil {
+		return nil, fmt.Errorf("invalid states %v of %v: %w", r.SNBTStateOrValue, r.Name, err)
+	}
+	if existing, found := c.PreciseMatchByState(name, props); found && existing != r.RTID {
+		conflict = &RecordConflict{r.Name, props.InPreciseSNBT(), fmt.Sprint(existing), fmt.Sprint(r.RTID), overwrite}
+	}
+	if _, err := c.AddAnchorByState(name, props, r.RTID, overwrite); err != nil && conflict == nil {
+		return nil, err
+	}
+	return conflict, nil
+}
+
+// LoadJavaConvertRecordWithReport loads a record like LoadJavaConvertRecord,
+// but returns conflicts and malformed records instead of panicking.
+// Without overwrite a conflicting record is not loaded and the conflict has Overwritten false
+func (c *ToJavaConvertor) LoadJavaConvertRecordWithReport(r *JavaConvertRecord, overwrite bool) (conflict *RecordConflict, err error) {
+	name := describe.BlockNameForSearch(r.Name)
+	javaBlock := describe.NewJavaBlockString(r.JavaBlockName, r.JavaBlockSNBT)
+	if val, ok := r.GetLegacyValue(); ok {
+		if existing, found := c.PreciseMatchByLegacyValue(name, val); found && existing.String() != javaBlock.String() {
+			conflict = &RecordConflict{r.Name, r.SNBTStateOrValue, existing.String(), javaBlock.String(), overwrite}
+		}
+		if _, err := c.AddAnchorByLegacyValue(name, val, javaBlock, overwrite); err != nil && conflict == nil {
+			return nil, err
+		}
+		return conflict, nil
+	}
+
+	props, err := describe.PropsForSearchFromStr(r.SNBTStateOrValue)
+	if err != nil {
+		return nil, fmt.Errorf("invalid states %v of %v: %w", r.SNBTStateOrValue, r.Name, err)
+	}
+	if existing, found := c.PreciseMatchByState(name, props); found && existing.String() != javaBlock.String() {
+		conflict = &RecordConflict{r.Name, props.InPreciseSNBT(), existing.String(), javaBlock.String(), overwrite}
+	}
+	if _, err := c.AddAnchorByState(name, props, javaBlock, overwrite); err != nil && conflict == nil {
+		return nil, err
+	}
+	return conflict, nil
+}
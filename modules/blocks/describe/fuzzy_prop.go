@@ -287,6 +287,18 @@ func FuzzyPropValForSearchFromString(val string) fuzzyString {
 	return fs
 }
 
+// FuzzyPropValForSearchFromPropVal converts a certain prop value to the fuzzy form used for search
+func FuzzyPropValForSearchFromPropVal(v PropVal) PropValForSearch {
+	switch {
+	case v.HasType(PropValTypeUint8):
+		return FuzzyPropValForSearchFromBool(v.Uint8Val() != 0)
+	case v.HasType(PropValTypeInt32):
+		return FuzzyPropValForSearchFromInt32(v.Int32Val())
+	default:
+		return FuzzyPropValForSearchFromString(v.StringVal())
+	}
+}
+
 // func assertPropSame(v1, v2 PropValForSearch) {
 // 	if !v1.FuzzyEqual(v2) {
 // 		panic(fmt.Errorf("%v!=%v", v1, v2))
@@ -0,0 +1,183 @@
+package blocks
+
+import (
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
+
+	"github.com/Yeah114/blocks/describe"
+)
+
+// MatchKind tells how a source block was resolved during conversion
+type MatchKind int
+
+const (
+	MatchExact    MatchKind = iota // name and all states matched
+	MatchFuzzy                     // name matched, some states differ, are missing or redundant
+	MatchFallback                  // not found, converted to air/unknown
+)
+
+func (k MatchKind) String() string {
+	switch k {
+	case MatchExact:
+		return "exact"
+	case MatchFuzzy:
+		return "fuzzy"
+	default:
+		return "fallback"
+	}
+}
+
+// FidelityEntry is the statistics of one source block. Lookups counts conversions of the source, not
+// placed blocks: converters cache their results, so a source filling a whole structure may be looked up
+// once per palette or per distinct id
+type FidelityEntry struct {
+	Source    string    `json:"source"`
+	Target    string    `json:"target"`
+	ToJava    bool      `json:"to_java,omitempty"`
+	Kind      MatchKind `json:"-"`
+	KindName  string    `json:"kind"`
+	Lookups   int       `json:"lookups"`
+	Different uint8     `json:"different,omitempty"`
+	Missing   uint8     `json:"missing,omitempty"`
+	Redundant uint8     `json:"redundant,omitempty"`
+}
+
+// FidelityReport collects how every block looked up through this package was matched
+type FidelityReport struct {
+	mu      sync.Mutex
+	entries map[fidelityKey]*FidelityEntry
+}
+
+type fidelityKey struct {
+	source string
+	toJava bool
+}
+
+var activeFidelityReport atomic.Pointer[FidelityReport]
+
+// StartFidelityReport starts collecting match statistics of all lookups until StopFidelityReport
+func StartFidelityReport() *FidelityReport {
+	r := &FidelityReport{entries: map[fidelityKey]*FidelityEntry{}}
+	activeFidelityReport.Store(r)
+	return r
+}
+
+// StopFidelityReport stops collecting and returns the collected report (nil if not started)
+func StopFidelityReport() *FidelityReport {
+	return activeFidelityReport.Swap(nil)
+}
+
+func (r *FidelityReport) add(source, target string, toJava bool, kind MatchKind, score describe.ComparedOutput) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	key := fidelityKey{source, toJava}
+	e, ok := r.entries[key]
+	if !ok {
+		e = &FidelityEntry{
+			Source:    source,
+			Target:    target,
+			ToJava:    toJava,
+			Kind:      kind,
+			KindName:  kind.String(),
+			Different: score.Different,
+			Missing:   score.Missing,
+			Redundant: score.Redundant,
+		}
+		r.entries[key] = e
+	}
+	e.Lookups++
+}
+
+// Entries returns the statistics sorted by match kind (worst first), then by lookups
+func (r *FidelityReport) Entries() []*FidelityEntry {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	list := make([]*FidelityEntry, 0, len(r.entries))
+	for _, e := range r.entries {
+		list = append(list, e)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Kind != list[j].Kind {
+			return list[i].Kind > list[j].Kind
+		}
+		if list[i].Lookups != list[j].Lookups {
+			return list[i].Lookups > list[j].Lookups
+		}
+		return list[i].Source < list[j].Source
+	})
+	return list
+}
+
+// LookupCounts returns the number of lookups (not distinct sources, nor placed blocks) of each match kind
+func (r *FidelityReport) LookupCounts() map[MatchKind]int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	counts := map[MatchKind]int{}
+	for _, e := range r.entries {
+		counts[e.Kind] += e.Lookups
+	}
+	return counts
+}
+
+func matchKindOf(found bool, score describe.ComparedOutput) MatchKind {
+	if !found {
+		return MatchFallback
+	}
+	if score.Different > 0 || score.Missing > 0 || score.Redundant > 0 {
+		return MatchFuzzy
+	}
+	return MatchExact
+}
+
+// recordBedrockMatch records a lookup resolving to a runtime id of MC_CURRENT
+func recordBedrockMatch(source func() string, rtid uint32, found bool, score describe.ComparedOutput) {
+	r := activeFidelityReport.Load()
+	if r == nil {
+		return
+	}
+	target := "air"
+	if block, ok := RuntimeIDToBlock(rtid); ok {
+		target = block.BedrockString()
+	}
+	r.add(source(), target, false, matchKindOf(found, score), score)
+}
+
+// recordLegacyMatch records a lookup by legacy value, which has no state score:
+// it is exact if the legacy value itself is mapped, fuzzy otherwise
+func recordLegacyMatch(name describe.BaseWithNameSpace, data uint16, rtid uint32, found bool) {
+	if activeFidelityReport.Load() == nil {
+		return
+	}
+	score := describe.ComparedOutput{}
+	if _, precise := DefaultAnyToNemcConvertor.PreciseMatchByLegacyValue(name, data); found && !precise {
+		score.Different = 1
+	}
+	recordBedrockMatch(func() string { return name.LongName() + " " + strconv.Itoa(int(data)) }, rtid, found, score)
+}
+
+// recordStateMatch records a lookup by name and states resolving to a runtime id of MC_CURRENT
+func recordStateMatch(name describe.BaseWithNameSpace, props *describe.PropsForSearch, rtid uint32, found bool, score describe.ComparedOutput) {
+	recordBedrockMatch(func() string { return searchString(name, props) }, rtid, found, score)
+}
+
+// recordJavaMatch records a lookup by bedrock name and states resolving to a java block
+func recordJavaMatch(name describe.BaseWithNameSpace, props *describe.PropsForSearch, javaBlock *describe.JavaBlockString, found bool, score describe.ComparedOutput) {
+	r := activeFidelityReport.Load()
+	if r == nil {
+		return
+	}
+	target := "minecraft:air"
+	if found && javaBlock != nil {
+		target = javaBlock.String()
+	}
+	r.add(searchString(name, props), target, true, matchKindOf(found, score), score)
+}
+
+func searchString(name describe.BaseWithNameSpace, props *describe.PropsForSearch) string {
+	if snbt := props.InPreciseSNBT(); snbt != "" && snbt != "{}" {
+		return name.LongName() + " " + snbt
+	}
+	return name.LongName()
+}
@@ -0,0 +1,95 @@
+package blocks
+
+import (
+	"strings"
+	"testing"
+)
+
+func findFidelityEntry(entries []*FidelityEntry, source string, toJava bool) *FidelityEntry {
+	for _, e := range entries {
+		if e.Source == source && e.ToJava == toJava {
+			return e
+		}
+	}
+	return nil
+}
+
+func TestFidelityReport(t *testing.T) {
+	StartFidelityReport()
+	LegacyBlockToRuntimeID("stone", 0)
+	LegacyBlockToRuntimeID("stone", 0)
+	BlockNameAndStateStrToRuntimeID("oak_log", `["pillar_axis":"x","fidelity_test_state":1]`)
+	BlockStrToRuntimeID("fidelity_test_unknown")
+	r := StopFidelityReport()
+	if r == nil {
+		t.Fatal("StopFidelityReport returned nil")
+	}
+	LegacyBlockToRuntimeID("stone", 1)
+
+	entries := r.Entries()
+	exact := findFidelityEntry(entries, "minecraft:stone 0", false)
+	if exact == nil || exact.Kind != MatchExact || exact.Lookups != 2 || !strings.HasPrefix(exact.Target, "stone") {
+		t.Errorf("stone 0: %+v", exact)
+	}
+	var fuzzy, fallback *FidelityEntry
+	for _, e := range entries {
+		switch {
+		case strings.HasPrefix(e.Source, "minecraft:oak_log"):
+			fuzzy = e
+		case e.Source == "minecraft:fidelity_test_unknown":
+			fallback = e
+		case e.Source == "minecraft:stone 1":
+			t.Error("lookup after StopFidelityReport was recorded")
+		}
+	}
+	if fuzzy == nil || fuzzy.Kind != MatchFuzzy || fuzzy.KindName != "fuzzy" || fuzzy.Redundant == 0 {
+		t.Errorf("oak_log with an unknown state: %+v", fuzzy)
+	}
+	if fallback == nil || fallback.Kind != MatchFallback || !strings.HasPrefix(fallback.Target, "air") {
+		t.Errorf("unknown block: %+v", fallback)
+	}
+	if entries[0].Kind != MatchFallback {
+		t.Errorf("entries are not sorted worst first: %+v", entries[0])
+	}
+
+	counts := r.LookupCounts()
+	if counts[MatchExact] < 2 || counts[MatchFuzzy] < 1 || counts[MatchFallback] < 1 {
+		t.Errorf("counts: %v", counts)
+	}
+}
+
+func TestFidelityReportJava(t *testing.T) {
+	stone, _ := BlockStrToRuntimeID("stone")
+	StartFidelityReport()
+	RuntimeIDToJavaBlockStr(stone)
+	r := StopFidelityReport()
+	e := findFidelityEntry(r.Entries(), "minecraft:stone", true)
+	if e == nil || e.Kind != MatchExact || !strings.Contains(e.Target, "stone") {
+		t.Errorf("stone to java: %+v", e)
+	}
+}
+
+func TestStopFidelityReportWithoutStart(t *testing.T) {
+	StopFidelityReport()
+	if r := StopFidelityReport(); r != nil {
+		t.Errorf("got %v, want nil", r)
+	}
+}
+
+func TestFidelityReportSchematicIDs(t *testing.T) {
+	StartFidelityReport()
+	SchematicIDToRuntimeID(SchematicID{Block: 35, Data: 14})
+	SchematicIDToRuntimeID(SchematicID{Block: 300, Data: 2})
+	SchematicIDToRuntimeID(SchematicID{Block: 253, Data: 0})
+	r := StopFidelityReport()
+	entries := r.Entries()
+	if e := findFidelityEntry(entries, "minecraft:wool 14", false); e == nil || e.Kind != MatchExact || e.Lookups != 1 {
+		t.Errorf("35:14: %+v", e)
+	}
+	if e := findFidelityEntry(entries, "schematic 300:2", false); e == nil || e.Kind != MatchFallback {
+		t.Errorf("300:2: %+v", e)
+	}
+	if e := findFidelityEntry(entries, "schematic 253:0", false); e == nil || e.Kind != MatchFallback {
+		t.Errorf("253:0: %+v", e)
+	}
+}
@@ -16,6 +16,11 @@ import (
 //go:embed "nemc.br"
 var nemcBlockInfoBytes []byte
 
+// block states of the international edition, generated from the standard_block_states.nbt of bedrock-world-operator
+//
+//go:embed "bedrock.br"
+var bedrockBlockInfoBytes []byte
+
 //go:embed "bedrock_java_to_translate.br"
 var toNemcDataLoadBedrockJavaTranslateInfo []byte
 
@@ -25,6 +30,9 @@ var toNemcDataLoadSpecificLegacyValuesTranslateInfo []byte
 //go:embed "bedrock_to_java_translate.br"
 var bedrockToJavaDataLoadInfo []byte
 
+//go:embed "item_translate.br"
+var itemTranslateInfo []byte
+
 func readAndUnpack(bs []byte) string {
 	dataBytes, err := io.ReadAll(brotli.NewReader(bytes.NewBuffer(bs)))
 	if err != nil {
@@ -54,11 +62,9 @@ var DefaultAnyToNemcConvertor *convertor.ToNEMCConvertor
 var BedrockToJavaConvertor *convertor.ToJavaConvertor
 
 var quickSchematicMapping [256][256]uint32
-var runtimeIDToSchematic map[uint32][2]uint8
 
 func initSchematicBlockCheck(schematicToNemcConvertor *convertor.ToNEMCConvertor) {
 	quickSchematicMapping = [256][256]uint32{}
-	runtimeIDToSchematic = make(map[uint32][2]uint8)
 	
 	for i := 0; i < 256; i++ {
 		blockName := schematicBlockStrings[i]
@@ -75,15 +81,9 @@ func initSchematicBlockCheck(schematicToNemcConvertor *convertor.ToNEMCConvertor
 				rtid, _ = schematicToNemcConvertor.TryBestSearchByLegacyValue(describe.BlockNameForSearch(blockName), 0)
 			}
 			quickSchematicMapping[blockI][dataI] = rtid
-			
-			// 构建反向映射，优先选择数据值为0的映射
-			if existing, exists := runtimeIDToSchematic[rtid]; !exists || dataI == 0 {
-				if !exists || existing[1] != 0 {
-					runtimeIDToSchematic[rtid] = [2]uint8{uint8(blockI), uint8(dataI)}
-				}
-			}
 		}
 	}
+	initSchematicReverse()
 	schematicToNemcConvertor = nil
 }
 
@@ -108,6 +108,9 @@ func initConvertor() {
 	}
 	initSchematicBlockCheck(schematicToNemcConvertor)
 	initBedrockToJavaConvertor()
+	initNEMCPalette()
+	initBedrockPalette()
+	initItemTranslation()
 }
 
 func initBedrockToJavaConvertor() {
@@ -121,6 +124,14 @@ func initBedrockToJavaConvertor() {
 	}
 }
 
+func initItemTranslation() {
+	itemRecords, err := ReadItemRecordsFromString(readAndUnpack(itemTranslateInfo))
+	if err != nil {
+		panic(err)
+	}
+	initItems(itemRecords)
+}
+
 var inited bool
 
 func init() {
@@ -14,7 +14,10 @@ func RuntimeIDToBlock(runtimeID uint32) (block *describe.Block, found bool) {
 }
 
 func LegacyBlockToRuntimeID(name string, data uint16) (runtimeID uint32, found bool) {
-	return DefaultAnyToNemcConvertor.TryBestSearchByLegacyValue(describe.BlockNameForSearch(name), data)
+	blockName := describe.BlockNameForSearch(name)
+	runtimeID, found = DefaultAnyToNemcConvertor.TryBestSearchByLegacyValue(blockName, data)
+	recordLegacyMatch(blockName, data, runtimeID, found)
+	return runtimeID, found
 }
 
 func RuntimeIDToState(runtimeID uint32) (baseName string, properties map[string]any, found bool) {
@@ -49,7 +52,9 @@ func BlockNameAndStateToRuntimeID(name string, properties map[string]any) (runti
 		fmt.Println(err)
 		return uint32(AIR_RUNTIMEID), false
 	}
-	rtid, _, found := DefaultAnyToNemcConvertor.TryBestSearchByState(describe.BlockNameForSearch(name), props)
+	blockName := describe.BlockNameForSearch(name)
+	rtid, score, found := DefaultAnyToNemcConvertor.TryBestSearchByState(blockName, props)
+	recordStateMatch(blockName, props, rtid, found, score)
 	return rtid, found
 }
 
@@ -60,32 +65,114 @@ func BlockNameAndStateStrToRuntimeID(name string, stateStr string) (runtimeID ui
 		fmt.Println(err)
 		return uint32(AIR_RUNTIMEID), false
 	}
-	rtid, _, found := DefaultAnyToNemcConvertor.TryBestSearchByState(describe.BlockNameForSearch(name), props)
+	blockName := describe.BlockNameForSearch(name)
+	rtid, score, found := DefaultAnyToNemcConvertor.TryBestSearchByState(blockName, props)
+	recordStateMatch(blockName, props, rtid, found, score)
 	return rtid, found
 }
 
 func BlockStrToRuntimeID(blockNameWithOrWithoutState string) (runtimeID uint32, found bool) {
 	blockNameWithOrWithoutState = strings.TrimSpace(blockNameWithOrWithoutState)
-	ss := strings.Split(blockNameWithOrWithoutState, " ")
-	if len(ss) > 1 {
-		cleanedSS := []string{}
-		for _, s := range ss {
-			if s == "" {
-				continue
-			}
-			cleanedSS = append(cleanedSS, s)
+	if name, data, ok := splitLegacyBlockStr(blockNameWithOrWithoutState); ok {
+		blockName := describe.BlockNameForSearch(name)
+		rtid, found := DefaultAnyToNemcConvertor.TryBestSearchByLegacyValue(blockName, data)
+		recordLegacyMatch(blockName, data, rtid, found)
+		return rtid, found
+	}
+	blockName, blockProps := ConvertStringToBlockNameAndPropsForSearch(blockNameWithOrWithoutState)
+	rtid, score, found := DefaultAnyToNemcConvertor.TryBestSearchByState(blockName, blockProps)
+	recordStateMatch(blockName, blockProps, rtid, found, score)
+	return rtid, found
+}
+
+// splitLegacyBlockStr splits "name data" block strings
+func splitLegacyBlockStr(blockStr string) (name string, data uint16, ok bool) {
+	ss := strings.Fields(blockStr)
+	if len(ss) != 2 {
+		return "", 0, false
+	}
+	val, err := strconv.Atoi(ss[1])
+	if err != nil {
+		return "", 0, false
+	}
+	return ss[0], uint16(val), true
+}
+
+// LookupBlockStr is BlockStrToRuntimeID returning *UnknownBlockError or *InvalidStateError instead of found,
+// on *InvalidStateError runtimeID is still the best fuzzy match
+func LookupBlockStr(blockNameWithOrWithoutState string) (runtimeID uint32, err error) {
+	blockNameWithOrWithoutState = strings.TrimSpace(blockNameWithOrWithoutState)
+	if name, data, ok := splitLegacyBlockStr(blockNameWithOrWithoutState); ok {
+		blockName, err := parseBlockName(name)
+		if err != nil {
+			return uint32(AIR_RUNTIMEID), err
+		}
+		rtid, found := DefaultAnyToNemcConvertor.TryBestSearchByLegacyValue(blockName, data)
+		recordLegacyMatch(blockName, data, rtid, found)
+		if !found {
+			return rtid, &UnknownBlockError{Name: name, Suggestions: SuggestBlockNames(name, maxSuggestions)}
 		}
-		if len(cleanedSS) == 2 {
-			val, err := strconv.Atoi(cleanedSS[1])
-			if err == nil {
-				rtid, found := DefaultAnyToNemcConvertor.TryBestSearchByLegacyValue(describe.BlockNameForSearch(cleanedSS[0]), uint16(val))
-				return rtid, found
+		return rtid, nil
+	}
+	blockName, blockProps, err := ParseBlockStr(blockNameWithOrWithoutState)
+	if err != nil {
+		return uint32(AIR_RUNTIMEID), err
+	}
+	return lookupByState(blockName, blockProps)
+}
+
+// LookupBlockNameAndStateStr is BlockNameAndStateStrToRuntimeID returning errors, see LookupBlockStr
+func LookupBlockNameAndStateStr(name string, stateStr string) (runtimeID uint32, err error) {
+	blockName, err := parseBlockName(name)
+	if err != nil {
+		return uint32(AIR_RUNTIMEID), err
+	}
+	props, err := describe.PropsForSearchFromStr(stateStr)
+	if err != nil {
+		return uint32(AIR_RUNTIMEID), &InvalidStateError{Name: name, State: stateStr, Err: err, ValidStates: ValidStates(name)}
+	}
+	return lookupByState(blockName, props)
+}
+
+// LookupBlockNameAndState is BlockNameAndStateToRuntimeID returning errors, see LookupBlockStr
+func LookupBlockNameAndState(name string, properties map[string]any) (runtimeID uint32, err error) {
+	blockName, err := parseBlockName(name)
+	if err != nil {
+		return uint32(AIR_RUNTIMEID), err
+	}
+	props, err := describe.PropsForSearchFromNbt(properties)
+	if err != nil {
+		return uint32(AIR_RUNTIMEID), &InvalidStateError{Name: name, State: fmt.Sprint(properties), Err: err, ValidStates: ValidStates(name)}
+	}
+	return lookupByState(blockName, props)
+}
+
+func lookupByState(blockName describe.BaseWithNameSpace, props *describe.PropsForSearch) (runtimeID uint32, err error) {
+	rtid, score, found := DefaultAnyToNemcConvertor.TryBestSearchByState(blockName, props)
+	recordStateMatch(blockName, props, rtid, found, score)
+	if !found {
+		return rtid, &UnknownBlockError{Name: blockName.LongName(), Suggestions: SuggestBlockNames(blockName.BaseName(), maxSuggestions)}
+	}
+	// only states not matched exactly are checked, and only if the name is a bedrock block name,
+	// since aliases (e.g. java or legacy names) use their own state keys
+	if props != nil && int(score.Same) < props.NumProps() {
+		if block, ok := RuntimeIDToBlock(rtid); ok && block.ShortName() == blockName.BaseName() {
+			if invalid := checkStates(block.ShortName(), props); invalid != nil {
+				invalid.State = props.InPreciseSNBT()
+				return rtid, invalid
 			}
 		}
 	}
-	blockName, blockProps := ConvertStringToBlockNameAndPropsForSearch(blockNameWithOrWithoutState)
-	rtid, _, found := DefaultAnyToNemcConvertor.TryBestSearchByState(blockName, blockProps)
-	return rtid, found
+	return rtid, nil
+}
+
+// parseBlockName is describe.BlockNameForSearch returning *UnknownBlockError instead of panicking on malformed names
+func parseBlockName(name string) (describe.BaseWithNameSpace, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || strings.Count(name, ":") > 1 {
+		return describe.BaseWithNameSpace{}, &UnknownBlockError{Name: name, Suggestions: SuggestBlockNames(name[strings.LastIndex(name, ":")+1:], maxSuggestions)}
+	}
+	return describe.BlockNameForSearch(name), nil
 }
 
 // JavaBlockStrToRuntimeID converts Java block strings to RuntimeID
@@ -101,14 +188,26 @@ func GetSchematicMapping() [256][256]uint32 {
 	return quickSchematicMapping
 }
 
+// RuntimeIDToSchematic returns the built-in (8 bit) schematic id, see RuntimeIDToSchematicID
 func RuntimeIDToSchematic(runtimeID uint32) (block uint8, value uint8, found bool) {
 	if schematic, exists := runtimeIDToSchematic[runtimeID]; exists {
-		return schematic[0], schematic[1], true
+		return uint8(schematic.id.Block), schematic.id.Data, true
 	}
 	return 0, 0, false
 }
 
 func ConvertStringToBlockNameAndPropsForSearch(blockString string) (blockNameForSearch describe.BaseWithNameSpace, propsForSearch *describe.PropsForSearch) {
+	blockNameForSearch, propsForSearch, err := ParseBlockStr(blockString)
+	if err != nil {
+		// legacy capability
+		fmt.Println(err)
+	}
+	return blockNameForSearch, propsForSearch
+}
+
+// ParseBlockStr splits a block string like `name[states]` or `name{states}` into name and states,
+// returning *UnknownBlockError for malformed names and *InvalidStateError for unparsable states
+func ParseBlockStr(blockString string) (blockNameForSearch describe.BaseWithNameSpace, propsForSearch *describe.PropsForSearch, err error) {
 	blockString = strings.ReplaceAll(blockString, "{", "[")
 	inFrags := strings.Split(blockString, "[")
 	inBlockName, inBlockState := inFrags[0], ""
@@ -120,12 +219,16 @@ func ConvertStringToBlockNameAndPropsForSearch(blockString string) (blockNameFor
 			inBlockState = inBlockState[:len(inBlockState)-1]
 		}
 	}
-	inBlockStateForSearch, err := describe.PropsForSearchFromStr(inBlockState)
+	blockNameForSearch, err = parseBlockName(inBlockName)
 	if err != nil {
-		// legacy capability
-		fmt.Println(err)
+		return blockNameForSearch, nil, err
+	}
+	propsForSearch, err = describe.PropsForSearchFromStr(inBlockState)
+	if err != nil {
+		name := strings.TrimSpace(inBlockName)
+		return blockNameForSearch, propsForSearch, &InvalidStateError{Name: name, State: inBlockState, Err: err, ValidStates: ValidStates(name)}
 	}
-	return describe.BlockNameForSearch(inBlockName), inBlockStateForSearch
+	return blockNameForSearch, propsForSearch, nil
 }
 
 // RuntimeIDToJavaBlockStr converts Bedrock RuntimeID to Java block string
@@ -134,7 +237,8 @@ func RuntimeIDToJavaBlockStr(runtimeID uint32) (javaBlockStr string, found bool)
 	if !found {
 		return "minecraft:air", false
 	}
-	javaBlock, _, found := BedrockToJavaConvertor.TryBestSearchByState(block.NameForSearch(), block.StatesForSearch())
+	javaBlock, score, found := BedrockToJavaConvertor.TryBestSearchByState(block.NameForSearch(), block.StatesForSearch())
+	recordJavaMatch(block.NameForSearch(), block.StatesForSearch(), javaBlock, found, score)
 	if !found {
 		return "minecraft:air", false
 	}
@@ -147,7 +251,8 @@ func RuntimeIDToJavaBlockNameAndState(runtimeID uint32) (name string, properties
 	if !found {
 		return "air", nil, false
 	}
-	javaBlock, _, found := BedrockToJavaConvertor.TryBestSearchByState(block.NameForSearch(), block.StatesForSearch())
+	javaBlock, score, found := BedrockToJavaConvertor.TryBestSearchByState(block.NameForSearch(), block.StatesForSearch())
+	recordJavaMatch(block.NameForSearch(), block.StatesForSearch(), javaBlock, found, score)
 	if !found {
 		return "air", nil, false
 	}
@@ -157,7 +262,8 @@ func RuntimeIDToJavaBlockNameAndState(runtimeID uint32) (name string, properties
 // BedrockBlockStrToJavaBlockStr converts Bedrock block string to Java block string
 func BedrockBlockStrToJavaBlockStr(bedrockBlockStr string) (javaBlockStr string, found bool) {
 	blockName, blockProps := ConvertStringToBlockNameAndPropsForSearch(bedrockBlockStr)
-	javaBlock, _, found := BedrockToJavaConvertor.TryBestSearchByState(blockName, blockProps)
+	javaBlock, score, found := BedrockToJavaConvertor.TryBestSearchByState(blockName, blockProps)
+	recordJavaMatch(blockName, blockProps, javaBlock, found, score)
 	if !found {
 		return "minecraft:air", false
 	}
@@ -170,7 +276,9 @@ func BedrockBlockNameAndStateToJavaBlock(name string, properties map[string]any)
 	if err != nil {
 		return "air", nil, false
 	}
-	javaBlock, _, found := BedrockToJavaConvertor.TryBestSearchByState(describe.BlockNameForSearch(name), props)
+	blockName := describe.BlockNameForSearch(name)
+	javaBlock, score, found := BedrockToJavaConvertor.TryBestSearchByState(blockName, props)
+	recordJavaMatch(blockName, props, javaBlock, found, score)
 	if !found {
 		return "air", nil, false
 	}
@@ -183,7 +291,8 @@ func RuntimeIDToJavaBlockNameAndStateStr(runtimeID uint32) (blockName, blockStat
 	if !found {
 		return "air", "[]", false
 	}
-	javaBlock, _, found := BedrockToJavaConvertor.TryBestSearchByState(block.NameForSearch(), block.StatesForSearch())
+	javaBlock, score, found := BedrockToJavaConvertor.TryBestSearchByState(block.NameForSearch(), block.StatesForSearch())
+	recordJavaMatch(block.NameForSearch(), block.StatesForSearch(), javaBlock, found, score)
 	if !found {
 		return "air", "[]", false
 	}
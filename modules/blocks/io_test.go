@@ -0,0 +1,88 @@
+package blocks
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestLookupBlockStr(t *testing.T) {
+	for _, s := range []string{
+		"minecraft:stone",
+		"stone",
+		`oak_log ["pillar_axis":"x"]`,
+		"minecraft:oak_stairs[facing=east,half=top]",
+		"wool 14",
+	} {
+		rtid, err := LookupBlockStr(s)
+		if err != nil {
+			t.Errorf("%s: %v", s, err)
+			continue
+		}
+		if rtid == AIR_RUNTIMEID {
+			t.Errorf("%s: converted to air", s)
+		}
+	}
+}
+
+func TestLookupBlockStrUnknownBlock(t *testing.T) {
+	for _, s := range []string{"minecraft:stonee", "stonee 0", "a:b:c"} {
+		_, err := LookupBlockStr(s)
+		var unknown *UnknownBlockError
+		if !errors.As(err, &unknown) {
+			t.Errorf("%s: got %v, want *UnknownBlockError", s, err)
+		}
+	}
+	_, err := LookupBlockStr("minecraft:stonee")
+	var unknown *UnknownBlockError
+	if errors.As(err, &unknown) && len(unknown.Suggestions) == 0 {
+		t.Error("stonee: no suggestions")
+	}
+}
+
+func TestLookupBlockStrInvalidState(t *testing.T) {
+	cases := []struct {
+		block string
+		key   string
+	}{
+		{`oak_log ["pillar_axis":"w"]`, "pillar_axis"},
+		{`oak_log ["no_such_state":1]`, "no_such_state"},
+		{"minecraft:oak_stairs[facing=up]", "facing"},
+	}
+	for _, c := range cases {
+		rtid, err := LookupBlockStr(c.block)
+		var invalid *InvalidStateError
+		if !errors.As(err, &invalid) {
+			t.Errorf("%s: got %v, want *InvalidStateError", c.block, err)
+			continue
+		}
+		if invalid.Key != c.key {
+			t.Errorf("%s: offending key %q, want %q", c.block, invalid.Key, c.key)
+		}
+		if len(invalid.ValidStates) == 0 {
+			t.Errorf("%s: no valid states listed", c.block)
+		}
+		if rtid == AIR_RUNTIMEID {
+			t.Errorf("%s: the best fuzzy match should still be returned", c.block)
+		}
+	}
+}
+
+func TestValidateBlockStates(t *testing.T) {
+	if err := ValidateBlockStates("oak_log", `["pillar_axis":"y"]`, false); err != nil {
+		t.Errorf("valid partial states: %v", err)
+	}
+	if err := ValidateBlockStates("oak_log", `["pillar_axis":"y"]`, true); err != nil {
+		t.Errorf("oak_log has a single state, pillar_axis is complete: %v", err)
+	}
+	var invalid *InvalidStateError
+	if err := ValidateBlockStates("oak_log", `["pillar_axis":"w"]`, false); !errors.As(err, &invalid) {
+		t.Errorf("invalid value: got %v", err)
+	}
+	if err := ValidateBlockStates("oak_log", `[pillar_axis]`, false); !errors.As(err, &invalid) || invalid.Err == nil {
+		t.Errorf("unparsable states: got %v", err)
+	}
+	var unknown *UnknownBlockError
+	if err := ValidateBlockStates("oak_logg", "", false); !errors.As(err, &unknown) {
+		t.Errorf("unknown block: got %v", err)
+	}
+}
@@ -0,0 +1,186 @@
+package blocks
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ItemRecord is an item whose id (or data value) differs between java, bedrock and netease.
+// Items missing from the table use the same id in every edition
+type ItemRecord struct {
+	Java        string
+	Bedrock     string
+	BedrockData int16
+	NetEase     string
+	NetEaseData int16
+}
+
+type itemKey struct {
+	name string
+	data int16
+}
+
+type itemIndex struct {
+	byKey  map[itemKey]*ItemRecord
+	byName map[string]*ItemRecord // the record with the lowest data value of each name
+	lowest map[string]int16
+}
+
+func newItemIndex() *itemIndex {
+	return &itemIndex{byKey: map[itemKey]*ItemRecord{}, byName: map[string]*ItemRecord{}, lowest: map[string]int16{}}
+}
+
+func (idx *itemIndex) add(name string, data int16, r *ItemRecord) {
+	if _, ok := idx.byKey[itemKey{name, data}]; !ok {
+		idx.byKey[itemKey{name, data}] = r
+	}
+	if lowest, ok := idx.lowest[name]; !ok || data < lowest {
+		idx.byName[name], idx.lowest[name] = r, data
+	}
+}
+
+// lookupItem finds the record of name and data in the indexes in order, falling back to the name
+// alone since data values of tools and armor are durability in older worlds
+func lookupItem(name string, data int16, indexes ...*itemIndex) (*ItemRecord, bool) {
+	for _, idx := range indexes {
+		if r, ok := idx.byKey[itemKey{name, data}]; ok {
+			return r, true
+		}
+	}
+	for _, idx := range indexes {
+		if r, ok := idx.byName[name]; ok {
+			return r, true
+		}
+	}
+	return nil, false
+}
+
+var (
+	itemRecords  []*ItemRecord
+	javaItems    map[string]*ItemRecord
+	bedrockItems *itemIndex
+	neteaseItems *itemIndex
+)
+
+// ReadItemRecordsFromString parses item records, one per line:
+// `java_id bedrock_name bedrock_data netease_name netease_data`
+func ReadItemRecordsFromString(s string) ([]*ItemRecord, error) {
+	var records []*ItemRecord
+	for i, line := range strings.Split(s, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) != 5 {
+			return nil, fmt.Errorf("line %v: expect 5 fields, got %v", i+1, len(fields))
+		}
+		bedrockData, err := strconv.ParseInt(fields[2], 10, 16)
+		if err != nil {
+			return nil, fmt.Errorf("line %v: %w", i+1, err)
+		}
+		neteaseData, err := strconv.ParseInt(fields[4], 10, 16)
+		if err != nil {
+			return nil, fmt.Errorf("line %v: %w", i+1, err)
+		}
+		records = append(records, &ItemRecord{
+			Java:        fields[0],
+			Bedrock:     fields[1],
+			BedrockData: int16(bedrockData),
+			NetEase:     fields[3],
+			NetEaseData: int16(neteaseData),
+		})
+	}
+	return records, nil
+}
+
+func initItems(records []*ItemRecord) {
+	itemRecords = records
+	javaItems = make(map[string]*ItemRecord, len(records))
+	bedrockItems, neteaseItems = newItemIndex(), newItemIndex()
+	for _, r := range records {
+		if _, ok := javaItems[r.Java]; !ok {
+			javaItems[r.Java] = r
+		}
+		bedrockItems.add(r.Bedrock, r.BedrockData, r)
+		neteaseItems.add(r.NetEase, r.NetEaseData, r)
+	}
+}
+
+// ItemRecords returns every item whose id differs between editions
+func ItemRecords() []*ItemRecord {
+	return itemRecords
+}
+
+// splitItemName returns the name without the "minecraft:" namespace, ok is false for other namespaces
+func splitItemName(name string) (short string, ok bool) {
+	if ns, short, found := strings.Cut(name, ":"); found {
+		return short, ns == "minecraft"
+	}
+	return name, true
+}
+
+// JavaItemToBedrock returns the bedrock name (with namespace) and data value of a java item id,
+// found is false if the id is the same in both editions
+func JavaItemToBedrock(javaID string) (name string, data int16, found bool) {
+	short, ok := splitItemName(javaID)
+	if !ok {
+		return javaID, 0, false
+	}
+	if r, ok := javaItems[short]; ok {
+		return "minecraft:" + r.Bedrock, r.BedrockData, true
+	}
+	return "minecraft:" + short, 0, false
+}
+
+// JavaItemToNetEase returns the netease name (with namespace) and data value of a java item id,
+// found is false if the id is the same in both editions
+func JavaItemToNetEase(javaID string) (name string, data int16, found bool) {
+	short, ok := splitItemName(javaID)
+	if !ok {
+		return javaID, 0, false
+	}
+	if r, ok := javaItems[short]; ok {
+		return "minecraft:" + r.NetEase, r.NetEaseData, true
+	}
+	return "minecraft:" + short, 0, false
+}
+
+// BedrockItemToJava returns the java item id (with namespace) of a bedrock item,
+// legacy (netease style) names and data values are accepted as well
+func BedrockItemToJava(name string, data int16) (javaID string, found bool) {
+	short, ok := splitItemName(name)
+	if !ok {
+		return name, false
+	}
+	if r, ok := lookupItem(short, data, bedrockItems, neteaseItems); ok {
+		return "minecraft:" + r.Java, true
+	}
+	return "minecraft:" + short, false
+}
+
+// NetEaseItemToJava returns the java item id (with namespace) of a netease item,
+// names and data values of the current bedrock version are accepted as well
+func NetEaseItemToJava(name string, data int16) (javaID string, found bool) {
+	short, ok := splitItemName(name)
+	if !ok {
+		return name, false
+	}
+	if r, ok := lookupItem(short, data, neteaseItems, bedrockItems); ok {
+		return "minecraft:" + r.Java, true
+	}
+	return "minecraft:" + short, false
+}
+
+// NetEaseItemToBedrock returns the bedrock name (with namespace) and data value of a netease item
+func NetEaseItemToBedrock(name string, data int16) (bedrockName string, bedrockData int16, found bool) {
+	short, ok := splitItemName(name)
+	if !ok {
+		return name, data, false
+	}
+	if r, ok := neteaseItems.byKey[itemKey{short, data}]; ok {
+		return "minecraft:" + r.Bedrock, r.BedrockData, true
+	}
+	return "minecraft:" + short, data, false
+}
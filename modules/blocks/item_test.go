@@ -0,0 +1,67 @@
+package blocks
+
+import "testing"
+
+func TestReadItemRecordsFromString(t *testing.T) {
+	records, err := ReadItemRecordsFromString("coarse_dirt coarse_dirt 0 dirt 1\n\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 1 || *records[0] != (ItemRecord{"coarse_dirt", "coarse_dirt", 0, "dirt", 1}) {
+		t.Errorf("got %+v", records)
+	}
+	for _, s := range []string{"coarse_dirt coarse_dirt 0 dirt", "coarse_dirt coarse_dirt x dirt 1"} {
+		if _, err := ReadItemRecordsFromString(s); err == nil {
+			t.Errorf("%q: expected an error", s)
+		}
+	}
+}
+
+func TestJavaItemToEditions(t *testing.T) {
+	if name, data, found := JavaItemToBedrock("minecraft:coarse_dirt"); !found || name != "minecraft:coarse_dirt" || data != 0 {
+		t.Errorf("coarse_dirt to bedrock: %v %v %v", name, data, found)
+	}
+	if name, data, found := JavaItemToNetEase("coarse_dirt"); !found || name != "minecraft:dirt" || data != 1 {
+		t.Errorf("coarse_dirt to netease: %v %v %v", name, data, found)
+	}
+	if name, data, found := JavaItemToNetEase("minecraft:diamond"); found || name != "minecraft:diamond" || data != 0 {
+		t.Errorf("diamond to netease: %v %v %v", name, data, found)
+	}
+	if name, _, found := JavaItemToBedrock("mymod:gem"); found || name != "mymod:gem" {
+		t.Errorf("other namespace: %v %v", name, found)
+	}
+}
+
+func TestItemToJava(t *testing.T) {
+	cases := []struct {
+		name string
+		data int16
+		want string
+	}{
+		{"minecraft:bed", 11, "minecraft:blue_bed"},
+		{"dirt", 1, "minecraft:coarse_dirt"},
+		{"minecraft:coarse_dirt", 0, "minecraft:coarse_dirt"},
+		{"minecraft:diamond", 0, "minecraft:diamond"},
+	}
+	for _, c := range cases {
+		if got, _ := BedrockItemToJava(c.name, c.data); got != c.want {
+			t.Errorf("bedrock %v %v: got %v, want %v", c.name, c.data, got, c.want)
+		}
+		if got, _ := NetEaseItemToJava(c.name, c.data); got != c.want {
+			t.Errorf("netease %v %v: got %v, want %v", c.name, c.data, got, c.want)
+		}
+	}
+	// durability of tools is kept in the data value of older worlds, the name alone still matches
+	if got, found := NetEaseItemToJava("minecraft:bed", 99); !found || got != "minecraft:white_bed" {
+		t.Errorf("bed with unknown data: got %v %v", got, found)
+	}
+}
+
+func TestNetEaseItemToBedrock(t *testing.T) {
+	if name, data, found := NetEaseItemToBedrock("stonebrick", 3); !found || name != "minecraft:chiseled_stone_bricks" || data != 0 {
+		t.Errorf("to bedrock: %v %v %v", name, data, found)
+	}
+	if name, data, found := NetEaseItemToBedrock("minecraft:diamond_sword", 12); found || name != "minecraft:diamond_sword" || data != 12 {
+		t.Errorf("unchanged item: %v %v %v", name, data, found)
+	}
+}
@@ -0,0 +1,420 @@
+package blocks
+
+import (
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/Yeah114/blocks/describe"
+)
+
+// BlockAccessor gives the runtime ids of the blocks around the block being converted,
+// layer 1 is the second storage layer where bedrock keeps the water of waterlogged blocks
+type BlockAccessor interface {
+	BlockAt(x, y, z int32, layer uint8) (runtimeID uint32)
+}
+
+// BlockAccessorFunc adapts a function to BlockAccessor
+type BlockAccessorFunc func(x, y, z int32, layer uint8) uint32
+
+func (f BlockAccessorFunc) BlockAt(x, y, z int32, layer uint8) uint32 {
+	return f(x, y, z, layer)
+}
+
+type javaState struct {
+	source   *describe.Block
+	block    *describe.JavaBlockString
+	score    describe.ComparedOutput
+	found    bool
+	name     string
+	props    map[string]string
+	recorded bool // recorded in the fidelity report, once per runtime id like the other converters
+}
+
+// ContextJavaConvertor converts bedrock blocks to java blocks like RuntimeIDToJavaBlockStr, but also fills
+// the java properties bedrock keeps in layer 1 or in neighbouring blocks: waterlogged, the states of the
+// other door half, connections of fences, glass panes, iron bars and redstone wire, the shape of stairs and
+// snowy of grass blocks. Per-runtime-id results are cached, so use one convertor for a whole export
+type ContextJavaConvertor struct {
+	acc   BlockAccessor
+	mu    sync.Mutex
+	cache map[uint32]*javaState
+}
+
+func NewContextJavaConvertor(acc BlockAccessor) *ContextJavaConvertor {
+	return &ContextJavaConvertor{acc: acc, cache: map[uint32]*javaState{}}
+}
+
+// state returns the cached java state of a runtime id, record records the first recorded lookup of
+// the runtime id in the fidelity report
+func (c *ContextJavaConvertor) state(runtimeID uint32, record bool) *javaState {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	s, ok := c.cache[runtimeID]
+	if !ok {
+		s = &javaState{name: "minecraft:air", props: map[string]string{}}
+		if block, ok := RuntimeIDToBlock(runtimeID); ok {
+			s.source = block
+			s.block, s.score, s.found = BedrockToJavaConvertor.TryBestSearchByState(block.NameForSearch(), block.StatesForSearch())
+			if s.found {
+				s.name, s.props = s.block.Name(), parseJavaProps(s.block.SNBT())
+			}
+		}
+		c.cache[runtimeID] = s
+	}
+	if record && !s.recorded && s.source != nil {
+		s.recorded = true
+		recordJavaMatch(s.source.NameForSearch(), s.source.StatesForSearch(), s.block, s.found, s.score)
+	}
+	return s
+}
+
+// at returns the java state of the layer 0 block at the position, without recording it in the fidelity report
+func (c *ContextJavaConvertor) at(x, y, z int32) *javaState {
+	return c.state(c.acc.BlockAt(x, y, z, 0), false)
+}
+
+// JavaBlock returns the java block name and properties (java block states are always strings) at the position
+func (c *ContextJavaConvertor) JavaBlock(x, y, z int32) (name string, properties map[string]string, found bool) {
+	s := c.state(c.acc.BlockAt(x, y, z, 0), true)
+	if !s.found {
+		return s.name, map[string]string{}, false
+	}
+	props := make(map[string]string, len(s.props)+1)
+	for k, v := range s.props {
+		props[k] = v
+	}
+	short := strings.TrimPrefix(s.name, "minecraft:")
+	if isJavaWaterloggable(short) {
+		props["waterlogged"] = boolString(isWater(c.acc.BlockAt(x, y, z, 1)))
+	}
+	switch {
+	case strings.HasSuffix(short, "_door"):
+		c.completeDoor(x, y, z, s.name, props)
+	case strings.HasSuffix(short, "_stairs"):
+		props["shape"] = c.stairsShape(x, y, z, props)
+	case short == "redstone_wire":
+		c.connectRedstone(x, y, z, props)
+	case strings.HasSuffix(short, "_fence"):
+		c.connect(x, y, z, props, func(n *javaState, d direction) bool {
+			nShort := strings.TrimPrefix(n.name, "minecraft:")
+			if strings.HasSuffix(nShort, "_fence") {
+				return (short == "nether_brick_fence") == (nShort == "nether_brick_fence")
+			}
+			if strings.HasSuffix(nShort, "_fence_gate") {
+				return facingOf(n.props["facing"]).axis() != d.axis()
+			}
+			return isJavaSturdy(nShort, n.props)
+		})
+	case strings.HasSuffix(short, "glass_pane") || short == "iron_bars":
+		c.connect(x, y, z, props, func(n *javaState, d direction) bool {
+			nShort := strings.TrimPrefix(n.name, "minecraft:")
+			if strings.HasSuffix(nShort, "glass_pane") || nShort == "iron_bars" || strings.HasSuffix(nShort, "_wall") {
+				return true
+			}
+			return isJavaSturdy(nShort, n.props)
+		})
+	case short == "grass_block" || short == "podzol" || short == "mycelium":
+		above := strings.TrimPrefix(c.at(x, y+1, z).name, "minecraft:")
+		props["snowy"] = boolString(above == "snow" || above == "snow_block" || above == "powder_snow")
+	}
+	return s.name, props, true
+}
+
+// JavaBlockStr returns the java block string at the position, e.g. oak_fence[east=true,north=false,...]
+func (c *ContextJavaConvertor) JavaBlockStr(x, y, z int32) (javaBlockStr string, found bool) {
+	name, props, found := c.JavaBlock(x, y, z)
+	if !found {
+		return "minecraft:air", false
+	}
+	return JavaBlockString(name, props), true
+}
+
+// JavaBlockString formats a java block name and properties, with properties sorted by key
+func JavaBlockString(name string, props map[string]string) string {
+	if len(props) == 0 {
+		return name
+	}
+	keys := make([]string, 0, len(props))
+	for k := range props {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var sb strings.Builder
+	sb.WriteString(name)
+	sb.WriteByte('[')
+	for i, k := range keys {
+		if i > 0 {
+			sb.WriteByte(',')
+		}
+		sb.WriteString(k + "=" + props[k])
+	}
+	sb.WriteByte(']')
+	return sb.String()
+}
+
+// ParseJavaBlockString splits a java block string like oak_stairs[facing=east,half=top] into its name and properties
+func ParseJavaBlockString(s string) (name string, props map[string]string) {
+	name, states, _ := strings.Cut(s, "[")
+	return strings.TrimSpace(name), parseJavaProps(states)
+}
+
+func parseJavaProps(snbt string) map[string]string {
+	props := map[string]string{}
+	snbt = strings.Trim(snbt, "{}[] ")
+	if snbt == "" {
+		return props
+	}
+	for _, kv := range strings.Split(snbt, ",") {
+		k, v, ok := strings.Cut(kv, "=")
+		if !ok {
+			k, v, ok = strings.Cut(kv, ":")
+		}
+		if ok {
+			props[strings.Trim(strings.TrimSpace(k), `"`)] = strings.Trim(strings.TrimSpace(v), `"`)
+		}
+	}
+	return props
+}
+
+// completeDoor copies facing and open from the lower half to the upper half and hinge the other way,
+// bedrock only keeps each of them on one half
+func (c *ContextJavaConvertor) completeDoor(x, y, z int32, name string, props map[string]string) {
+	if props["half"] == "upper" {
+		if lower := c.at(x, y-1, z); lower.name == name && lower.props["half"] == "lower" {
+			props["facing"], props["open"] = lower.props["facing"], lower.props["open"]
+		}
+	} else if upper := c.at(x, y+1, z); upper.name == name && upper.props["half"] == "upper" {
+		props["hinge"] = upper.props["hinge"]
+	}
+}
+
+type direction struct {
+	name   string
+	dx, dz int32
+}
+
+var horizontalDirections = []direction{{"north", 0, -1}, {"east", 1, 0}, {"south", 0, 1}, {"west", -1, 0}}
+
+func facingOf(name string) direction {
+	for _, d := range horizontalDirections {
+		if d.name == name {
+			return d
+		}
+	}
+	return direction{}
+}
+
+func (d direction) axis() string {
+	if d.dx != 0 {
+		return "x"
+	}
+	if d.dz != 0 {
+		return "z"
+	}
+	return ""
+}
+
+func (d direction) opposite() direction {
+	return facingOf(map[string]string{"north": "south", "south": "north", "east": "west", "west": "east"}[d.name])
+}
+
+func (d direction) counterClockWise() direction {
+	return facingOf(map[string]string{"north": "west", "west": "south", "south": "east", "east": "north"}[d.name])
+}
+
+// connect sets the north/east/south/west properties of fences, panes and bars
+func (c *ContextJavaConvertor) connect(x, y, z int32, props map[string]string, connects func(n *javaState, d direction) bool) {
+	for _, d := range horizontalDirections {
+		n := c.at(x+d.dx, y, z+d.dz)
+		props[d.name] = boolString(n.found && connects(n, d))
+	}
+}
+
+// stairsShape follows the java rule: a stair in front turned sideways makes an outer corner,
+// one behind makes an inner corner
+func (c *ContextJavaConvertor) stairsShape(x, y, z int32, props map[string]string) string {
+	facing := facingOf(props["facing"])
+	if facing.name == "" {
+		return props["shape"]
+	}
+	stairs := func(d direction) (*javaState, bool) {
+		n := c.at(x+d.dx, y, z+d.dz)
+		return n, strings.HasSuffix(n.name, "_stairs") && n.props["half"] == props["half"]
+	}
+	canTakeShape := func(d direction) bool {
+		n, ok := stairs(d)
+		return !ok || n.props["facing"] != props["facing"]
+	}
+	if front, ok := stairs(facing); ok {
+		if d := facingOf(front.props["facing"]); d.axis() != facing.axis() && d.axis() != "" && canTakeShape(d.opposite()) {
+			if d == facing.counterClockWise() {
+				return "outer_left"
+			}
+			return "outer_right"
+		}
+	}
+	if back, ok := stairs(facing.opposite()); ok {
+		if d := facingOf(back.props["facing"]); d.axis() != facing.axis() && d.axis() != "" && canTakeShape(d) {
+			if d == facing.counterClockWise() {
+				return "inner_left"
+			}
+			return "inner_right"
+		}
+	}
+	return "straight"
+}
+
+// connectRedstone sets the side/up/none connections of redstone wire, an isolated wire is a cross
+// and a wire connected on one side only also points to the opposite side
+func (c *ContextJavaConvertor) connectRedstone(x, y, z int32, props map[string]string) {
+	aboveSturdy := func() bool {
+		a := c.at(x, y+1, z)
+		return isJavaSturdy(strings.TrimPrefix(a.name, "minecraft:"), a.props)
+	}()
+	connected := map[string]bool{}
+	for _, d := range horizontalDirections {
+		n := c.at(x+d.dx, y, z+d.dz)
+		nShort := strings.TrimPrefix(n.name, "minecraft:")
+		nSturdy := isJavaSturdy(nShort, n.props)
+		conn := "none"
+		switch {
+		case !aboveSturdy && nSturdy && isRedstoneWire(c.at(x+d.dx, y+1, z+d.dz)):
+			conn = "up"
+		case redstoneConnects(nShort, n.props, d):
+			conn = "side"
+		case !nSturdy && isRedstoneWire(c.at(x+d.dx, y-1, z+d.dz)):
+			conn = "side"
+		}
+		props[d.name] = conn
+		connected[d.name] = conn != "none"
+	}
+	count := 0
+	for _, ok := range connected {
+		if ok {
+			count++
+		}
+	}
+	switch count {
+	case 0:
+		for _, d := range horizontalDirections {
+			props[d.name] = "side"
+		}
+	case 1:
+		for _, d := range horizontalDirections {
+			if connected[d.name] && !connected[d.opposite().name] {
+				props[d.opposite().name] = "side"
+			}
+		}
+	}
+}
+
+func isRedstoneWire(s *javaState) bool {
+	return strings.TrimPrefix(s.name, "minecraft:") == "redstone_wire"
+}
+
+// redstoneConnects tells if wire connects to the neighbour in direction d
+func redstoneConnects(name string, props map[string]string, d direction) bool {
+	switch {
+	case name == "redstone_wire":
+		return true
+	case name == "repeater":
+		return facingOf(props["facing"]).axis() == d.axis()
+	case name == "observer":
+		return props["facing"] == d.name
+	}
+	for _, source := range []string{"redstone_block", "redstone_torch", "redstone_wall_torch", "lever", "comparator",
+		"daylight_detector", "detector_rail", "trapped_chest", "tripwire_hook", "target", "lectern", "lightning_rod",
+		"sculk_sensor", "calibrated_sculk_sensor"} {
+		if name == source {
+			return true
+		}
+	}
+	return strings.HasSuffix(name, "_button") || strings.HasSuffix(name, "_pressure_plate")
+}
+
+var javaWaterloggableNames = map[string]bool{
+	"iron_bars": true, "glass_pane": true, "lantern": true, "soul_lantern": true, "chain": true, "ladder": true,
+	"chest": true, "trapped_chest": true, "ender_chest": true, "sea_pickle": true, "conduit": true, "scaffolding": true,
+	"campfire": true, "soul_campfire": true, "lightning_rod": true, "pointed_dripstone": true, "amethyst_cluster": true,
+	"glow_lichen": true, "big_dripleaf": true, "big_dripleaf_stem": true, "small_dripleaf": true, "hanging_roots": true,
+	"mangrove_roots": true, "mangrove_propagule": true, "light": true, "barrier": true, "sculk_sensor": true,
+	"calibrated_sculk_sensor": true, "sculk_shrieker": true, "sculk_vein": true, "decorated_pot": true,
+	"heavy_core": true, "rail": true, "candle": true, "copper_grate": true,
+}
+
+var javaWaterloggableSuffixes = []string{
+	"_slab", "_stairs", "_fence", "_wall", "_pane", "_trapdoor", "_sign", "_rail", "_candle", "_coral",
+	"_coral_fan", "_bud", "_copper_grate",
+}
+
+// isJavaWaterloggable tells if the java block has the waterlogged property
+func isJavaWaterloggable(name string) bool {
+	if javaWaterloggableNames[name] {
+		return true
+	}
+	for _, suffix := range javaWaterloggableSuffixes {
+		if strings.HasSuffix(name, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+func isWater(runtimeID uint32) bool {
+	block, ok := RuntimeIDToBlock(runtimeID)
+	if !ok {
+		return false
+	}
+	name := block.ShortName()
+	return name == "water" || name == "flowing_water"
+}
+
+var javaNotSturdyKeywords = []string{
+	"air", "water", "lava", "_stairs", "_fence", "_wall", "_pane", "iron_bars", "_door", "_trapdoor", "torch",
+	"_sign", "_button", "_pressure_plate", "rail", "_carpet", "leaves", "_sapling", "_flower", "_tulip", "grass",
+	"fern", "_bush", "bush", "vine", "lichen", "coral", "kelp", "seagrass", "sugar_cane", "cactus",
+	"chest", "lantern", "chain", "ladder", "_bed", "banner", "skull", "_head", "candle", "cake", "_pot", "anvil",
+	"hopper", "cauldron", "brewing_stand", "enchanting_table", "lectern", "stonecutter", "bell", "campfire",
+	"snow", "farmland", "dirt_path", "lever", "repeater", "comparator", "redstone_wire", "tripwire", "fire",
+	"portal", "cobweb", "scaffolding", "piston_head", "end_rod", "lightning_rod", "chorus", "dripstone",
+	"amethyst_cluster", "_bud", "dripleaf", "azalea", "roots", "sculk_vein", "daylight_detector", "sea_pickle",
+	"turtle_egg", "frogspawn", "conduit", "spore_blossom", "honey_block", "wheat", "carrots", "potatoes", "bamboo_sapling",
+	"beetroots", "_stem", "nether_wart", "cocoa", "sweet_berry", "cave_vines", "dragon_egg", "barrier",
+	"pumpkin", "jack_o_lantern", "melon", "shulker_box", "poppy", "dandelion", "orchid", "allium", "azure_bluet",
+	"oxeye_daisy", "cornflower", "lily", "rose", "peony", "lilac", "sunflower", "mushroom", "fungus", "sprouts",
+	"pickle", "moss_carpet", "pink_petals", "end_portal_frame", "grindstone", "heavy_core", "composter",
+}
+
+// full blocks whose names contain one of javaNotSturdyKeywords
+var javaSturdyNames = map[string]bool{
+	"grass_block": true, "mushroom_stem": true, "snow_block": true, "sea_lantern": true, "dried_kelp_block": true,
+	"muddy_mangrove_roots": true,
+}
+
+// isJavaSturdy roughly tells if the java block has full faces that fences, panes and wire can attach to,
+// blocks java excludes from connections (leaves, pumpkins, melons, shulker boxes, barriers) are not sturdy either
+func isJavaSturdy(name string, props map[string]string) bool {
+	if strings.HasSuffix(name, "_slab") {
+		return props["type"] == "double"
+	}
+	if javaSturdyNames[name] || strings.HasSuffix(name, "_mushroom_block") || strings.HasSuffix(name, "_coral_block") {
+		return true
+	}
+	if name == "bamboo" {
+		return false
+	}
+	for _, keyword := range javaNotSturdyKeywords {
+		if strings.Contains(name, keyword) {
+			return false
+		}
+	}
+	return name != ""
+}
+
+func boolString(b bool) string {
+	if b {
+		return "true"
+	}
+	return "false"
+}
@@ -0,0 +1,93 @@
+package blocks
+
+import "testing"
+
+// testBlocks is a BlockAccessor over a map of positions, other positions are air
+type testBlocks struct {
+	t      *testing.T
+	layers [2]map[[3]int32]uint32
+}
+
+func newTestBlocks(t *testing.T) *testBlocks {
+	return &testBlocks{t: t, layers: [2]map[[3]int32]uint32{{}, {}}}
+}
+
+func (b *testBlocks) set(x, y, z int32, layer uint8, block string) {
+	b.t.Helper()
+	rtid, found := BlockStrToRuntimeID(block)
+	if !found {
+		b.t.Fatalf("%v not found", block)
+	}
+	b.layers[layer][[3]int32{x, y, z}] = rtid
+}
+
+func (b *testBlocks) BlockAt(x, y, z int32, layer uint8) uint32 {
+	if rtid, ok := b.layers[layer][[3]int32{x, y, z}]; ok {
+		return rtid
+	}
+	return AIR_RUNTIMEID
+}
+
+func TestContextJavaConvertor(t *testing.T) {
+	b := newTestBlocks(t)
+	b.set(0, 0, 0, 0, "fence")
+	b.set(1, 0, 0, 0, "fence")
+	b.set(0, 0, 1, 0, "stone")
+	b.set(0, 0, 0, 1, `water ["liquid_depth":0]`)
+	b.set(5, 0, 0, 0, "grass")
+	b.set(5, 1, 0, 0, `snow_layer ["height":0]`)
+	c := NewContextJavaConvertor(b)
+
+	name, props, found := c.JavaBlock(0, 0, 0)
+	if !found || name != "oak_fence" {
+		t.Fatalf("fence: %v %v %v", name, props, found)
+	}
+	want := map[string]string{"east": "true", "south": "true", "west": "false", "north": "false", "waterlogged": "true"}
+	for k, v := range want {
+		if props[k] != v {
+			t.Errorf("fence %v = %v, want %v (%v)", k, props[k], v, props)
+		}
+	}
+	if str, _ := c.JavaBlockStr(5, 0, 0); str != "grass_block[snowy=true]" {
+		t.Errorf("grass under snow: %v", str)
+	}
+	if str, found := c.JavaBlockStr(9, 9, 9); found || str != "minecraft:air" {
+		t.Errorf("air: %v %v", str, found)
+	}
+}
+
+func TestContextJavaConvertorFidelity(t *testing.T) {
+	b := newTestBlocks(t)
+	for x := int32(0); x < 4; x++ {
+		b.set(x, 0, 0, 0, "stone")
+	}
+	b.set(0, 1, 0, 0, "dirt")
+	c := NewContextJavaConvertor(b)
+
+	StartFidelityReport()
+	c.JavaBlock(0, 0, 0)
+	c.at(0, 1, 0)
+	for x := int32(0); x < 4; x++ {
+		c.JavaBlock(x, 0, 0)
+	}
+	r := StopFidelityReport()
+	if e := findFidelityEntry(r.Entries(), "minecraft:stone", true); e == nil || e.Lookups != 1 {
+		t.Errorf("stone is recorded once per runtime id: %+v", e)
+	}
+	if e := findFidelityEntry(r.Entries(), "minecraft:dirt", true); e != nil {
+		t.Errorf("neighbours must not be recorded: %+v", e)
+	}
+}
+
+func TestParseJavaBlockString(t *testing.T) {
+	name, props := ParseJavaBlockString("minecraft:oak_stairs[facing=east, half=top]")
+	if name != "minecraft:oak_stairs" || len(props) != 2 || props["facing"] != "east" || props["half"] != "top" {
+		t.Errorf("got %v %v", name, props)
+	}
+	if JavaBlockString(name, props) != "minecraft:oak_stairs[facing=east,half=top]" {
+		t.Errorf("JavaBlockString: %v", JavaBlockString(name, props))
+	}
+	if name, props := ParseJavaBlockString("stone"); name != "stone" || len(props) != 0 {
+		t.Errorf("got %v %v", name, props)
+	}
+}
@@ -0,0 +1,281 @@
+package blocks
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/Yeah114/blocks/block_set"
+	"github.com/Yeah114/blocks/convertor"
+	"github.com/Yeah114/blocks/describe"
+
+	"github.com/andybalholm/brotli"
+)
+
+// NEMC_PALETTE_NAME is the name of the embedded NetEase palette (MC_CURRENT)
+const NEMC_PALETTE_NAME = "nemc"
+
+// BEDROCK_PALETTE_NAME is the name of the embedded international edition palette
+const BEDROCK_PALETTE_NAME = "bedrock"
+
+// Palette is a block set of one game version together with a convertor
+// that resolves block names/states to runtime ids of that block set
+type Palette struct {
+	name      string
+	blockSet  *block_set.BlockSet
+	convertor *convertor.ToNEMCConvertor
+}
+
+func (p *Palette) Name() string {
+	return p.name
+}
+
+func (p *Palette) BlockSet() *block_set.BlockSet {
+	return p.blockSet
+}
+
+func (p *Palette) Version() uint32 {
+	return p.blockSet.Version()
+}
+
+func (p *Palette) AirRuntimeID() uint32 {
+	return p.blockSet.AirRuntimeID()
+}
+
+func (p *Palette) RuntimeIDToBlock(runtimeID uint32) (block *describe.Block, found bool) {
+	block = p.blockSet.BlockByRtid(runtimeID)
+	return block, block != nil
+}
+
+func (p *Palette) BlockNameAndStateToRuntimeID(name string, properties map[string]any) (runtimeID uint32, found bool) {
+	props, err := describe.PropsForSearchFromNbt(properties)
+	if err != nil {
+		return p.AirRuntimeID(), false
+	}
+	rtid, _, found := p.convertor.TryBestSearchByState(describe.BlockNameForSearch(name), props)
+	return rtid, found
+}
+
+func (p *Palette) BlockStrToRuntimeID(blockNameWithOrWithoutState string) (runtimeID uint32, found bool) {
+	blockName, blockProps := ConvertStringToBlockNameAndPropsForSearch(strings.TrimSpace(blockNameWithOrWithoutState))
+	rtid, _, found := p.convertor.TryBestSearchByState(blockName, blockProps)
+	return rtid, found
+}
+
+// searchBlock finds the block of this palette closest to a block of another palette:
+// first by name and states, then by name and legacy value
+func (p *Palette) searchBlock(block *describe.Block) (runtimeID uint32, found bool) {
+	if rtid, _, found := p.convertor.TryBestSearchByState(block.NameForSearch(), block.StatesForSearch()); found {
+		return rtid, true
+	}
+	return p.convertor.TryBestSearchByLegacyValue(block.NameForSearch(), block.LegacyValue())
+}
+
+// NewPalette creates a palette from a block set, the convertor only knows the blocks of the set itself
+func NewPalette(name string, bs *block_set.BlockSet) *Palette {
+	return &Palette{
+		name:      name,
+		blockSet:  bs,
+		convertor: bs.CreateEmptyConvertor(),
+	}
+}
+
+// PaletteFromStringRecords parses block set records (the format of DumpStringRecords)
+func PaletteFromStringRecords(name string, records string) (p *Palette, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("invalid block set records: %v", r)
+		}
+	}()
+	return NewPalette(name, block_set.BlockSetFromStringRecords(records, 0xFFFFFFFF)), nil
+}
+
+// PaletteFromFile loads block set records from a plain text or brotli compressed (.br) file,
+// the palette is named after the file name without extension
+func PaletteFromFile(path string) (*Palette, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.HasPrefix(data, []byte("VERSION:")) {
+		data, err = io.ReadAll(brotli.NewReader(bytes.NewReader(data)))
+		if err != nil {
+			return nil, fmt.Errorf("%v is neither block set records nor brotli compressed records: %w", path, err)
+		}
+	}
+	name := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	return PaletteFromStringRecords(name, string(data))
+}
+
+var palettes = struct {
+	sync.RWMutex
+	byName map[string]*Palette
+}{byName: map[string]*Palette{}}
+
+// RegisterPalette makes a palette available by its name, an existing palette with the same name is replaced
+// except for the embedded nemc palette
+func RegisterPalette(p *Palette) error {
+	palettes.Lock()
+	defer palettes.Unlock()
+	if p.name == NEMC_PALETTE_NAME && palettes.byName[NEMC_PALETTE_NAME] != nil {
+		return fmt.Errorf("palette %v is embedded and cannot be replaced", NEMC_PALETTE_NAME)
+	}
+	palettes.byName[p.name] = p
+	return nil
+}
+
+// LoadPaletteDir registers every block set file (*.txt, *.br) in dir, returns the registered palettes
+func LoadPaletteDir(dir string) ([]*Palette, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	loaded := []*Palette{}
+	for _, entry := range entries {
+		ext := strings.ToLower(filepath.Ext(entry.Name()))
+		if entry.IsDir() || (ext != ".txt" && ext != ".br") {
+			continue
+		}
+		p, err := PaletteFromFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return loaded, err
+		}
+		if err := RegisterPalette(p); err != nil {
+			return loaded, err
+		}
+		loaded = append(loaded, p)
+	}
+	return loaded, nil
+}
+
+// GetPalette returns a registered palette, name is case insensitive
+func GetPalette(name string) (p *Palette, found bool) {
+	palettes.RLock()
+	defer palettes.RUnlock()
+	if p, found = palettes.byName[name]; found {
+		return p, true
+	}
+	for n, p := range palettes.byName {
+		if strings.EqualFold(n, name) {
+			return p, true
+		}
+	}
+	return nil, false
+}
+
+// NEMCPalette returns the embedded NetEase palette
+func NEMCPalette() *Palette {
+	p, _ := GetPalette(NEMC_PALETTE_NAME)
+	return p
+}
+
+// Palettes returns all registered palettes sorted by name
+func Palettes() []*Palette {
+	palettes.RLock()
+	defer palettes.RUnlock()
+	list := make([]*Palette, 0, len(palettes.byName))
+	for _, p := range palettes.byName {
+		list = append(list, p)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].name < list[j].name })
+	return list
+}
+
+func initNEMCPalette() {
+	p := &Palette{
+		name:      NEMC_PALETTE_NAME,
+		blockSet:  MC_CURRENT,
+		convertor: DefaultAnyToNemcConvertor,
+	}
+	if err := RegisterPalette(p); err != nil {
+		panic(err)
+	}
+}
+
+func initBedrockPalette() {
+	p, err := PaletteFromStringRecords(BEDROCK_PALETTE_NAME, readAndUnpack(bedrockBlockInfoBytes))
+	if err != nil {
+		panic(err)
+	}
+	if err := RegisterPalette(p); err != nil {
+		panic(err)
+	}
+}
+
+// RuntimeIDTranslator translates runtime ids from one palette to another,
+// results are cached so translating a whole chunk only searches each block once
+type RuntimeIDTranslator struct {
+	from, to *Palette
+	mu       sync.RWMutex
+	cache    map[uint32]uint32
+	missing  map[uint32]bool
+}
+
+func NewRuntimeIDTranslator(from, to *Palette) *RuntimeIDTranslator {
+	return &RuntimeIDTranslator{
+		from:    from,
+		to:      to,
+		cache:   map[uint32]uint32{},
+		missing: map[uint32]bool{},
+	}
+}
+
+// Translate returns the runtime id in the target palette,
+// blocks that do not exist in the target palette become air and found is false
+func (t *RuntimeIDTranslator) Translate(runtimeID uint32) (translated uint32, found bool) {
+	if t.from == t.to {
+		return runtimeID, t.from.blockSet.BlockByRtid(runtimeID) != nil
+	}
+	t.mu.RLock()
+	translated, cached := t.cache[runtimeID]
+	missing := t.missing[runtimeID]
+	t.mu.RUnlock()
+	if cached {
+		return translated, !missing
+	}
+
+	translated, found = t.to.AirRuntimeID(), false
+	if block, ok := t.from.RuntimeIDToBlock(runtimeID); ok {
+		if rtid, ok := t.to.searchBlock(block); ok {
+			translated, found = rtid, true
+		}
+	}
+	t.mu.Lock()
+	t.cache[runtimeID] = translated
+	if !found {
+		t.missing[runtimeID] = true
+	}
+	t.mu.Unlock()
+	return translated, found
+}
+
+// Missing returns the runtime ids (of the source palette) that could not be translated so far
+func (t *RuntimeIDTranslator) Missing() []uint32 {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	list := make([]uint32, 0, len(t.missing))
+	for rtid := range t.missing {
+		list = append(list, rtid)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i] < list[j] })
+	return list
+}
+
+// TranslateRuntimeID translates a single runtime id between two registered palettes
+func TranslateRuntimeID(fromPalette, toPalette string, runtimeID uint32) (translated uint32, found bool, err error) {
+	from, ok := GetPalette(fromPalette)
+	if !ok {
+		return 0, false, fmt.Errorf("palette %v not found", fromPalette)
+	}
+	to, ok := GetPalette(toPalette)
+	if !ok {
+		return 0, false, fmt.Errorf("palette %v not found", toPalette)
+	}
+	translated, found = NewRuntimeIDTranslator(from, to).Translate(runtimeID)
+	return translated, found, nil
+}
@@ -0,0 +1,132 @@
+package blocks
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// recordsWithout returns the records of MC_CURRENT without the blocks named drop,
+// the runtime ids of every following block shift
+func recordsWithout(drop string) string {
+	lines := strings.Split(strings.TrimSuffix(MC_CURRENT.DumpStringRecords(), "\n"), "\n")
+	kept := []string{}
+	for _, line := range lines[2:] {
+		if !strings.HasPrefix(line, drop+" ") {
+			kept = append(kept, line)
+		}
+	}
+	return fmt.Sprintf("%v\nCOUNTS:%v\n%v\n", lines[0], len(kept), strings.Join(kept, "\n"))
+}
+
+func testPalette(t *testing.T, name, drop string) *Palette {
+	t.Helper()
+	p, err := PaletteFromStringRecords(name, recordsWithout(drop))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func TestRuntimeIDTranslator(t *testing.T) {
+	nemc := NEMCPalette()
+	p := testPalette(t, "without_barrier", "barrier")
+	tr := NewRuntimeIDTranslator(nemc, p)
+
+	stairs, found := nemc.BlockStrToRuntimeID(`oak_stairs ["weirdo_direction":2,"upside_down_bit":true]`)
+	if !found {
+		t.Fatal("oak_stairs not found")
+	}
+	translated, found := tr.Translate(stairs)
+	if !found {
+		t.Fatal("oak_stairs not translated")
+	}
+	src, _ := nemc.RuntimeIDToBlock(stairs)
+	dst, _ := p.RuntimeIDToBlock(translated)
+	if src.BedrockString() != dst.BedrockString() {
+		t.Errorf("translated %v to %v", src.BedrockString(), dst.BedrockString())
+	}
+	if back, found := NewRuntimeIDTranslator(p, nemc).Translate(translated); !found || back != stairs {
+		t.Errorf("translated back to %v, want %v", back, stairs)
+	}
+
+	barrier, _ := nemc.BlockStrToRuntimeID("barrier")
+	if rtid, found := tr.Translate(barrier); found || rtid != p.AirRuntimeID() {
+		t.Errorf("missing block translated to %v (found %v), want air", rtid, found)
+	}
+	if rtid, found := tr.Translate(barrier); found || rtid != p.AirRuntimeID() {
+		t.Errorf("cached missing block translated to %v (found %v), want air", rtid, found)
+	}
+	if missing := tr.Missing(); len(missing) != 1 || missing[0] != barrier {
+		t.Errorf("Missing() = %v, want [%v]", missing, barrier)
+	}
+}
+
+func TestRuntimeIDTranslatorSamePalette(t *testing.T) {
+	nemc := NEMCPalette()
+	stone, _ := nemc.BlockStrToRuntimeID("stone")
+	if rtid, found := NewRuntimeIDTranslator(nemc, nemc).Translate(stone); !found || rtid != stone {
+		t.Errorf("got %v (found %v), want %v", rtid, found, stone)
+	}
+}
+
+func TestTranslateRuntimeIDUnknownPalette(t *testing.T) {
+	if _, _, err := TranslateRuntimeID(NEMC_PALETTE_NAME, "no_such_palette", 0); err == nil {
+		t.Error("expected an error for an unknown palette")
+	}
+}
+
+func TestRegisterPalette(t *testing.T) {
+	if err := RegisterPalette(testPalette(t, NEMC_PALETTE_NAME, "barrier")); err == nil {
+		t.Error("the embedded palette must not be replaceable")
+	}
+	if err := RegisterPalette(testPalette(t, "Registered_Test", "barrier")); err != nil {
+		t.Fatal(err)
+	}
+	if p, found := GetPalette("registered_test"); !found || p.Name() != "Registered_Test" {
+		t.Errorf("GetPalette is not case insensitive: %v %v", p, found)
+	}
+}
+
+func TestLoadPaletteDir(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "dir_test.txt"), []byte(recordsWithout("barrier")), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "notes.md"), []byte("not a palette"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := LoadPaletteDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(loaded) != 1 || loaded[0].Name() != "dir_test" {
+		t.Fatalf("loaded %v", loaded)
+	}
+	if len(loaded[0].BlockSet().Blocks()) >= len(MC_CURRENT.Blocks()) {
+		t.Error("the dropped block is still in the palette")
+	}
+}
+
+func TestBedrockPalette(t *testing.T) {
+	p, found := GetPalette(BEDROCK_PALETTE_NAME)
+	if !found {
+		t.Fatal("the embedded bedrock palette is not registered")
+	}
+	if p.Version() <= NEMCPalette().Version() {
+		t.Errorf("bedrock palette version %v is not newer than nemc %v", p.Version(), NEMCPalette().Version())
+	}
+	if block, _ := p.RuntimeIDToBlock(p.AirRuntimeID()); block.ShortName() != "air" {
+		t.Errorf("air runtime id points to %v", block.ShortName())
+	}
+	stairs, _ := NEMCPalette().BlockStrToRuntimeID(`oak_stairs ["weirdo_direction":2,"upside_down_bit":true]`)
+	translated, found := NewRuntimeIDTranslator(NEMCPalette(), p).Translate(stairs)
+	if !found {
+		t.Fatal("oak_stairs not translated to the bedrock palette")
+	}
+	if block, _ := p.RuntimeIDToBlock(translated); block.ShortName() != "oak_stairs" {
+		t.Errorf("translated to %v", block.BedrockString())
+	}
+}
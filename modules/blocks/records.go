@@ -0,0 +1,127 @@
+package blocks
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Yeah114/blocks/convertor"
+
+	"github.com/andybalholm/brotli"
+)
+
+// RecordLoadReport is the result of loading additional conversion records
+type RecordLoadReport struct {
+	Source    string
+	Loaded    int // records actually added, conflicting records only count when overwritten
+	Rejected  int // conflicting records not loaded because overwrite is off
+	Conflicts []*convertor.RecordConflict
+	Skipped   []string // malformed records, with reason
+}
+
+// readRecordFile reads a record file, files with .br extension are brotli compressed
+func readRecordFile(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	if strings.EqualFold(filepath.Ext(path), ".br") {
+		data, err = io.ReadAll(brotli.NewReader(bytes.NewReader(data)))
+		if err != nil {
+			return "", fmt.Errorf("fail to decompress %v: %w", path, err)
+		}
+	}
+	return string(data), nil
+}
+
+// readRecordsSafely parses ConvertRecord text, out of range legacy values are returned as error instead of panic
+func readRecordsSafely(records string) (rs []*convertor.ConvertRecord, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("invalid record: %v", r)
+		}
+	}()
+	return convertor.ReadRecordsFromString(records)
+}
+
+// LoadConvertRecords loads additional ConvertRecord text (the format ReadRecordsFromString parses)
+// into DefaultAnyToNemcConvertor, overwrite replaces existing mappings of the same name and state
+func LoadConvertRecords(source, records string, overwrite bool) (*RecordLoadReport, error) {
+	rs, err := readRecordsSafely(records)
+	if err != nil {
+		return nil, fmt.Errorf("fail to read records from %v: %w", source, err)
+	}
+	report := &RecordLoadReport{Source: source}
+	numBlocks := uint32(len(MC_CURRENT.Blocks()))
+	for i, r := range rs {
+		if r.RTID >= numBlocks {
+			report.Skipped = append(report.Skipped, fmt.Sprintf("record %v (%v %v): runtime id %v out of range", i+1, r.Name, r.SNBTStateOrValue, r.RTID))
+			continue
+		}
+		conflict, err := DefaultAnyToNemcConvertor.LoadConvertRecordWithReport(r, overwrite)
+		if err != nil {
+			report.Skipped = append(report.Skipped, fmt.Sprintf("record %v (%v %v): %v", i+1, r.Name, r.SNBTStateOrValue, err))
+			continue
+		}
+		if conflict != nil {
+			report.Conflicts = append(report.Conflicts, conflict)
+			if !conflict.Overwritten {
+				report.Rejected++
+				continue
+			}
+		}
+		report.Loaded++
+	}
+	// schematic ids are resolved once, rebuild them so the new records also apply to schematic files
+	if report.Loaded > 0 {
+		initSchematicBlockCheck(DefaultAnyToNemcConvertor)
+	}
+	return report, nil
+}
+
+// LoadConvertRecordsFile loads additional ConvertRecord files, see LoadConvertRecords
+func LoadConvertRecordsFile(path string, overwrite bool) (*RecordLoadReport, error) {
+	records, err := readRecordFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return LoadConvertRecords(path, records, overwrite)
+}
+
+// LoadJavaConvertRecords loads additional JavaConvertRecord text (the format ReadJavaRecordsFromString parses)
+// into BedrockToJavaConvertor, overwrite replaces existing mappings of the same name and state
+func LoadJavaConvertRecords(source, records string, overwrite bool) (*RecordLoadReport, error) {
+	rs, err := convertor.ReadJavaRecordsFromString(records)
+	if err != nil {
+		return nil, fmt.Errorf("fail to read java records from %v: %w", source, err)
+	}
+	report := &RecordLoadReport{Source: source}
+	for i, r := range rs {
+		conflict, err := BedrockToJavaConvertor.LoadJavaConvertRecordWithReport(r, overwrite)
+		if err != nil {
+			report.Skipped = append(report.Skipped, fmt.Sprintf("record %v (%v %v): %v", i+1, r.Name, r.SNBTStateOrValue, err))
+			continue
+		}
+		if conflict != nil {
+			report.Conflicts = append(report.Conflicts, conflict)
+			if !conflict.Overwritten {
+				report.Rejected++
+				continue
+			}
+		}
+		report.Loaded++
+	}
+	return report, nil
+}
+
+// LoadJavaConvertRecordsFile loads additional JavaConvertRecord files, see LoadJavaConvertRecords
+func LoadJavaConvertRecordsFile(path string, overwrite bool) (*RecordLoadReport, error) {
+	records, err := readRecordFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return LoadJavaConvertRecords(path, records, overwrite)
+}
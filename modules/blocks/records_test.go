@@ -0,0 +1,99 @@
+package blocks
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func legacyRecord(name string, data int, rtid uint32) string {
+	return fmt.Sprintf("%v\n%v\n%v\n", name, data, rtid)
+}
+
+func TestLoadConvertRecords(t *testing.T) {
+	stone, _ := BlockStrToRuntimeID("stone")
+	dirt, _ := BlockStrToRuntimeID("dirt")
+
+	report, err := LoadConvertRecords("test", legacyRecord("records_test_block", 0, stone), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Loaded != 1 || len(report.Conflicts) != 0 || len(report.Skipped) != 0 {
+		t.Errorf("new record: %+v", report)
+	}
+	if rtid, found := LegacyBlockToRuntimeID("records_test_block", 0); !found || rtid != stone {
+		t.Errorf("records_test_block 0 converts to %v, want %v", rtid, stone)
+	}
+
+	report, err = LoadConvertRecords("test", legacyRecord("records_test_block", 0, dirt), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Loaded != 0 || report.Rejected != 1 || len(report.Conflicts) != 1 || report.Conflicts[0].Overwritten {
+		t.Errorf("conflicting record without overwrite: %+v", report)
+	}
+	if rtid, _ := LegacyBlockToRuntimeID("records_test_block", 0); rtid != stone {
+		t.Errorf("conflicting record without overwrite replaced the mapping: %v", rtid)
+	}
+
+	report, err = LoadConvertRecords("test", legacyRecord("records_test_block", 0, dirt), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Loaded != 1 || report.Rejected != 0 || len(report.Conflicts) != 1 || !report.Conflicts[0].Overwritten {
+		t.Errorf("conflicting record with overwrite: %+v", report)
+	}
+	if rtid, _ := LegacyBlockToRuntimeID("records_test_block", 0); rtid != dirt {
+		t.Errorf("records_test_block 0 converts to %v after overwrite, want %v", rtid, dirt)
+	}
+}
+
+func TestLoadConvertRecordsSkipsInvalid(t *testing.T) {
+	outOfRange := uint32(len(MC_CURRENT.Blocks()))
+	report, err := LoadConvertRecords("test", legacyRecord("records_test_invalid", 0, outOfRange), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Loaded != 0 || len(report.Skipped) != 1 {
+		t.Errorf("out of range runtime id: %+v", report)
+	}
+	if _, err := LoadConvertRecords("test", "records_test_invalid\n0\nnot a number\n", false); err == nil {
+		t.Error("malformed records should fail to load")
+	}
+}
+
+func TestLoadConvertRecordsFile(t *testing.T) {
+	stone, _ := BlockStrToRuntimeID("stone")
+	path := filepath.Join(t.TempDir(), "records.txt")
+	if err := os.WriteFile(path, []byte(legacyRecord("records_test_file", 3, stone)), 0644); err != nil {
+		t.Fatal(err)
+	}
+	report, err := LoadConvertRecordsFile(path, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Source != path || report.Loaded != 1 {
+		t.Errorf("report: %+v", report)
+	}
+	if _, err := LoadConvertRecordsFile(filepath.Join(t.TempDir(), "missing.txt"), false); err == nil {
+		t.Error("missing file should fail to load")
+	}
+}
+
+func TestLoadJavaConvertRecords(t *testing.T) {
+	report, err := LoadJavaConvertRecords("test", "records_test_java\n0\nminecraft:stone\n{}\n", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Loaded != 1 || len(report.Conflicts) != 0 {
+		t.Errorf("new java record: %+v", report)
+	}
+	report, err = LoadJavaConvertRecords("test", "records_test_java\n0\nminecraft:dirt\n{}\n", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Loaded != 0 || report.Rejected != 1 || len(report.Conflicts) != 1 || report.Conflicts[0].Overwritten {
+		t.Errorf("conflicting java record without overwrite: %+v", report)
+	}
+}
@@ -0,0 +1,271 @@
+package blocks
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/Yeah114/blocks/block_set"
+	"github.com/Yeah114/blocks/describe"
+)
+
+// StateSchema describes one state key of a block and all values it can take
+type StateSchema struct {
+	Name   string
+	Type   describe.PropValType
+	Values []describe.PropVal
+}
+
+// Allows tells if the value (in any fuzzy form, e.g. true/1b/"true") is one of the values of the state
+func (s *StateSchema) Allows(value describe.PropValForSearch) bool {
+	return s.find(value) != nil
+}
+
+func (s *StateSchema) find(value describe.PropValForSearch) describe.PropVal {
+	for _, v := range s.Values {
+		if describe.FuzzyPropValForSearchFromPropVal(v).FuzzyEqual(value) {
+			return v
+		}
+	}
+	return nil
+}
+
+// ValueStrings returns the values in bedrock string form
+func (s *StateSchema) ValueStrings() []string {
+	vals := make([]string, 0, len(s.Values))
+	for _, v := range s.Values {
+		vals = append(vals, v.BedrockString())
+	}
+	return vals
+}
+
+// BlockSchema describes the valid states of a block base name, derived from all its variants in a BlockSet
+type BlockSchema struct {
+	Name     string
+	States   []*StateSchema // sorted by name
+	variants []*describe.Block
+}
+
+// State returns the schema of a state key, "minecraft:" prefix is ignored
+func (s *BlockSchema) State(key string) (*StateSchema, bool) {
+	key = strings.TrimPrefix(key, "minecraft:")
+	for _, st := range s.States {
+		if st.Name == key {
+			return st, true
+		}
+	}
+	return nil, false
+}
+
+// Variants returns every block (state combination) of the base name, in runtime id order
+func (s *BlockSchema) Variants() []*describe.Block {
+	return s.variants
+}
+
+// ValidStates returns every state key and its values in bedrock string form
+func (s *BlockSchema) ValidStates() map[string][]string {
+	states := make(map[string][]string, len(s.States))
+	for _, st := range s.States {
+		states[st.Name] = st.ValueStrings()
+	}
+	return states
+}
+
+// Validate checks that every given state key and value exists and that some variant has all of them,
+// complete also requires every state key of the block to be given. The error is an *InvalidStateError
+func (s *BlockSchema) Validate(props *describe.PropsForSearch, complete bool) error {
+	invalid := func(key, value string) error {
+		return &InvalidStateError{Name: s.Name, State: props.InPreciseSNBT(), Key: key, Value: value, ValidStates: s.ValidStates()}
+	}
+	given := map[string]bool{}
+	if props != nil {
+		for _, p := range *props {
+			st, ok := s.State(p.Name)
+			if !ok {
+				return invalid(strings.TrimPrefix(p.Name, "minecraft:"), "")
+			}
+			if !st.Allows(p.Value) {
+				return invalid(st.Name, p.Value.InPreciseSNBT())
+			}
+			given[st.Name] = true
+		}
+	}
+	if complete {
+		for _, st := range s.States {
+			if !given[st.Name] {
+				return &InvalidStateError{Name: s.Name, State: props.InPreciseSNBT(), Err: fmt.Errorf("missing state %v", st.Name), ValidStates: s.ValidStates()}
+			}
+		}
+	}
+	if _, found := s.Match(props); !found {
+		return &InvalidStateError{Name: s.Name, State: props.InPreciseSNBT(), Err: fmt.Errorf("no variant has this combination of states"), ValidStates: s.ValidStates()}
+	}
+	return nil
+}
+
+// Match returns the first variant having all the given states (other states may take any value)
+func (s *BlockSchema) Match(props *describe.PropsForSearch) (*describe.Block, bool) {
+	for _, b := range s.variants {
+		if hasStates(b, props) {
+			return b, true
+		}
+	}
+	return nil, false
+}
+
+// WithState returns the variant equal to block except that key is set to value,
+// useful to enumerate rotations or mirrored variants of a block
+func (s *BlockSchema) WithState(block *describe.Block, key string, value describe.PropValForSearch) (*describe.Block, bool) {
+	key = strings.TrimPrefix(key, "minecraft:")
+	if _, ok := s.State(key); !ok || block == nil {
+		return nil, false
+	}
+	want := describe.PropsForSearch{{Name: key, Value: value}}
+	for _, p := range block.States() {
+		if strings.TrimPrefix(p.Name, "minecraft:") != key {
+			want = append(want, describe.PropForSearch{Name: p.Name, Value: describe.FuzzyPropValForSearchFromPropVal(p.Value)})
+		}
+	}
+	return s.Match(&want)
+}
+
+// StateVariants returns the variants equal to block except for the value of key, one per value of key
+func (s *BlockSchema) StateVariants(block *describe.Block, key string) []*describe.Block {
+	st, ok := s.State(key)
+	if !ok {
+		return nil
+	}
+	var variants []*describe.Block
+	for _, v := range st.Values {
+		if b, found := s.WithState(block, key, describe.FuzzyPropValForSearchFromPropVal(v)); found {
+			variants = append(variants, b)
+		}
+	}
+	return variants
+}
+
+func hasStates(b *describe.Block, props *describe.PropsForSearch) bool {
+	if props == nil {
+		return true
+	}
+	for _, p := range *props {
+		key := strings.TrimPrefix(p.Name, "minecraft:")
+		matched := false
+		for _, st := range b.States() {
+			if strings.TrimPrefix(st.Name, "minecraft:") == key {
+				matched = describe.FuzzyPropValForSearchFromPropVal(st.Value).FuzzyEqual(p.Value)
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	return true
+}
+
+// Schema holds the BlockSchema of every base name in a BlockSet
+type Schema struct {
+	blocks map[string]*BlockSchema
+	names  []string
+}
+
+// BuildSchema derives the state schema of every block in the BlockSet
+func BuildSchema(bs *block_set.BlockSet) *Schema {
+	schema := &Schema{blocks: map[string]*BlockSchema{}}
+	seen := map[string]map[string]bool{}
+	for _, b := range bs.Blocks() {
+		name := b.ShortName()
+		bsc, ok := schema.blocks[name]
+		if !ok {
+			bsc = &BlockSchema{Name: name}
+			schema.blocks[name] = bsc
+			schema.names = append(schema.names, name)
+			seen[name] = map[string]bool{}
+		}
+		bsc.variants = append(bsc.variants, b)
+		for _, p := range b.States() {
+			key := strings.TrimPrefix(p.Name, "minecraft:")
+			st, ok := bsc.State(key)
+			if !ok {
+				st = &StateSchema{Name: key, Type: p.Value.Type()}
+				bsc.States = append(bsc.States, st)
+			}
+			if id := key + "=" + p.Value.SNBTString(); !seen[name][id] {
+				seen[name][id] = true
+				st.Values = append(st.Values, p.Value)
+			}
+		}
+	}
+	for _, bsc := range schema.blocks {
+		sort.Slice(bsc.States, func(i, j int) bool { return bsc.States[i].Name < bsc.States[j].Name })
+		for _, st := range bsc.States {
+			sortPropVals(st.Values)
+		}
+	}
+	sort.Strings(schema.names)
+	return schema
+}
+
+func sortPropVals(vals []describe.PropVal) {
+	sort.Slice(vals, func(i, j int) bool {
+		vi, erri := strconv.Atoi(vals[i].BedrockString())
+		vj, errj := strconv.Atoi(vals[j].BedrockString())
+		if erri == nil && errj == nil {
+			return vi < vj
+		}
+		return vals[i].BedrockString() < vals[j].BedrockString()
+	})
+}
+
+// Block returns the schema of a base name, "minecraft:" prefix is ignored
+func (s *Schema) Block(name string) (*BlockSchema, bool) {
+	bsc, ok := s.blocks[trimNamespace(name)]
+	return bsc, ok
+}
+
+// Names returns all base names, sorted
+func (s *Schema) Names() []string {
+	return s.names
+}
+
+// Validate checks name and states (bedrock state string like `["a"=1,"b"="x"]`) against the schema,
+// returning *UnknownBlockError or *InvalidStateError
+func (s *Schema) Validate(name, stateStr string, complete bool) error {
+	bsc, ok := s.Block(name)
+	if !ok {
+		return &UnknownBlockError{Name: name, Suggestions: suggestNames(s.names, name, maxSuggestions)}
+	}
+	props, err := describe.PropsForSearchFromStr(stateStr)
+	if err != nil {
+		return &InvalidStateError{Name: bsc.Name, State: stateStr, Err: err, ValidStates: bsc.ValidStates()}
+	}
+	return bsc.Validate(props, complete)
+}
+
+var (
+	currentSchemaOnce sync.Once
+	currentSchema     *Schema
+)
+
+// CurrentSchema returns the schema of MC_CURRENT
+func CurrentSchema() *Schema {
+	currentSchemaOnce.Do(func() { currentSchema = BuildSchema(MC_CURRENT) })
+	return currentSchema
+}
+
+// ValidateBlockStates checks name and states against CurrentSchema, see Schema.Validate
+func ValidateBlockStates(name, stateStr string, complete bool) error {
+	return CurrentSchema().Validate(name, stateStr, complete)
+}
+
+// BlockVariants returns every state variant of the base name in MC_CURRENT
+func BlockVariants(name string) []*describe.Block {
+	bsc, ok := CurrentSchema().Block(name)
+	if !ok {
+		return nil
+	}
+	return bsc.Variants()
+}
@@ -0,0 +1,119 @@
+package blocks
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/Yeah114/blocks/describe"
+)
+
+func TestBuildSchema(t *testing.T) {
+	bsc, ok := CurrentSchema().Block("minecraft:oak_stairs")
+	if !ok {
+		t.Fatal("oak_stairs not in schema")
+	}
+	if len(bsc.States) != 2 || bsc.States[0].Name != "upside_down_bit" || bsc.States[1].Name != "weirdo_direction" {
+		t.Fatalf("oak_stairs states: %v", bsc.ValidStates())
+	}
+	direction, _ := bsc.State("minecraft:weirdo_direction")
+	if got := direction.ValueStrings(); len(got) != 4 || got[0] != "0" || got[3] != "3" {
+		t.Errorf("weirdo_direction values: %v", got)
+	}
+	if len(bsc.Variants()) != 8 {
+		t.Errorf("oak_stairs has %v variants, want 8", len(bsc.Variants()))
+	}
+	if len(BlockVariants("oak_stairs")) != 8 || BlockVariants("schema_test_unknown") != nil {
+		t.Error("BlockVariants does not match the schema")
+	}
+	if _, ok := CurrentSchema().Block("schema_test_unknown"); ok {
+		t.Error("unknown block found in schema")
+	}
+}
+
+func TestSchemaValidate(t *testing.T) {
+	s := CurrentSchema()
+	valid := []struct {
+		state    string
+		complete bool
+	}{
+		{`["weirdo_direction":2]`, false},
+		{`["weirdo_direction":2,"upside_down_bit":true]`, true},
+		{`["upside_down_bit":1b]`, false},
+		{"", false},
+	}
+	for _, c := range valid {
+		if err := s.Validate("oak_stairs", c.state, c.complete); err != nil {
+			t.Errorf("%v (complete %v): %v", c.state, c.complete, err)
+		}
+	}
+
+	invalid := []struct {
+		state    string
+		complete bool
+		key      string
+		parseErr bool
+	}{
+		{`["weirdo_direction":7]`, false, "weirdo_direction", false},
+		{`["facing_direction":2]`, false, "facing_direction", false},
+		{`["weirdo_direction":2]`, true, "", true},
+		{`[weirdo_direction]`, false, "", true},
+	}
+	for _, c := range invalid {
+		err := s.Validate("oak_stairs", c.state, c.complete)
+		var invalid *InvalidStateError
+		if !errors.As(err, &invalid) {
+			t.Errorf("%v (complete %v): got %v, want *InvalidStateError", c.state, c.complete, err)
+			continue
+		}
+		if invalid.Key != c.key || (invalid.Err != nil) != c.parseErr || len(invalid.ValidStates) != 2 {
+			t.Errorf("%v (complete %v): %+v", c.state, c.complete, invalid)
+		}
+	}
+
+	var unknown *UnknownBlockError
+	if err := s.Validate("oak_stair", "", false); !errors.As(err, &unknown) || len(unknown.Suggestions) == 0 || unknown.Suggestions[0] != "oak_stairs" {
+		t.Errorf("unknown block: %v", err)
+	}
+}
+
+func stairsState(b *describe.Block, key string) string {
+	for _, p := range b.States() {
+		if p.Name == key {
+			return p.Value.BedrockString()
+		}
+	}
+	return ""
+}
+
+func TestWithStateAndStateVariants(t *testing.T) {
+	bsc, _ := CurrentSchema().Block("oak_stairs")
+	props, err := describe.PropsForSearchFromStr(`["weirdo_direction":1,"upside_down_bit":true]`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	block, found := bsc.Match(props)
+	if !found {
+		t.Fatal("no oak_stairs variant facing 1 upside down")
+	}
+
+	rotated, found := bsc.WithState(block, "weirdo_direction", describe.FuzzyPropValForSearchFromPropVal(describe.PropValInt32(3)))
+	if !found || stairsState(rotated, "weirdo_direction") != "3" || stairsState(rotated, "upside_down_bit") != stairsState(block, "upside_down_bit") {
+		t.Errorf("WithState: %v", rotated)
+	}
+	if _, found := bsc.WithState(block, "facing_direction", describe.FuzzyPropValForSearchFromPropVal(describe.PropValInt32(3))); found {
+		t.Error("WithState accepted a key the block does not have")
+	}
+
+	variants := bsc.StateVariants(block, "weirdo_direction")
+	if len(variants) != 4 {
+		t.Fatalf("StateVariants returned %v variants, want 4", len(variants))
+	}
+	for i, v := range variants {
+		if stairsState(v, "weirdo_direction") != string(rune('0'+i)) || stairsState(v, "upside_down_bit") != stairsState(block, "upside_down_bit") {
+			t.Errorf("variant %v: %v", i, v)
+		}
+	}
+	if bsc.StateVariants(block, "facing_direction") != nil {
+		t.Error("StateVariants of an unknown key should be nil")
+	}
+}
@@ -0,0 +1,245 @@
+package blocks
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/Yeah114/blocks/describe"
+)
+
+// SchematicID is the block id (12 bit when the schematic has AddBlocks) and data value of an MCEdit schematic block
+type SchematicID struct {
+	Block uint16
+	Data  uint8
+}
+
+type schematicReverse struct {
+	id    SchematicID
+	exact bool
+}
+
+var runtimeIDToSchematic map[uint32]schematicReverse
+
+// initSchematicReverse maps every runtime id to a schematic id. Runtime ids which some id and data value
+// converts to map back exactly, preferring data 0 and then the lowest block id when several ids convert
+// to the same block. The others map to the data value of the same block sharing the most states,
+// so orientation and colour survive even when a bedrock-only state differs
+func initSchematicReverse() {
+	runtimeIDToSchematic = make(map[uint32]schematicReverse)
+	candidates := map[string][]SchematicID{}
+	for blockI := 0; blockI < 256; blockI++ {
+		// java schematics only keep 4 bit data values
+		for dataI := 0; dataI < 16; dataI++ {
+			rtid := quickSchematicMapping[blockI][dataI]
+			if rtid == AIR_RUNTIMEID && blockI != 0 {
+				continue
+			}
+			id := SchematicID{Block: uint16(blockI), Data: uint8(dataI)}
+			if r, exists := runtimeIDToSchematic[rtid]; exists && (r.id.Data == 0 || id.Data != 0) {
+				continue
+			}
+			runtimeIDToSchematic[rtid] = schematicReverse{id: id, exact: true}
+			if block, ok := RuntimeIDToBlock(rtid); ok {
+				candidates[block.ShortName()] = append(candidates[block.ShortName()], id)
+			}
+		}
+	}
+	for _, block := range MC_CURRENT.Blocks() {
+		if _, exists := runtimeIDToSchematic[block.Rtid()]; exists {
+			continue
+		}
+		best, bestScore := SchematicID{}, -1
+		for _, id := range candidates[block.ShortName()] {
+			target, _ := RuntimeIDToBlock(quickSchematicMapping[id.Block][id.Data])
+			if score := sameStates(block, target); score > bestScore {
+				best, bestScore = id, score
+			}
+		}
+		if bestScore >= 0 {
+			runtimeIDToSchematic[block.Rtid()] = schematicReverse{id: best, exact: false}
+		}
+	}
+}
+
+func sameStates(a, b *describe.Block) int {
+	same := 0
+	for _, pa := range a.States() {
+		for _, pb := range b.States() {
+			if pa.Name == pb.Name {
+				if pa.Value.SNBTString() == pb.Value.SNBTString() {
+					same++
+				}
+				break
+			}
+		}
+	}
+	return same
+}
+
+// RuntimeIDToSchematicID returns the schematic id of a runtime id, exact is false if the block has
+// a state no id and data value converts to and the closest data value of the same block is returned
+func RuntimeIDToSchematicID(runtimeID uint32) (id SchematicID, exact bool, found bool) {
+	r, found := runtimeIDToSchematic[runtimeID]
+	return r.id, r.exact, found
+}
+
+// SchematicIDToRuntimeID converts a schematic id, ids above 255 have no built-in meaning and become air,
+// use a SchematicIDMapping for schematics carrying their own id table.
+// The lookup is recorded in the active FidelityReport
+func SchematicIDToRuntimeID(id SchematicID) uint32 {
+	source := func() string { return "schematic " + strconv.Itoa(int(id.Block)) + ":" + strconv.Itoa(int(id.Data)) }
+	if id.Block > 255 {
+		recordBedrockMatch(source, AIR_RUNTIMEID, false, describe.ComparedOutput{})
+		return AIR_RUNTIMEID
+	}
+	rtid := quickSchematicMapping[id.Block][id.Data]
+	if rtid == AIR_RUNTIMEID && id.Block != 0 {
+		// ids without a legacy block fall back to air
+		recordBedrockMatch(source, AIR_RUNTIMEID, false, describe.ComparedOutput{})
+		return rtid
+	}
+	recordLegacyMatch(describe.BlockNameForSearch(schematicBlockStrings[id.Block]), uint16(id.Data), rtid, true)
+	return rtid
+}
+
+// SchematicBlockIDs combines the Blocks and AddBlocks arrays of a schematic into 12 bit ids.
+// AddBlocks packs two nibbles per byte with the first block in the high nibble, older schematica
+// files use a full byte per block ("Add"), both are accepted. addBlocks may be nil
+func SchematicBlockIDs(blockBytes, addBlocks []byte) []uint16 {
+	ids := make([]uint16, len(blockBytes))
+	for i, b := range blockBytes {
+		ids[i] = uint16(b)
+		switch {
+		case len(addBlocks) == len(blockBytes) && len(addBlocks) > 1:
+			ids[i] |= uint16(addBlocks[i]&0xF) << 8
+		case i>>1 < len(addBlocks):
+			add := addBlocks[i>>1]
+			if i&1 == 0 {
+				add >>= 4
+			}
+			ids[i] |= uint16(add&0xF) << 8
+		}
+	}
+	return ids
+}
+
+// SplitSchematicBlockIDs splits 12 bit ids into the Blocks and AddBlocks arrays,
+// addBlocks is nil when every id fits in 8 bits
+func SplitSchematicBlockIDs(ids []uint16) (blockBytes, addBlocks []byte) {
+	blockBytes = make([]byte, len(ids))
+	for i, id := range ids {
+		blockBytes[i] = byte(id)
+		if id > 255 {
+			if addBlocks == nil {
+				addBlocks = make([]byte, (len(ids)+1)>>1)
+			}
+			add := byte(id>>8) & 0xF
+			if i&1 == 0 {
+				add <<= 4
+			}
+			addBlocks[i>>1] |= add
+		}
+	}
+	return blockBytes, addBlocks
+}
+
+// SchematicIDMapping converts ids of a schematic carrying its own id table (MCEdit "BlockIDs",
+// Schematica "SchematicaMapping"), needed for ids above 255 which only mean something with the table.
+// Ids missing from the table use the built-in mapping
+type SchematicIDMapping struct {
+	names map[uint16]string
+	ids   map[string]uint16
+	mu    sync.Mutex
+	cache map[SchematicID]uint32
+}
+
+func NewSchematicIDMapping(names map[uint16]string) *SchematicIDMapping {
+	m := &SchematicIDMapping{names: map[uint16]string{}, ids: map[string]uint16{}, cache: map[SchematicID]uint32{}}
+	for id, name := range names {
+		name = strings.TrimPrefix(name, "minecraft:")
+		m.names[id] = name
+		m.ids[name] = id
+	}
+	return m
+}
+
+// SchematicIDMappingFromNBT reads the id table of a schematic root compound,
+// returns nil if the schematic has none
+func SchematicIDMappingFromNBT(root map[string]any) *SchematicIDMapping {
+	names := map[uint16]string{}
+	if table, ok := root["BlockIDs"].(map[string]any); ok {
+		for key, value := range table {
+			id, err := strconv.ParseUint(key, 10, 16)
+			name, isName := value.(string)
+			if err == nil && isName {
+				names[uint16(id)] = name
+			}
+		}
+	}
+	if table, ok := root["SchematicaMapping"].(map[string]any); ok {
+		for name, value := range table {
+			switch id := value.(type) {
+			case int16:
+				names[uint16(id)] = name
+			case int32:
+				names[uint16(id)] = name
+			}
+		}
+	}
+	if len(names) == 0 {
+		return nil
+	}
+	return NewSchematicIDMapping(names)
+}
+
+// BlockIDsNBT returns the table as an MCEdit "BlockIDs" compound, to be written next to AddBlocks
+func (m *SchematicIDMapping) BlockIDsNBT() map[string]any {
+	table := make(map[string]any, len(m.names))
+	for id, name := range m.names {
+		table[strconv.Itoa(int(id))] = "minecraft:" + name
+	}
+	return table
+}
+
+// RuntimeID converts a schematic id using the table, falling back to the built-in mapping
+func (m *SchematicIDMapping) RuntimeID(id SchematicID) uint32 {
+	name, ok := m.names[id.Block]
+	if !ok {
+		return SchematicIDToRuntimeID(id)
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if rtid, ok := m.cache[id]; ok {
+		return rtid
+	}
+	blockName := describe.BlockNameForSearch(name)
+	rtid, found := DefaultAnyToNemcConvertor.TryBestSearchByLegacyValue(blockName, uint16(id.Data))
+	if !found || rtid == AIR_RUNTIMEID {
+		rtid, found = DefaultAnyToNemcConvertor.TryBestSearchByLegacyValue(blockName, 0)
+	}
+	recordLegacyMatch(blockName, uint16(id.Data), rtid, found)
+	if !found {
+		rtid = AIR_RUNTIMEID
+	}
+	m.cache[id] = rtid
+	return rtid
+}
+
+// SchematicID returns the schematic id of a runtime id, using the id of the table when
+// the block name is in it and the built-in reverse mapping otherwise
+func (m *SchematicIDMapping) SchematicID(runtimeID uint32) (id SchematicID, exact bool, found bool) {
+	id, exact, found = RuntimeIDToSchematicID(runtimeID)
+	if found {
+		if tableID, ok := m.ids[schematicBlockStrings[id.Block]]; ok {
+			id.Block = tableID
+		}
+		return id, exact, true
+	}
+	if block, ok := RuntimeIDToBlock(runtimeID); ok {
+		if tableID, ok := m.ids[block.ShortName()]; ok {
+			return SchematicID{Block: tableID, Data: uint8(block.LegacyValue())}, false, true
+		}
+	}
+	return SchematicID{}, false, false
+}
@@ -0,0 +1,82 @@
+package blocks
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSchematicBlockIDs(t *testing.T) {
+	blockBytes := []byte{1, 2, 3}
+	cases := []struct {
+		name      string
+		addBlocks []byte
+		want      []uint16
+	}{
+		{"no AddBlocks", nil, []uint16{1, 2, 3}},
+		{"nibbles, first block in the high nibble", []byte{0x12, 0x30}, []uint16{0x101, 0x202, 0x303}},
+		{"full byte per block", []byte{1, 0, 0x0F}, []uint16{0x101, 2, 0xF03}},
+	}
+	for _, c := range cases {
+		if got := SchematicBlockIDs(blockBytes, c.addBlocks); !reflect.DeepEqual(got, c.want) {
+			t.Errorf("%s: got %v, want %v", c.name, got, c.want)
+		}
+	}
+}
+
+func TestSplitSchematicBlockIDs(t *testing.T) {
+	blockBytes, addBlocks := SplitSchematicBlockIDs([]uint16{1, 2, 3})
+	if addBlocks != nil || !reflect.DeepEqual(blockBytes, []byte{1, 2, 3}) {
+		t.Errorf("8 bit ids: got %v %v", blockBytes, addBlocks)
+	}
+
+	ids := []uint16{0x101, 2, 0xF03, 4, 0x255}
+	blockBytes, addBlocks = SplitSchematicBlockIDs(ids)
+	if want := []byte{0x10, 0xF0, 0x20}; !reflect.DeepEqual(addBlocks, want) {
+		t.Errorf("AddBlocks: got %x, want %x", addBlocks, want)
+	}
+	if got := SchematicBlockIDs(blockBytes, addBlocks); !reflect.DeepEqual(got, ids) {
+		t.Errorf("round trip: got %v, want %v", got, ids)
+	}
+}
+
+func TestSchematicIDToRuntimeID(t *testing.T) {
+	block, ok := RuntimeIDToBlock(SchematicIDToRuntimeID(SchematicID{Block: 35, Data: 14}))
+	if !ok || block.ShortName() != "red_wool" {
+		t.Errorf("35:14: got %v", block)
+	}
+	if rtid := SchematicIDToRuntimeID(SchematicID{Block: 300}); rtid != AIR_RUNTIMEID {
+		t.Errorf("ids above 255 without a table should be air, got %v", rtid)
+	}
+}
+
+func TestRuntimeIDToSchematicIDPrefersDataZero(t *testing.T) {
+	for _, block := range []uint16{1, 5, 17, 35} {
+		rtid := SchematicIDToRuntimeID(SchematicID{Block: block})
+		id, exact, found := RuntimeIDToSchematicID(rtid)
+		if !found || !exact || id.Data != 0 {
+			t.Errorf("block %d: got %v exact=%v found=%v", block, id, exact, found)
+		}
+	}
+}
+
+func TestSchematicIDMapping(t *testing.T) {
+	m := SchematicIDMappingFromNBT(map[string]any{
+		"BlockIDs": map[string]any{"300": "minecraft:stone", "301": "minecraft:wool"},
+	})
+	if m == nil {
+		t.Fatal("no mapping read from BlockIDs")
+	}
+	stone, _ := BlockStrToRuntimeID("minecraft:stone")
+	if rtid := m.RuntimeID(SchematicID{Block: 300}); rtid != stone {
+		t.Errorf("300:0: got %v, want stone %v", rtid, stone)
+	}
+	if block, _ := RuntimeIDToBlock(m.RuntimeID(SchematicID{Block: 301, Data: 14})); block == nil || block.ShortName() != "red_wool" {
+		t.Errorf("301:14: got %v, want red_wool", block)
+	}
+	if id, _, found := m.SchematicID(stone); !found || id.Block != 300 {
+		t.Errorf("stone: got %v found=%v, want the table id 300", id, found)
+	}
+	if SchematicIDMappingFromNBT(map[string]any{}) != nil {
+		t.Error("schematics without a table should have no mapping")
+	}
+}
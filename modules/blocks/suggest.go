@@ -0,0 +1,215 @@
+package blocks
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/Yeah114/blocks/describe"
+)
+
+const maxSuggestions = 5
+
+// UnknownBlockError is returned when no block matches the name, Suggestions are the closest known names
+type UnknownBlockError struct {
+	Name        string
+	Suggestions []string
+}
+
+func (e *UnknownBlockError) Error() string {
+	if len(e.Suggestions) == 0 {
+		return fmt.Sprintf("unknown block %v", e.Name)
+	}
+	return fmt.Sprintf("unknown block %v, did you mean: %v", e.Name, strings.Join(e.Suggestions, ", "))
+}
+
+// InvalidStateError is returned when the states can not be parsed, or contain a key or value the block does not have.
+// ValidStates lists every state key of the block and its possible values
+type InvalidStateError struct {
+	Name        string
+	State       string
+	Key         string // the offending key, empty if the states can not be parsed
+	Value       string // the offending value, empty if the key itself is invalid
+	Err         error  // parse error, if any
+	ValidStates map[string][]string
+}
+
+func (e *InvalidStateError) Error() string {
+	var msg string
+	switch {
+	case e.Err != nil:
+		msg = fmt.Sprintf("invalid states %v of %v: %v", e.State, e.Name, e.Err)
+	case e.Value != "":
+		msg = fmt.Sprintf("invalid value %v of state %v of %v", e.Value, e.Key, e.Name)
+	default:
+		msg = fmt.Sprintf("%v has no state %v", e.Name, e.Key)
+	}
+	if len(e.ValidStates) == 0 {
+		return msg + " (the block has no states)"
+	}
+	return msg + ", valid states: " + FormatValidStates(e.ValidStates)
+}
+
+// FormatValidStates formats the result of ValidStates as `key=[v1 v2] key2=[...]`, sorted by key
+func FormatValidStates(states map[string][]string) string {
+	keys := make([]string, 0, len(states))
+	for k := range states {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	frags := make([]string, 0, len(keys))
+	for _, k := range keys {
+		frags = append(frags, k+"=["+strings.Join(states[k], " ")+"]")
+	}
+	return strings.Join(frags, " ")
+}
+
+func trimNamespace(name string) string {
+	return strings.TrimPrefix(strings.ToLower(strings.TrimSpace(name)), "minecraft:")
+}
+
+// SuggestBlockNames returns up to n known block names closest to name by edit distance,
+// names containing the input (or covering at least half of it) are preferred
+func SuggestBlockNames(name string, n int) []string {
+	return suggestNames(CurrentSchema().Names(), name, n)
+}
+
+func suggestNames(names []string, name string, n int) []string {
+	name = trimNamespace(name)
+	if name == "" {
+		return nil
+	}
+	type candidate struct {
+		name     string
+		distance int
+	}
+	limit := len(name)/3 + 1
+	var candidates []candidate
+	for _, known := range names {
+		d := editDistance(name, known)
+		if len(name) >= 3 && (strings.Contains(known, name) || len(known)*2 >= len(name) && strings.Contains(name, known)) {
+			d = min(d, 1)
+		}
+		if d <= limit {
+			candidates = append(candidates, candidate{known, d})
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if candidates[i].distance != candidates[j].distance {
+			return candidates[i].distance < candidates[j].distance
+		}
+		return len(candidates[i].name) < len(candidates[j].name)
+	})
+	suggestions := make([]string, 0, n)
+	for i := 0; i < len(candidates) && i < n; i++ {
+		suggestions = append(suggestions, candidates[i].name)
+	}
+	return suggestions
+}
+
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(rb)]
+}
+
+// ValidStates returns every state key of the block and its possible values (in bedrock string form),
+// nil if the block is unknown or has no states
+func ValidStates(name string) map[string][]string {
+	bsc, ok := CurrentSchema().Block(name)
+	if !ok || len(bsc.States) == 0 {
+		return nil
+	}
+	return bsc.ValidStates()
+}
+
+// javaOnlyStateKeys are java states bedrock keeps outside the block states (e.g. in a second block layer),
+// they are accepted on any block
+var javaOnlyStateKeys = map[string]bool{"waterlogged": true}
+
+// javaStates returns the possible values of every state key of the java equivalents
+// of the block which have the same name
+func javaStates(bsc *BlockSchema) map[string][]describe.PropValForSearch {
+	states := map[string][]describe.PropValForSearch{}
+	for _, b := range bsc.Variants() {
+		javaBlock, _, found := BedrockToJavaConvertor.TryBestSearchByState(b.NameForSearch(), b.StatesForSearch())
+		if !found || javaBlock == nil || trimNamespace(javaBlock.Name()) != bsc.Name {
+			continue
+		}
+		props, err := describe.PropsForSearchFromNbt(javaBlock.ToNBT())
+		if err != nil || props == nil {
+			continue
+		}
+		for _, p := range *props {
+			key := strings.TrimPrefix(p.Name, "minecraft:")
+			states[key] = append(states[key], p.Value)
+		}
+	}
+	return states
+}
+
+// checkStates validates props against the schema of the block if all keys are bedrock state keys,
+// otherwise (java state keys) only checks every key and value exists on the block or its java equivalent
+func checkStates(name string, props *describe.PropsForSearch) *InvalidStateError {
+	bsc, ok := CurrentSchema().Block(name)
+	if !ok || props == nil {
+		return nil
+	}
+	bedrockKeys := true
+	for _, p := range *props {
+		if _, ok := bsc.State(p.Name); !ok {
+			bedrockKeys = false
+			break
+		}
+	}
+	if bedrockKeys {
+		if err := bsc.Validate(props, false); err != nil {
+			return err.(*InvalidStateError)
+		}
+		return nil
+	}
+
+	java := javaStates(bsc)
+	for _, p := range *props {
+		key := strings.TrimPrefix(p.Name, "minecraft:")
+		if javaOnlyStateKeys[key] {
+			continue
+		}
+		if st, ok := bsc.State(key); ok {
+			if !st.Allows(p.Value) {
+				return &InvalidStateError{Name: bsc.Name, Key: key, Value: p.Value.InPreciseSNBT(), ValidStates: bsc.ValidStates()}
+			}
+			continue
+		}
+		values, hasKey := java[key]
+		if !hasKey {
+			return &InvalidStateError{Name: bsc.Name, Key: key, ValidStates: bsc.ValidStates()}
+		}
+		hasValue := false
+		for _, v := range values {
+			if v.FuzzyEqual(p.Value) {
+				hasValue = true
+				break
+			}
+		}
+		if !hasValue {
+			return &InvalidStateError{Name: bsc.Name, Key: key, Value: p.Value.InPreciseSNBT(), ValidStates: bsc.ValidStates()}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+package blocks
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSuggestBlockNames(t *testing.T) {
+	if got := SuggestBlockNames("stonee", 5); len(got) == 0 || got[0] != "stone" {
+		t.Errorf("stonee: %v, want stone first", got)
+	}
+	if got := SuggestBlockNames(" MINECRAFT:STONEE ", 5); len(got) == 0 || got[0] != "stone" {
+		t.Errorf("namespaced upper case: %v, want stone first", got)
+	}
+	// names containing the input rank like a single edit, shorter names first
+	got := SuggestBlockNames("oak_stair", 5)
+	if len(got) < 2 || got[0] != "oak_stairs" {
+		t.Fatalf("oak_stair: %v, want oak_stairs first", got)
+	}
+	found := false
+	for _, name := range got {
+		found = found || name == "dark_oak_stairs"
+	}
+	if !found {
+		t.Errorf("oak_stair: %v, want dark_oak_stairs among the suggestions", got)
+	}
+	// short names inside the input (air in oak_stair) do not count as containing it
+	for _, name := range got {
+		if name == "air" {
+			t.Errorf("oak_stair: %v, air should not be suggested", got)
+		}
+	}
+	if got := SuggestBlockNames("oak_stairs_block", 5); len(got) == 0 || got[0] != "oak_stairs" {
+		t.Errorf("oak_stairs_block: %v, want oak_stairs first", got)
+	}
+	if got := SuggestBlockNames("oak_stair", 1); len(got) != 1 {
+		t.Errorf("n = 1: %v", got)
+	}
+	if got := SuggestBlockNames("zzzzzzzzzz", 5); len(got) != 0 {
+		t.Errorf("nothing close: %v", got)
+	}
+	if got := SuggestBlockNames("", 5); got != nil {
+		t.Errorf("empty name: %v", got)
+	}
+}
+
+func TestEditDistance(t *testing.T) {
+	cases := []struct {
+		a, b string
+		want int
+	}{
+		{"stone", "stone", 0},
+		{"kitten", "sitting", 3},
+		{"", "abc", 3},
+		{"abc", "", 3},
+		{"方块", "方", 1},
+	}
+	for _, c := range cases {
+		if got := editDistance(c.a, c.b); got != c.want {
+			t.Errorf("editDistance(%q, %q) = %v, want %v", c.a, c.b, got, c.want)
+		}
+	}
+}
+
+func TestValidStates(t *testing.T) {
+	want := map[string][]string{"pillar_axis": {`"x"`, `"y"`, `"z"`}}
+	if got := ValidStates("minecraft:oak_log"); !reflect.DeepEqual(got, want) {
+		t.Errorf("oak_log: %v, want %v", got, want)
+	}
+	if got := ValidStates("suggest_test_unknown"); got != nil {
+		t.Errorf("unknown block: %v", got)
+	}
+	if got := FormatValidStates(map[string][]string{"b": {"1"}, "a": {"x", "y"}}); got != "a=[x y] b=[1]" {
+		t.Errorf("FormatValidStates: %q", got)
+	}
+}
+
+func TestUnknownBlockErrorMessage(t *testing.T) {
+	err := &UnknownBlockError{Name: "stonee", Suggestions: []string{"stone", "stonecutter"}}
+	if msg := err.Error(); !strings.Contains(msg, "did you mean: stone, stonecutter") {
+		t.Errorf("message: %q", msg)
+	}
+	if msg := (&UnknownBlockError{Name: "x"}).Error(); strings.Contains(msg, "did you mean") {
+		t.Errorf("message without suggestions: %q", msg)
+	}
+}
@@ -0,0 +1,552 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	bwo_define "github.com/TriM-Organization/bedrock-world-operator/define"
+	"github.com/TriM-Organization/bedrock-world-operator/world"
+	world_define "github.com/TriM-Organization/bedrock-world-operator/world/define"
+	wsnetease "github.com/Yeah114/WaterStructure/utils/netease_world"
+)
+
+// neteaseKeyEnv 未指定密钥时读取的默认环境变量
+const neteaseKeyEnv = "FATALDER_NETEASE_KEY"
+
+// neteaseEncryptedMagic 网易加密后的 LevelDB 文件头
+var neteaseEncryptedMagic = []byte{0x80, 0x1d, 0x30, 0x01}
+
+// leveldbTableMagic LevelDB 表文件（.ldb）末尾的魔数
+const leveldbTableMagic = uint64(0xdb4775248b80fb57)
+
+// dbCryptState 数据库文件的加密状态
+type dbCryptState int
+
+const (
+	cryptStateUnknown dbCryptState = iota
+	cryptStatePlain
+	cryptStateEncrypted
+	cryptStateMixed
+)
+
+func (s dbCryptState) String() string {
+	switch s {
+	case cryptStatePlain:
+		return "未加密"
+	case cryptStateEncrypted:
+		return "已加密"
+	case cryptStateMixed:
+		return "混合（部分加密）"
+	default:
+		return "未知"
+	}
+}
+
+// dbFileCrypt 单个数据库文件的检测结果
+type dbFileCrypt struct {
+	Name  string
+	Size  int64
+	State dbCryptState
+}
+
+// isDBCryptFile 判断文件是否属于会被加密的数据库文件
+func isDBCryptFile(name string) bool {
+	ext := strings.ToLower(filepath.Ext(name))
+	return name == "CURRENT" || strings.HasPrefix(name, "MANIFEST-") || ext == ".ldb" || ext == ".log"
+}
+
+// detectFileCrypt 通过文件头判断单个数据库文件是否加密
+func detectFileCrypt(name string, data []byte, size int64) dbCryptState {
+	if size == 0 {
+		return cryptStateUnknown
+	}
+	if bytes.HasPrefix(data, neteaseEncryptedMagic) {
+		return cryptStateEncrypted
+	}
+	switch {
+	case name == "CURRENT":
+		if bytes.HasPrefix(data, []byte("MANIFEST-")) {
+			return cryptStatePlain
+		}
+		return cryptStateUnknown
+	case strings.HasSuffix(strings.ToLower(name), ".ldb"):
+		if len(data) >= 8 && binary.LittleEndian.Uint64(data[len(data)-8:]) == leveldbTableMagic {
+			return cryptStatePlain
+		}
+		return cryptStateUnknown
+	default:
+		// MANIFEST 和 .log 没有固定文件头，不是加密文件头即视为未加密
+		return cryptStatePlain
+	}
+}
+
+// readDBFileSample 读取文件头部和尾部用于检测
+func readDBFileSample(r io.ReaderAt, size int64) ([]byte, error) {
+	const headSize = 16
+	if size <= headSize+8 {
+		data := make([]byte, size)
+		if _, err := r.ReadAt(data, 0); err != nil && err != io.EOF {
+			return nil, err
+		}
+		return data, nil
+	}
+	data := make([]byte, headSize+8)
+	if _, err := r.ReadAt(data[:headSize], 0); err != nil && err != io.EOF {
+		return nil, err
+	}
+	if _, err := r.ReadAt(data[headSize:], size-8); err != nil && err != io.EOF {
+		return nil, err
+	}
+	return data, nil
+}
+
+// detectDBCrypt 检测 db 目录中所有数据库文件的加密状态
+func detectDBCrypt(dbDir string) (dbCryptState, []dbFileCrypt, error) {
+	entries, err := os.ReadDir(dbDir)
+	if err != nil {
+		return cryptStateUnknown, nil, err
+	}
+
+	var files []dbFileCrypt
+	for _, entry := range entries {
+		if entry.IsDir() || !isDBCryptFile(entry.Name()) {
+			continue
+		}
+		f, err := os.Open(filepath.Join(dbDir, entry.Name()))
+		if err != nil {
+			return cryptStateUnknown, nil, err
+		}
+		info, err := f.Stat()
+		if err != nil {
+			f.Close()
+			return cryptStateUnknown, nil, err
+		}
+		data, err := readDBFileSample(f, info.Size())
+		f.Close()
+		if err != nil {
+			return cryptStateUnknown, nil, fmt.Errorf("读取 %s 失败: %w", entry.Name(), err)
+		}
+		files = append(files, dbFileCrypt{
+			Name:  entry.Name(),
+			Size:  info.Size(),
+			State: detectFileCrypt(entry.Name(), data, info.Size()),
+		})
+	}
+	return summarizeDBCrypt(files), files, nil
+}
+
+// summarizeDBCrypt 汇总各文件的加密状态
+func summarizeDBCrypt(files []dbFileCrypt) dbCryptState {
+	plain, encrypted := 0, 0
+	for _, f := range files {
+		switch f.State {
+		case cryptStatePlain:
+			plain++
+		case cryptStateEncrypted:
+			encrypted++
+		}
+	}
+	switch {
+	case plain > 0 && encrypted > 0:
+		return cryptStateMixed
+	case encrypted > 0:
+		return cryptStateEncrypted
+	case plain > 0:
+		return cryptStatePlain
+	default:
+		return cryptStateUnknown
+	}
+}
+
+// parseNeteaseKey 解析十六进制密钥（允许空格、冒号和 0x 前缀）
+func parseNeteaseKey(s string) ([]byte, error) {
+	s = strings.TrimSpace(s)
+	s = strings.TrimPrefix(strings.TrimPrefix(s, "0x"), "0X")
+	s = strings.NewReplacer(" ", "", ":", "", "-", "", "\n", "", "\r", "").Replace(s)
+	if s == "" {
+		return nil, fmt.Errorf("密钥为空")
+	}
+	key, err := hex.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("无效的十六进制密钥: %w", err)
+	}
+	return key, nil
+}
+
+// readNeteaseKeyFile 从文件读取密钥，文件内容为十六进制文本时按十六进制解析，否则按原始字节使用
+func readNeteaseKeyFile(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("无法读取密钥文件: %w", err)
+	}
+	if key, err := parseNeteaseKey(string(data)); err == nil {
+		return key, nil
+	}
+	if len(data) == 0 {
+		return nil, fmt.Errorf("密钥文件为空: %s", path)
+	}
+	return data, nil
+}
+
+// parseNeteaseKeyArgs 从命令行参数中提取密钥选项，返回密钥和剩余参数
+// 支持 --key <十六进制>、--key-file <文件>、--key-env <环境变量名>
+// 均未指定时读取环境变量 FATALDER_NETEASE_KEY，仍为空则返回 nil（使用默认密钥）
+func parseNeteaseKeyArgs(args []string) (key []byte, rest []string, err error) {
+	specified := false
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case "--key", "--key-file", "--key-env":
+			if i+1 >= len(args) {
+				return nil, nil, fmt.Errorf("选项 %s 缺少参数", args[i])
+			}
+			if specified {
+				return nil, nil, fmt.Errorf("只能指定一个密钥来源")
+			}
+			specified = true
+			value := args[i+1]
+			switch args[i] {
+			case "--key":
+				key, err = parseNeteaseKey(value)
+			case "--key-file":
+				key, err = readNeteaseKeyFile(value)
+			case "--key-env":
+				env, ok := os.LookupEnv(value)
+				if !ok {
+					return nil, nil, fmt.Errorf("环境变量 %s 未设置", value)
+				}
+				key, err = parseNeteaseKey(env)
+			}
+			if err != nil {
+				return nil, nil, err
+			}
+			i++
+		default:
+			rest = append(rest, args[i])
+		}
+	}
+	if !specified {
+		if env := os.Getenv(neteaseKeyEnv); strings.TrimSpace(env) != "" {
+			if key, err = parseNeteaseKey(env); err != nil {
+				return nil, nil, fmt.Errorf("环境变量 %s: %w", neteaseKeyEnv, err)
+			}
+		}
+	}
+	return key, rest, nil
+}
+
+// resolveDBDir 根据世界目录或 db 目录路径得到 db 目录
+func resolveDBDir(worldPath string) string {
+	if filepath.Base(worldPath) == "db" {
+		return worldPath
+	}
+	return filepath.Join(worldPath, "db")
+}
+
+// checkCryptState 在加密/解密前检查数据库状态，拒绝重复加密和解密未加密的数据库
+func checkCryptState(dbDir string, encrypt bool) error {
+	state, _, err := detectDBCrypt(dbDir)
+	if err != nil {
+		return fmt.Errorf("检测加密状态失败: %w", err)
+	}
+	fmt.Printf("当前数据库状态: %s\n", state)
+	switch state {
+	case cryptStateEncrypted:
+		if encrypt {
+			return fmt.Errorf("数据库已经加密，拒绝重复加密")
+		}
+	case cryptStatePlain:
+		if !encrypt {
+			return fmt.Errorf("数据库未加密，无需解密")
+		}
+	case cryptStateMixed:
+		return fmt.Errorf("数据库部分文件已加密、部分未加密，可能已损坏，请使用 crypt-status 查看详情")
+	}
+	return nil
+}
+
+// neteaseCrypt 网易版世界加密/解密
+// key 为 nil 时使用默认密钥
+func neteaseCrypt(worldPath, outputPath string, encrypt bool, key []byte) error {
+	info, err := os.Stat(worldPath)
+	if err != nil {
+		return fmt.Errorf("无法访问路径: %w", err)
+	}
+
+	action := "解密"
+	if encrypt {
+		action = "加密"
+	}
+
+	if info.IsDir() {
+		// 是世界目录：在副本上处理，验证后替换，原数据库保留为备份
+		dbDir := resolveDBDir(worldPath)
+		if _, err := os.Stat(filepath.Join(dbDir, "CURRENT")); err != nil {
+			return fmt.Errorf("无效的 db 目录: %w", err)
+		}
+		if err := checkCryptState(dbDir, encrypt); err != nil {
+			return err
+		}
+		printNeteaseKeyInfo(key)
+
+		backupDir, err := cryptDBDirSafely(dbDir, encrypt, key)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("原数据库已备份到: %s\n", backupDir)
+		fmt.Printf("%s完成: %s\n", action, dbDir)
+		return nil
+	}
+
+	// 是 .mcworld 文件：只解压 db 和 level.dat 处理后重新打包，原文件不会被修改
+	worldDir, cleanup, err := unarchiveMCWorldData(worldPath)
+	if err != nil {
+		return fmt.Errorf("无法解压世界文件: %w", err)
+	}
+	defer cleanup()
+
+	dbDir := filepath.Join(worldDir, "db")
+	if _, err := os.Stat(dbDir); err != nil {
+		return fmt.Errorf("缺少 db 目录: %w", err)
+	}
+	if err := checkCryptState(dbDir, encrypt); err != nil {
+		return err
+	}
+	printNeteaseKeyInfo(key)
+
+	fmt.Printf("正在%s数据库: %s\n", action, dbDir)
+	if err := cryptDB(dbDir, encrypt, key); err != nil {
+		return fmt.Errorf("%s失败: %w", action, err)
+	}
+
+	if outputPath == "" {
+		outputPath = strings.TrimSuffix(worldPath, filepath.Ext(worldPath))
+		if encrypt {
+			outputPath += ".encrypted.mcworld"
+		} else {
+			outputPath += ".decrypted.mcworld"
+		}
+	}
+	if !strings.HasSuffix(strings.ToLower(outputPath), ".mcworld") {
+		outputPath += ".mcworld"
+	}
+	fmt.Printf("正在打包为: %s\n", outputPath)
+	if err := repackMCWorld(worldPath, worldDir, outputPath); err != nil {
+		return fmt.Errorf("打包失败: %w", err)
+	}
+	fmt.Printf("%s完成: %s\n", action, outputPath)
+	return nil
+}
+
+// printNeteaseKeyInfo 提示是否使用了自定义密钥
+func printNeteaseKeyInfo(key []byte) {
+	if key != nil {
+		fmt.Printf("使用自定义密钥（%d 字节）\n", len(key))
+	}
+}
+
+// cryptDB 直接加密/解密 db 目录
+func cryptDB(dbDir string, encrypt bool, key []byte) error {
+	if encrypt {
+		return wsnetease.Encrypt(dbDir, key)
+	}
+	return wsnetease.Decrypt(dbDir, key)
+}
+
+// cryptDBDirSafely 在 db 的副本上加密/解密，验证通过后替换原 db，并返回带时间戳的备份目录
+// 任何一步失败时原 db 保持不变
+func cryptDBDirSafely(dbDir string, encrypt bool, key []byte) (string, error) {
+	action := "解密"
+	if encrypt {
+		action = "加密"
+	}
+
+	parent := filepath.Dir(dbDir)
+	stamp := time.Now().Format("20060102-150405")
+	workDir := filepath.Join(parent, "db.fatalder-"+stamp+".tmp")
+	backupDir := filepath.Join(parent, "db.backup-"+stamp)
+
+	// 副本与原 db 位于同一目录，保证后续重命名不跨文件系统
+	fmt.Printf("正在复制数据库: %s\n", workDir)
+	if err := copyDir(dbDir, workDir); err != nil {
+		_ = os.RemoveAll(workDir)
+		return "", fmt.Errorf("复制数据库失败: %w", err)
+	}
+	swapped := false
+	defer func() {
+		if !swapped {
+			_ = os.RemoveAll(workDir)
+		}
+	}()
+	// 副本中不需要原数据库的锁文件
+	_ = os.Remove(filepath.Join(workDir, "LOCK"))
+
+	fmt.Printf("正在%s数据库副本\n", action)
+	if err := cryptDB(workDir, encrypt, key); err != nil {
+		return "", fmt.Errorf("%s失败，原存档未修改: %w", action, err)
+	}
+
+	fmt.Println("正在验证结果...")
+	if err := verifyCryptedDB(parent, workDir, encrypt, key); err != nil {
+		return "", fmt.Errorf("验证失败，原存档未修改: %w", err)
+	}
+
+	// 先将原 db 改名为备份，再把副本改名为 db；第二步失败时恢复原 db
+	if err := os.Rename(dbDir, backupDir); err != nil {
+		return "", fmt.Errorf("备份原数据库失败，原存档未修改: %w", err)
+	}
+	if err := os.Rename(workDir, dbDir); err != nil {
+		if restoreErr := os.Rename(backupDir, dbDir); restoreErr != nil {
+			return "", fmt.Errorf("替换数据库失败: %v；恢复原数据库也失败: %v，原数据库位于 %s", err, restoreErr, backupDir)
+		}
+		return "", fmt.Errorf("替换数据库失败，已恢复原数据库: %w", err)
+	}
+	swapped = true
+	return backupDir, nil
+}
+
+// verifySampleChunks 验证时最多读取的区块数
+const verifySampleChunks = 25
+
+// verifyCryptedDB 验证加密/解密后的 db：检查文件状态，再在临时副本上遍历数据库中实际存在的区块键，
+// 抽样用 world.Open 读取；一个区块都读不到时视为失败
+// 加密结果无法直接打开，会先用同一密钥解密临时副本
+func verifyCryptedDB(worldDir, dbDir string, encrypt bool, key []byte) error {
+	want := cryptStatePlain
+	if encrypt {
+		want = cryptStateEncrypted
+	}
+	state, _, err := detectDBCrypt(dbDir)
+	if err != nil {
+		return fmt.Errorf("检测加密状态失败: %w", err)
+	}
+	if state != want {
+		return fmt.Errorf("处理后的数据库状态为%s，预期为%s", state, want)
+	}
+
+	scratchDir, err := os.MkdirTemp("", "fatalder-verify-*")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(scratchDir)
+
+	scratchDB := filepath.Join(scratchDir, "db")
+	if err := copyDir(dbDir, scratchDB); err != nil {
+		return fmt.Errorf("复制数据库失败: %w", err)
+	}
+	levelDat := filepath.Join(worldDir, "level.dat")
+	if _, err := os.Stat(levelDat); err == nil {
+		if err := copyFile(levelDat, filepath.Join(scratchDir, "level.dat")); err != nil {
+			return fmt.Errorf("复制 level.dat 失败: %w", err)
+		}
+	}
+	if encrypt {
+		if err := wsnetease.Decrypt(scratchDB, key); err != nil {
+			return fmt.Errorf("无法用相同密钥解密加密结果: %w", err)
+		}
+	}
+
+	samples, total, err := sampleWorldChunks(scratchDir, verifySampleChunks)
+	if err != nil {
+		return err
+	}
+	if total == 0 {
+		// 没有区块的空世界改为确认其余记录（如 ~local_player）都能读取
+		records, err := countWorldRecords(scratchDir)
+		if err != nil {
+			return fmt.Errorf("读取数据库记录失败: %w", err)
+		}
+		fmt.Printf("验证通过: 数据库中没有区块，%d 条其他记录全部可读\n", records)
+		return nil
+	}
+
+	bedrockWorld, err := world.Open(scratchDir, nil)
+	if err != nil {
+		return fmt.Errorf("无法打开世界: %w", err)
+	}
+	defer bedrockWorld.Close()
+
+	for _, sample := range samples {
+		_, exists, err := bedrockWorld.LoadChunk(sample.Dimension, sample.Pos)
+		if err != nil {
+			return fmt.Errorf("读取%s区块 %v 失败: %w", dimensionName(sample.Dimension), sample.Pos, err)
+		}
+		if !exists {
+			return fmt.Errorf("%s区块 %v 存在于数据库中但无法读取", dimensionName(sample.Dimension), sample.Pos)
+		}
+	}
+	fmt.Printf("验证通过: 数据库共 %d 个区块，抽样读取 %d 个全部成功\n", total, len(samples))
+	return nil
+}
+
+// sampledChunk 抽样验证的区块
+type sampledChunk struct {
+	Dimension bwo_define.Dimension
+	Pos       bwo_define.ChunkPos
+}
+
+// sampleWorldChunks 只读遍历世界 db 中所有维度的区块版本键，返回均匀抽取的至多 limit 个区块和区块总数
+func sampleWorldChunks(worldDir string, limit int) ([]sampledChunk, int, error) {
+	db, err := openWorldDB(worldDir)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer db.Close()
+
+	var chunks []sampledChunk
+	err = db.forEach(nil, func(key, value []byte) error {
+		k, ok := parseChunkKey(key)
+		if ok && (k.Tag == world_define.KeyVersion || k.Tag == world_define.KeyVersionOld) {
+			chunks = append(chunks, sampledChunk{Dimension: k.Dimension, Pos: k.Pos})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, 0, fmt.Errorf("遍历数据库失败: %w", err)
+	}
+	if len(chunks) <= limit {
+		return chunks, len(chunks), nil
+	}
+	samples := make([]sampledChunk, 0, limit)
+	for i := 0; i < limit; i++ {
+		samples = append(samples, chunks[i*len(chunks)/limit])
+	}
+	return samples, len(chunks), nil
+}
+
+// promptNeteaseKey 交互模式下读取密钥，留空时使用环境变量或默认密钥
+func promptNeteaseKey(reader *bufio.Reader) ([]byte, error) {
+	fmt.Printf("请输入密钥（十六进制，留空使用 %s 或默认密钥）: ", neteaseKeyEnv)
+	input, err := reader.ReadString('\n')
+	if err != nil {
+		return nil, fmt.Errorf("读取输入失败: %w", err)
+	}
+	input = strings.TrimSpace(input)
+	if input == "" {
+		key, _, err := parseNeteaseKeyArgs(nil)
+		return key, err
+	}
+	return parseNeteaseKey(input)
+}
+
+// countWorldRecords 遍历世界 db 中的全部记录并返回数量，数据库无法读取时返回错误
+func countWorldRecords(worldDir string) (int, error) {
+	db, err := openWorldDB(worldDir)
+	if err != nil {
+		return 0, err
+	}
+	defer db.Close()
+	records := 0
+	err = db.forEach(nil, func(key, value []byte) error {
+		records++
+		return nil
+	})
+	return records, err
+}
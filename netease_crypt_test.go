@@ -0,0 +1,58 @@
+package main
+
+import (
+	"encoding/binary"
+	"path/filepath"
+	"testing"
+
+	"github.com/df-mc/goleveldb/leveldb"
+)
+
+func TestDetectFileCrypt(t *testing.T) {
+	ldbTail := binary.LittleEndian.AppendUint64([]byte("table data"), leveldbTableMagic)
+	encrypted := append(append([]byte{}, neteaseEncryptedMagic...), "payload"...)
+	cases := []struct {
+		name string
+		data []byte
+		size int64
+		want dbCryptState
+	}{
+		{"000005.ldb", ldbTail, int64(len(ldbTail)), cryptStatePlain},
+		{"000005.LDB", ldbTail, int64(len(ldbTail)), cryptStatePlain},
+		{"000005.ldb", encrypted, int64(len(encrypted)), cryptStateEncrypted},
+		{"000005.ldb", []byte("no table magic"), 14, cryptStateUnknown},
+		{"CURRENT", []byte("MANIFEST-000002\n"), 16, cryptStatePlain},
+		{"CURRENT", encrypted, int64(len(encrypted)), cryptStateEncrypted},
+		{"CURRENT", []byte("garbage"), 7, cryptStateUnknown},
+		{"MANIFEST-000002", []byte{0x01, 0x02}, 2, cryptStatePlain},
+		{"000003.log", encrypted, int64(len(encrypted)), cryptStateEncrypted},
+		{"000003.log", nil, 0, cryptStateUnknown},
+	}
+	for _, c := range cases {
+		if got := detectFileCrypt(c.name, c.data, c.size); got != c.want {
+			t.Errorf("%s %q: 得到 %v，应为 %v", c.name, c.data, got, c.want)
+		}
+	}
+}
+
+func TestVerifyCryptedDBEmptyWorld(t *testing.T) {
+	worldDir := t.TempDir()
+	dbDir := filepath.Join(worldDir, "db")
+	db, err := leveldb.OpenFile(dbDir, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Put([]byte("~local_player"), []byte("player"), nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if records, err := countWorldRecords(worldDir); err != nil || records != 1 {
+		t.Errorf("countWorldRecords 得到 %d %v，应为 1", records, err)
+	}
+	if err := verifyCryptedDB(worldDir, dbDir, false, nil); err != nil {
+		t.Errorf("没有区块的空世界验证失败: %v", err)
+	}
+}
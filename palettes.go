@@ -0,0 +1,401 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	world_define "github.com/TriM-Organization/bedrock-world-operator/world/define"
+	"github.com/df-mc/goleveldb/leveldb"
+	"github.com/sandertv/gophertunnel/minecraft/nbt"
+
+	wsstructure "github.com/Yeah114/WaterStructure/structure"
+	"github.com/Yeah114/blocks"
+	"github.com/Yeah114/blocks/describe"
+)
+
+// paletteDirEnv 存放额外方块调色板文件的目录（*.txt 或 *.br，格式与 palette dump 的输出相同）
+const paletteDirEnv = "FATALDER_PALETTE_DIR"
+
+// loadUserPalettes 加载指定目录（为空时使用环境变量）中的方块调色板
+func loadUserPalettes(dir string) error {
+	if dir == "" {
+		dir = os.Getenv(paletteDirEnv)
+	}
+	if dir == "" {
+		return nil
+	}
+	loaded, err := blocks.LoadPaletteDir(dir)
+	if err != nil {
+		return fmt.Errorf("加载方块调色板失败: %w", err)
+	}
+	for _, p := range loaded {
+		fmt.Printf("已加载方块调色板: %s (版本 %d，%d 种方块)\n", p.Name(), p.Version(), len(p.BlockSet().Blocks()))
+	}
+	return nil
+}
+
+// findPalette 按名称查找已加载的方块调色板
+func findPalette(name string) (*blocks.Palette, error) {
+	p, ok := blocks.GetPalette(name)
+	if !ok {
+		return nil, fmt.Errorf("未找到方块调色板: %s（使用 'palette list' 查看已加载的调色板）", name)
+	}
+	return p, nil
+}
+
+// listPalettes 输出所有已加载的方块调色板
+func listPalettes() {
+	fmt.Println("已加载的方块调色板:")
+	for _, p := range blocks.Palettes() {
+		note := ""
+		switch p.Name() {
+		case blocks.NEMC_PALETTE_NAME:
+			note = "（内置，网易版）"
+		case blocks.BEDROCK_PALETTE_NAME:
+			note = "（内置，国际版）"
+		}
+		fmt.Printf("  %-20s 版本 %-10d %6d 种方块 %s\n", p.Name(), p.Version(), len(p.BlockSet().Blocks()), note)
+	}
+}
+
+// translatePaletteBlock 将方块（名称和状态，或运行时 ID）从一个调色板转换到另一个调色板并输出结果
+func translatePaletteBlock(fromName, toName, block string) error {
+	from, err := findPalette(fromName)
+	if err != nil {
+		return err
+	}
+	to, err := findPalette(toName)
+	if err != nil {
+		return err
+	}
+
+	var runtimeID uint32
+	if id, err := strconv.ParseUint(block, 10, 32); err == nil {
+		runtimeID = uint32(id)
+	} else {
+		id, found := from.BlockStrToRuntimeID(block)
+		if !found {
+			return fmt.Errorf("调色板 %s 中没有方块: %s", from.Name(), block)
+		}
+		runtimeID = id
+	}
+	src, found := from.RuntimeIDToBlock(runtimeID)
+	if !found {
+		return fmt.Errorf("调色板 %s 中没有运行时 ID %d", from.Name(), runtimeID)
+	}
+
+	translated, found := blocks.NewRuntimeIDTranslator(from, to).Translate(runtimeID)
+	fmt.Printf("%s: %d  %s\n", from.Name(), runtimeID, src.BedrockString())
+	if !found {
+		return fmt.Errorf("调色板 %s 中没有对应的方块", to.Name())
+	}
+	dst, _ := to.RuntimeIDToBlock(translated)
+	fmt.Printf("%s: %d  %s\n", to.Name(), translated, dst.BedrockString())
+	return nil
+}
+
+// dumpPalette 将调色板导出为文本记录，可修改后放入调色板目录作为其他版本加载
+func dumpPalette(name, outputPath string) error {
+	p, err := findPalette(name)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(outputPath, []byte(p.BlockSet().DumpStringRecords()), 0644); err != nil {
+		return fmt.Errorf("写入文件失败: %w", err)
+	}
+	fmt.Printf("已导出调色板 %s 到: %s\n", p.Name(), outputPath)
+	return nil
+}
+
+// outputPaletteFormats 支持 convert --palette 的目标格式（按名称和状态保存基岩版方块）
+var outputPaletteFormats = []string{wsstructure.NameMCStructure, wsstructure.NameMCWorld}
+
+// checkOutputPalette 检查目标格式能否使用指定的方块调色板输出
+func checkOutputPalette(p *blocks.Palette, targetFormat string) error {
+	if p == nil {
+		return nil
+	}
+	for _, format := range outputPaletteFormats {
+		if format == targetFormat {
+			return nil
+		}
+	}
+	return fmt.Errorf("目标格式 %s 不支持 --palette，只支持: %s", targetFormat, strings.Join(outputPaletteFormats, ", "))
+}
+
+// paletteTranslation 用 RuntimeIDTranslator 把输出中的方块（内置网易版调色板的名称和状态）转换为目标调色板
+type paletteTranslation struct {
+	from, to   *blocks.Palette
+	translator *blocks.RuntimeIDTranslator
+	cache      map[string]map[string]any // 方块名称和状态 SNBT → 转换后的方块
+	missing    map[string]bool           // 目标调色板中没有、已替换为空气的方块
+	items      int                       // 已转换为国际版名称的物品数量
+}
+
+// newPaletteTranslation 创建从内置调色板到 p 的转换
+func newPaletteTranslation(p *blocks.Palette) *paletteTranslation {
+	from := blocks.NEMCPalette()
+	return &paletteTranslation{
+		from:       from,
+		to:         p,
+		translator: blocks.NewRuntimeIDTranslator(from, p),
+		cache:      make(map[string]map[string]any),
+		missing:    make(map[string]bool),
+	}
+}
+
+// translateState 转换一个方块状态（{name, states, version}），内置调色板中找不到的方块保持不变
+func (t *paletteTranslation) translateState(state map[string]any) map[string]any {
+	name, _ := state["name"].(string)
+	props, _ := state["states"].(map[string]any)
+	key := name + describe.PropsFromNbt(props).SNBTString()
+	if translated, ok := t.cache[key]; ok {
+		return translated
+	}
+
+	translated := state
+	if rtid, found := t.from.BlockNameAndStateToRuntimeID(name, props); found {
+		target, found := t.translator.Translate(rtid)
+		if !found {
+			src, _ := t.from.RuntimeIDToBlock(rtid)
+			t.missing[src.BedrockString()] = true
+		}
+		block, _ := t.to.RuntimeIDToBlock(target)
+		translated = map[string]any{
+			"name":    block.LongName(),
+			"states":  block.States().ToNBT(),
+			"version": int32(t.to.Version()),
+		}
+	}
+	t.cache[key] = translated
+	return translated
+}
+
+// translateItems 把方块实体中的物品（网易版名称和数据值）转换为国际版，嵌套的容器物品（如潜影盒中的物品）一并转换
+// 内置调色板以外的调色板都按国际版处理
+func (t *paletteTranslation) translateItems(v any) {
+	if t.to == t.from {
+		return
+	}
+	switch v := v.(type) {
+	case map[string]any:
+		if name, ok := v["Name"].(string); ok && v["Count"] != nil {
+			data, _ := v["Damage"].(int16)
+			bedrockName, bedrockData, found := blocks.NetEaseItemToBedrock(name, data)
+			if found && (bedrockName != name || bedrockData != data) {
+				v["Name"], v["Damage"] = bedrockName, bedrockData
+				t.items++
+			}
+		}
+		for _, value := range v {
+			t.translateItems(value)
+		}
+	case []any:
+		for _, value := range v {
+			t.translateItems(value)
+		}
+	}
+}
+
+// translateBlockEntities 转换区块方块实体记录（连续的 NBT）中的物品
+func (t *paletteTranslation) translateBlockEntities(data []byte) ([]byte, error) {
+	list, err := decodeNBTList(data)
+	if err != nil {
+		return nil, err
+	}
+	var out bytes.Buffer
+	enc := nbt.NewEncoderWithEncoding(&out, nbt.LittleEndian)
+	for _, blockEntity := range list {
+		t.translateItems(blockEntity)
+		if err := enc.Encode(blockEntity); err != nil {
+			return nil, err
+		}
+	}
+	return out.Bytes(), nil
+}
+
+// printSummary 输出转换结果和目标调色板中没有的方块
+func (t *paletteTranslation) printSummary(entries int) {
+	fmt.Printf("已按方块调色板 %s 转换 %d 个调色板条目\n", t.to.Name(), entries)
+	if t.items > 0 {
+		fmt.Printf("已将 %d 个容器物品转换为国际版名称\n", t.items)
+	}
+	if len(t.missing) == 0 {
+		return
+	}
+	missing := make([]string, 0, len(t.missing))
+	for name := range t.missing {
+		missing = append(missing, name)
+	}
+	sort.Strings(missing)
+	fmt.Printf("调色板 %s 中没有以下 %d 种方块，已替换为空气:\n", t.to.Name(), len(missing))
+	for _, name := range missing {
+		fmt.Printf("  %s\n", name)
+	}
+}
+
+// translateMCStructureFile 转换 MCStructure 文件中所有调色板的方块和方块实体中的物品，方块索引保持不变
+func (t *paletteTranslation) translateMCStructureFile(f *os.File) (int, error) {
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return 0, err
+	}
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return 0, fmt.Errorf("读取输出文件失败: %w", err)
+	}
+	var root map[string]any
+	if err := nbt.UnmarshalEncoding(data, &root, nbt.LittleEndian); err != nil {
+		return 0, fmt.Errorf("解析 MCStructure 失败: %w", err)
+	}
+
+	entries := 0
+	structure, _ := root["structure"].(map[string]any)
+	palettes, _ := structure["palette"].(map[string]any)
+	for _, palette := range palettes {
+		palette, _ := palette.(map[string]any)
+		list, _ := palette["block_palette"].([]any)
+		for i, entry := range list {
+			if state, ok := entry.(map[string]any); ok {
+				list[i] = t.translateState(state)
+				entries++
+			}
+		}
+		t.translateItems(palette["block_position_data"])
+	}
+
+	if data, err = nbt.MarshalEncoding(root, nbt.LittleEndian); err != nil {
+		return entries, fmt.Errorf("编码 MCStructure 失败: %w", err)
+	}
+	if err := f.Truncate(0); err != nil {
+		return entries, err
+	}
+	if _, err := f.WriteAt(data, 0); err != nil {
+		return entries, fmt.Errorf("写入输出文件失败: %w", err)
+	}
+	return entries, nil
+}
+
+// translateWorldPalette 转换世界 db 中所有子区块的方块调色板和方块实体中的物品，世界必须已经关闭
+func (t *paletteTranslation) translateWorldPalette(worldDir string) (int, error) {
+	db, err := leveldb.OpenFile(filepath.Join(worldDir, "db"), nil)
+	if err != nil {
+		return 0, fmt.Errorf("无法打开数据库: %w", err)
+	}
+	defer db.Close()
+
+	// 迭代器读取的是快照，遍历时可以直接写回
+	entries := 0
+	iter := db.NewIterator(nil, nil)
+	defer iter.Release()
+	for iter.Next() {
+		k, ok := parseChunkKey(iter.Key())
+		if ok && k.Tag == world_define.KeyBlockEntities && t.to != t.from {
+			data, err := t.translateBlockEntities(iter.Value())
+			if err != nil {
+				return entries, fmt.Errorf("转换%s区块 %v 的方块实体失败: %w", dimensionName(k.Dimension), k.Pos, err)
+			}
+			if err := db.Put(append([]byte(nil), iter.Key()...), data, nil); err != nil {
+				return entries, fmt.Errorf("写入方块实体失败: %w", err)
+			}
+			continue
+		}
+		if !ok || k.Tag != world_define.KeySubChunkData {
+			continue
+		}
+		data, n, err := t.translateSubChunk(iter.Value())
+		if err != nil {
+			return entries, fmt.Errorf("转换%s子区块 %v (%d) 失败: %w", dimensionName(k.Dimension), k.Pos, k.SubChunk, err)
+		}
+		if err := db.Put(append([]byte(nil), iter.Key()...), data, nil); err != nil {
+			return entries, fmt.Errorf("写入子区块失败: %w", err)
+		}
+		entries += n
+	}
+	return entries, iter.Error()
+}
+
+// translateSubChunk 转换磁盘格式子区块数据中每个方块存储的调色板，方块索引和其余数据保持不变
+// 格式: 版本(1) [存储数量(1) [Y 序号(1)]] 每个存储: 头(1) 索引数据 [调色板数量(4)] 调色板 NBT...
+func (t *paletteTranslation) translateSubChunk(data []byte) ([]byte, int, error) {
+	buf := bytes.NewBuffer(data)
+	out := bytes.NewBuffer(make([]byte, 0, len(data)))
+	version, _ := buf.ReadByte()
+	out.WriteByte(version)
+
+	storages := 1
+	switch version {
+	case 1:
+	case 8, 9:
+		header := 1
+		if version == 9 {
+			header = 2 // 存储数量和 Y 序号
+		}
+		raw := buf.Next(header)
+		if len(raw) != header {
+			return nil, 0, fmt.Errorf("子区块数据不完整")
+		}
+		out.Write(raw)
+		storages = int(raw[0])
+	default:
+		// 更早的格式按数据值保存方块，没有调色板
+		return data, 0, nil
+	}
+
+	entries := 0
+	for i := 0; i < storages; i++ {
+		header, err := buf.ReadByte()
+		if err != nil {
+			return nil, entries, fmt.Errorf("读取方块存储头失败: %w", err)
+		}
+		out.WriteByte(header)
+		bits := int(header >> 1)
+		if bits == 0x7f {
+			continue
+		}
+		words := subChunkStorageWords(bits)
+		raw := buf.Next(words * 4)
+		if len(raw) != words*4 {
+			return nil, entries, fmt.Errorf("方块索引数据不完整")
+		}
+		out.Write(raw)
+
+		count := uint32(1)
+		if bits != 0 {
+			if err := binary.Read(buf, binary.LittleEndian, &count); err != nil {
+				return nil, entries, fmt.Errorf("读取调色板数量失败: %w", err)
+			}
+			_ = binary.Write(out, binary.LittleEndian, count)
+		}
+		dec := nbt.NewDecoderWithEncoding(buf, nbt.LittleEndian)
+		enc := nbt.NewEncoderWithEncoding(out, nbt.LittleEndian)
+		for j := uint32(0); j < count; j++ {
+			var state map[string]any
+			if err := dec.Decode(&state); err != nil {
+				return nil, entries, fmt.Errorf("读取调色板失败: %w", err)
+			}
+			if err := enc.Encode(t.translateState(state)); err != nil {
+				return nil, entries, fmt.Errorf("写入调色板失败: %w", err)
+			}
+			entries++
+		}
+	}
+	out.Write(buf.Bytes())
+	return out.Bytes(), entries, nil
+}
+
+// subChunkStorageWords 每个方块 bits 位时保存 4096 个索引需要的 uint32 数量
+// 每个 uint32 不跨越索引，3、5、6 位时末尾还有一个不满的 uint32
+func subChunkStorageWords(bits int) int {
+	if bits == 0 {
+		return 0
+	}
+	perWord := 32 / bits
+	return (4096 + perWord - 1) / perWord
+}
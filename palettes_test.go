@@ -0,0 +1,163 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/Yeah114/blocks"
+	"github.com/sandertv/gophertunnel/minecraft/nbt"
+)
+
+// testPaletteWithout 返回去掉名为 drop 的方块后的内置调色板，后面方块的运行时 ID 随之改变
+func testPaletteWithout(t *testing.T, drop string) *blocks.Palette {
+	t.Helper()
+	lines := strings.Split(strings.TrimSuffix(blocks.NEMCPalette().BlockSet().DumpStringRecords(), "\n"), "\n")
+	kept := []string{}
+	for _, line := range lines[2:] {
+		if !strings.HasPrefix(line, drop+" ") {
+			kept = append(kept, line)
+		}
+	}
+	p, err := blocks.PaletteFromStringRecords("test", fmt.Sprintf("%s\nCOUNTS:%d\n%s\n", lines[0], len(kept), strings.Join(kept, "\n")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func TestSubChunkStorageWords(t *testing.T) {
+	for bits, want := range map[int]int{0: 0, 1: 128, 2: 256, 3: 410, 4: 512, 5: 683, 6: 820, 8: 1024, 16: 2048} {
+		if got := subChunkStorageWords(bits); got != want {
+			t.Errorf("subChunkStorageWords(%d) = %d，应为 %d", bits, got, want)
+		}
+	}
+}
+
+func TestTranslateSubChunk(t *testing.T) {
+	stairs := map[string]any{"name": "minecraft:oak_stairs", "states": map[string]any{"upside_down_bit": uint8(1), "weirdo_direction": int32(2)}, "version": int32(1)}
+	barrier := map[string]any{"name": "minecraft:barrier", "states": map[string]any{}, "version": int32(1)}
+	water := map[string]any{"name": "minecraft:water", "states": map[string]any{"liquid_depth": int32(0)}, "version": int32(1)}
+
+	// 版本 9，两个存储：1 位索引 + 两个调色板条目，0 位索引 + 单个调色板条目（没有数量字段）
+	var data bytes.Buffer
+	data.Write([]byte{9, 2, 0xfc})
+	data.WriteByte(1 << 1)
+	indices := make([]byte, subChunkStorageWords(1)*4)
+	indices[0] = 0b10
+	data.Write(indices)
+	binary.Write(&data, binary.LittleEndian, uint32(2))
+	enc := nbt.NewEncoderWithEncoding(&data, nbt.LittleEndian)
+	enc.Encode(stairs)
+	enc.Encode(barrier)
+	data.WriteByte(0)
+	enc.Encode(water)
+	data.Write([]byte{0xaa, 0xbb})
+
+	tr := newPaletteTranslation(testPaletteWithout(t, "barrier"))
+	out, entries, err := tr.translateSubChunk(data.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if entries != 3 {
+		t.Errorf("转换了 %d 个调色板条目，应为 3", entries)
+	}
+	if !tr.missing["barrier []"] || len(tr.missing) != 1 {
+		t.Errorf("缺少的方块为 %v", tr.missing)
+	}
+
+	buf := bytes.NewBuffer(out)
+	if head := buf.Next(4); !bytes.Equal(head, []byte{9, 2, 0xfc, 1 << 1}) {
+		t.Fatalf("子区块头为 %x", head)
+	}
+	if got := buf.Next(len(indices)); !bytes.Equal(got, indices) {
+		t.Error("方块索引被改变")
+	}
+	var count uint32
+	binary.Read(buf, binary.LittleEndian, &count)
+	dec := nbt.NewDecoderWithEncoding(buf, nbt.LittleEndian)
+	var states []map[string]any
+	for i := 0; i < int(count)+1; i++ {
+		if i == int(count) {
+			if header, _ := buf.ReadByte(); header != 0 {
+				t.Fatalf("第二个存储头为 %x", header)
+			}
+		}
+		var state map[string]any
+		if err := dec.Decode(&state); err != nil {
+			t.Fatal(err)
+		}
+		states = append(states, state)
+	}
+	if count != 2 || states[0]["name"] != "minecraft:oak_stairs" || states[1]["name"] != "minecraft:air" || states[2]["name"] != "minecraft:water" {
+		t.Errorf("转换后的调色板为 %v", states)
+	}
+	if s, _ := states[0]["states"].(map[string]any); s["weirdo_direction"] != int32(2) {
+		t.Errorf("楼梯的方块状态为 %v", states[0]["states"])
+	}
+	if rest := buf.Bytes(); !bytes.Equal(rest, []byte{0xaa, 0xbb}) {
+		t.Errorf("末尾数据为 %x", rest)
+	}
+}
+
+func TestTranslateSubChunkLegacy(t *testing.T) {
+	tr := newPaletteTranslation(blocks.NEMCPalette())
+	data := []byte{0, 1, 2, 3}
+	if out, entries, err := tr.translateSubChunk(data); err != nil || entries != 0 || !bytes.Equal(out, data) {
+		t.Errorf("没有调色板的旧格式应保持不变，得到 %x %d %v", out, entries, err)
+	}
+	if _, _, err := tr.translateSubChunk([]byte{9, 1}); err == nil {
+		t.Error("不完整的子区块应返回错误")
+	}
+}
+
+func TestTranslateBlockEntityItems(t *testing.T) {
+	item := func(name string, data int16) map[string]any {
+		return map[string]any{"Name": name, "Count": uint8(1), "Damage": data}
+	}
+	shulker := item("minecraft:undyed_shulker_box", 0)
+	shulker["tag"] = map[string]any{"Items": []any{item("minecraft:stonebrick", 3)}}
+	chest := map[string]any{"id": "Chest", "Items": []any{item("minecraft:stonebrick", 3), item("minecraft:diamond_sword", 12), shulker}}
+
+	var data bytes.Buffer
+	enc := nbt.NewEncoderWithEncoding(&data, nbt.LittleEndian)
+	enc.Encode(chest)
+	enc.Encode(map[string]any{"id": "Sign", "Text": "stonebrick"})
+
+	tr := newPaletteTranslation(blocks.NEMCPalette())
+	tr.translateItems(map[string]any{"Items": []any{item("minecraft:stonebrick", 3)}})
+	if tr.items != 0 {
+		t.Errorf("输出内置调色板时不应转换物品，转换了 %d 个", tr.items)
+	}
+
+	tr = newPaletteTranslation(testPaletteWithout(t, "barrier"))
+	out, err := tr.translateBlockEntities(data.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	list, err := decodeNBTList(out)
+	if err != nil || len(list) != 2 {
+		t.Fatalf("转换后的方块实体为 %v %v", list, err)
+	}
+	items, _ := list[0]["Items"].([]any)
+	first, _ := items[0].(map[string]any)
+	if first["Name"] != "minecraft:chiseled_stone_bricks" || first["Damage"] != int16(0) {
+		t.Errorf("石砖物品转换为 %v", first)
+	}
+	if sword, _ := items[1].(map[string]any); sword["Name"] != "minecraft:diamond_sword" || sword["Damage"] != int16(12) {
+		t.Errorf("两个版本相同的物品被改变: %v", sword)
+	}
+	box, _ := items[2].(map[string]any)
+	nested, _ := box["tag"].(map[string]any)["Items"].([]any)
+	if inner, _ := nested[0].(map[string]any); inner["Name"] != "minecraft:chiseled_stone_bricks" {
+		t.Errorf("潜影盒中的物品转换为 %v", inner)
+	}
+	if list[1]["Text"] != "stonebrick" {
+		t.Errorf("告示牌文本被改变: %v", list[1])
+	}
+	if tr.items != 2 {
+		t.Errorf("转换了 %d 个物品，应为 2", tr.items)
+	}
+}
@@ -12,7 +12,6 @@ import (
 	"strconv"
 	"strings"
 
-	"github.com/TriM-Organization/bedrock-world-operator/chunk"
 	bwo_define "github.com/TriM-Organization/bedrock-world-operator/define"
 	"golang.org/x/image/font"
 	"golang.org/x/image/font/basicfont"
@@ -154,16 +153,16 @@ type ContainerInfo struct {
 }
 
 type ItemInfo struct {
-	Name         string
-	Count        int
-	Slot         int
-	Enchantments []EnchantmentInfo
-	CustomName   string
+	Name         string            `json:"name"`
+	Count        int               `json:"count"`
+	Slot         int               `json:"slot"`
+	Enchantments []EnchantmentInfo `json:"enchantments,omitempty"`
+	CustomName   string            `json:"custom_name,omitempty"`
 }
 
 type EnchantmentInfo struct {
-	ID    string
-	Level int
+	ID    string `json:"id"`
+	Level int    `json:"level"`
 }
 
 func parseContainer(blockName string, x, y, z int, nbtData map[string]any) *ContainerInfo {
@@ -197,28 +196,35 @@ func parseContainer(blockName string, x, y, z int, nbtData map[string]any) *Cont
 	}
 
 	// 获取物品列表
-	itemsRaw, ok := nbtData["Items"]
-	if !ok {
-		return container
-	}
+	container.Items = append(container.Items, parseItemList(nbtData["Items"])...)
+	return container
+}
 
+// parseItemList 解析 NBT 物品列表（容器的 Items、玩家的 Inventory 等），跳过空槽位
+// 没有 Slot 标签的列表（如盔甲栏）使用列表中的序号作为槽位
+func parseItemList(itemsRaw any) []ItemInfo {
 	var itemsList []any
 	switch v := itemsRaw.(type) {
 	case []any:
 		itemsList = v
+	case []map[string]any:
+		for _, m := range v {
+			itemsList = append(itemsList, m)
+		}
 	case map[string]any:
 		itemsList = []any{v}
 	default:
-		return container
+		return nil
 	}
 
-	for _, itemRaw := range itemsList {
+	var items []ItemInfo
+	for index, itemRaw := range itemsList {
 		itemMap, ok := itemRaw.(map[string]any)
 		if !ok {
 			continue
 		}
 
-		item := ItemInfo{}
+		item := ItemInfo{Slot: index}
 
 		// 物品名称
 		if name, ok := itemMap["Name"].(string); ok {
@@ -266,12 +272,33 @@ func parseContainer(blockName string, x, y, z int, nbtData map[string]any) *Cont
 			}
 		}
 
+		// 基岩版存档中的自定义名称和附魔保存在 tag 中
+		if tag, ok := itemMap["tag"].(map[string]any); ok {
+			if display, ok := tag["display"].(map[string]any); ok && item.CustomName == "" {
+				if customName, ok := display["Name"].(string); ok {
+					item.CustomName = customName
+				}
+			}
+			if enchantments, ok := tag["ench"].([]any); ok {
+				for _, enchRaw := range enchantments {
+					enchMap, ok := enchRaw.(map[string]any)
+					if !ok {
+						continue
+					}
+					ench := EnchantmentInfo{ID: fmt.Sprint(enchMap["id"])}
+					if level, ok := enchMap["lvl"].(int16); ok {
+						ench.Level = int(level)
+					}
+					item.Enchantments = append(item.Enchantments, ench)
+				}
+			}
+		}
+
 		if item.Name != "" {
-			container.Items = append(container.Items, item)
+			items = append(items, item)
 		}
 	}
-
-	return container
+	return items
 }
 
 func generateReportImage(outputPath, filePath string, size wsdefine.Size, offset wsdefine.Offset, blockCounts map[string]int, containers []ContainerInfo) error {
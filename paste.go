@@ -0,0 +1,295 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+
+	bwo_define "github.com/TriM-Organization/bedrock-world-operator/define"
+	"github.com/TriM-Organization/bedrock-world-operator/world"
+
+	wsdefine "github.com/Yeah114/WaterStructure/define"
+	wsstructure "github.com/Yeah114/WaterStructure/structure"
+	"github.com/Yeah114/blocks"
+)
+
+// pasteOptions 粘贴结构的选项
+type pasteOptions struct {
+	Origin    [3]int32             // 结构最小角落放置到的方块坐标
+	Dimension bwo_define.Dimension // 写入的维度
+	SkipAir   bool                 // 是否跳过结构中的空气（保留原有方块）
+	UseFast   bool                 // 是否使用快速模式读取结构
+}
+
+// parse 解析粘贴命令行选项
+func (opts *pasteOptions) parse(options []string) error {
+	for i := 0; i < len(options); i++ {
+		value := func() (string, error) {
+			if i+1 >= len(options) {
+				return "", fmt.Errorf("选项 %s 缺少参数", options[i])
+			}
+			i++
+			return options[i], nil
+		}
+
+		switch options[i] {
+		case "--x", "--y", "--z":
+			axis := int(options[i][2] - 'x')
+			s, err := value()
+			if err != nil {
+				return err
+			}
+			v, err := strconv.ParseInt(s, 10, 32)
+			if err != nil {
+				return fmt.Errorf("选项 %s 的参数必须是整数: %s", options[i-1], s)
+			}
+			opts.Origin[axis] = int32(v)
+		case "--dimension", "--dim":
+			name, err := value()
+			if err != nil {
+				return err
+			}
+			if opts.Dimension, err = parseDimension(name); err != nil {
+				return err
+			}
+		case "--skip-air":
+			opts.SkipAir = true
+		case "--fast":
+			opts.UseFast = true
+		default:
+			return fmt.Errorf("未知的选项: %s", options[i])
+		}
+	}
+	return nil
+}
+
+// pasteStructure 将任意格式的结构写入已有世界的指定方块坐标
+// 只修改结构范围内的方块，周围地形保持不变；范围内原有的方块实体被结构中的方块实体替换
+func pasteStructure(srcPath, worldPath, outputPath string, options []string) error {
+	opts := &pasteOptions{Origin: [3]int32{0, -60, 0}}
+	if err := opts.parse(options); err != nil {
+		return err
+	}
+
+	srcFile, err := os.Open(srcPath)
+	if err != nil {
+		return fmt.Errorf("无法打开源文件: %w", err)
+	}
+	defer srcFile.Close()
+
+	srcStruct, err := wsstructure.StructureFromFile(srcFile)
+	if err != nil {
+		return fmt.Errorf("无法识别源文件格式: %w", err)
+	}
+	defer srcStruct.Close()
+
+	fmt.Printf("检测到源格式: %s\n", srcStruct.Name())
+	size := srcStruct.GetSize()
+	if err := checkDimensionHeight(opts.Dimension, opts.Origin[1], int(size.Height)); err != nil {
+		return err
+	}
+
+	// 先把结构写入临时世界，统一按方块读取，这样可以放到任意坐标而不受子区块对齐限制
+	tmpDir, err := os.MkdirTemp("", "fatalder-paste-*")
+	if err != nil {
+		return fmt.Errorf("无法创建临时目录: %w", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	srcWorld, err := world.Open(tmpDir, nil)
+	if err != nil {
+		return fmt.Errorf("无法创建临时世界: %w", err)
+	}
+	defer func() { _ = srcWorld.Close() }()
+
+	fmt.Println("步骤 1/2: 读取源结构...")
+	startSubChunkPos := wsdefine.SubChunkPos{0, structureBaseY >> 4, 0}
+	if opts.UseFast {
+		err = convertReaderToMCWorldFast(srcStruct, srcWorld, bwo_define.DimensionIDOverworld, bwo_define.SubChunkPos(startSubChunkPos), func(int) {}, func() {})
+	} else {
+		err = srcStruct.ToMCWorld(srcWorld, startSubChunkPos, func(int) {}, func() {})
+	}
+	if err != nil {
+		return fmt.Errorf("读取结构失败: %w", err)
+	}
+	srcStart := wsdefine.BlockPos{startSubChunkPos.X() * 16, startSubChunkPos.Y() * 16, startSubChunkPos.Z() * 16}
+	if isJavaStructureFormat(srcStruct.Name()) {
+		srcEnd := wsdefine.BlockPos{srcStart.X() + int32(size.Width) - 1, srcStart.Y() + int32(size.Height) - 1, srcStart.Z() + int32(size.Length) - 1}
+		javaBlockName, err := javaBlockNamesOf(srcFile, srcStruct.Name(), srcStart)
+		if err != nil {
+			return err
+		}
+		if _, _, err := translateBlockEntities(srcWorld, srcStart, srcEnd, true, javaBlockName); err != nil {
+			return fmt.Errorf("转换方块实体失败: %w", err)
+		}
+	}
+
+	return editWorldPath(worldPath, outputPath, ".paste.mcworld", func(worldDir string) error {
+		dstWorld, err := world.Open(worldDir, nil)
+		if err != nil {
+			return fmt.Errorf("无法打开世界: %w", err)
+		}
+		defer func() { _ = dstWorld.CloseWorld() }()
+
+		fmt.Printf("步骤 2/2: 写入%s (%d × %d × %d)...\n", dimensionName(opts.Dimension), size.Width, size.Height, size.Length)
+		placed, blockEntities, err := pasteWorldRegion(srcWorld, srcStart, size, dstWorld, opts)
+		if err != nil {
+			return err
+		}
+		end := [3]int32{
+			opts.Origin[0] + int32(size.Width) - 1,
+			opts.Origin[1] + int32(size.Height) - 1,
+			opts.Origin[2] + int32(size.Length) - 1,
+		}
+		fmt.Printf("写入范围: (%d,%d,%d) ~ (%d,%d,%d)\n", opts.Origin[0], opts.Origin[1], opts.Origin[2], end[0], end[1], end[2])
+		fmt.Printf("写入方块: %d  方块实体: %d\n", placed, blockEntities)
+		return nil
+	})
+}
+
+// pasteWorldRegion 将 src 主世界中从 srcStart 开始、大小为 size 的区域逐方块复制到 dst 的 opts.Origin
+// 两层方块（含含水层）一起复制，再合并方块实体
+func pasteWorldRegion(src *world.BedrockWorld, srcStart wsdefine.BlockPos, size wsdefine.Size, dst *world.BedrockWorld, opts *pasteOptions) (placed int, blockEntities int, err error) {
+	srcEditor := newChunkEditor(src, bwo_define.DimensionIDOverworld)
+	dstEditor := newChunkEditor(dst, opts.Dimension)
+	offset := [3]int32{
+		opts.Origin[0] - srcStart.X(),
+		opts.Origin[1] - srcStart.Y(),
+		opts.Origin[2] - srcStart.Z(),
+	}
+
+	// isSourceAir 判断源结构中对应位置是否为空气（跳过空气时目标方块保持不变）
+	isSourceAir := func(x, y, z int32) (bool, error) {
+		for layer := uint8(0); layer < 2; layer++ {
+			rid, err := srcEditor.block(x, y, z, layer)
+			if err != nil || rid != blocks.AIR_RUNTIMEID {
+				return false, err
+			}
+		}
+		return true, nil
+	}
+
+	for dx := int32(0); dx < int32(size.Width); dx++ {
+		for dz := int32(0); dz < int32(size.Length); dz++ {
+			for dy := int32(0); dy < int32(size.Height); dy++ {
+				sx, sy, sz := srcStart.X()+dx, srcStart.Y()+dy, srcStart.Z()+dz
+				if opts.SkipAir {
+					air, err := isSourceAir(sx, sy, sz)
+					if err != nil {
+						return placed, blockEntities, err
+					}
+					if air {
+						continue
+					}
+				}
+				for layer := uint8(0); layer < 2; layer++ {
+					rid, err := srcEditor.block(sx, sy, sz, layer)
+					if err != nil {
+						return placed, blockEntities, err
+					}
+					if err := dstEditor.setBlock(sx+offset[0], sy+offset[1], sz+offset[2], layer, rid); err != nil {
+						return placed, blockEntities, err
+					}
+				}
+				placed++
+			}
+		}
+	}
+	if err := dstEditor.flush(); err != nil {
+		return placed, blockEntities, err
+	}
+
+	// 读取源结构的方块实体，按目标区块分组
+	srcEnd := wsdefine.BlockPos{srcStart.X() + int32(size.Width) - 1, srcStart.Y() + int32(size.Height) - 1, srcStart.Z() + int32(size.Length) - 1}
+	region := subChunkRegionOf(srcStart, srcEnd)
+	pasted := make(map[bwo_define.ChunkPos][]map[string]any)
+	for cx := region.Min[0]; cx <= region.Max[0]; cx++ {
+		for cz := region.Min[2]; cz <= region.Max[2]; cz++ {
+			pos := bwo_define.ChunkPos{cx, cz}
+			list, err := decodeNBTList(src.LoadNBTPayloadOnly(bwo_define.DimensionIDOverworld, pos))
+			if err != nil {
+				return placed, blockEntities, fmt.Errorf("读取结构的方块实体失败: %w", err)
+			}
+			for _, m := range list {
+				x, y, z, ok := nbtBlockPos(m)
+				if !ok || !blockInBox(x, y, z, srcStart, srcEnd) {
+					continue
+				}
+				x, y, z = x+offset[0], y+offset[1], z+offset[2]
+				m["x"], m["y"], m["z"] = x, y, z
+				target := bwo_define.ChunkPos{x >> 4, z >> 4}
+				pasted[target] = append(pasted[target], m)
+			}
+		}
+	}
+
+	// 合并到目标世界：保留范围外（以及跳过空气时未被覆盖）的原有方块实体
+	dstStart := wsdefine.BlockPos{opts.Origin[0], opts.Origin[1], opts.Origin[2]}
+	dstEnd := wsdefine.BlockPos{srcEnd.X() + offset[0], srcEnd.Y() + offset[1], srcEnd.Z() + offset[2]}
+	dstRegion := subChunkRegionOf(dstStart, dstEnd)
+	for cx := dstRegion.Min[0]; cx <= dstRegion.Max[0]; cx++ {
+		for cz := dstRegion.Min[2]; cz <= dstRegion.Max[2]; cz++ {
+			pos := bwo_define.ChunkPos{cx, cz}
+			payload := dst.LoadNBTPayloadOnly(opts.Dimension, pos)
+			existing, err := decodeNBTList(payload)
+			if err != nil {
+				return placed, blockEntities, fmt.Errorf("读取区块 %v 的方块实体失败: %w", pos, err)
+			}
+			if len(existing) == 0 && len(pasted[pos]) == 0 {
+				continue
+			}
+
+			merged := make([]map[string]any, 0, len(existing)+len(pasted[pos]))
+			for _, m := range existing {
+				x, y, z, ok := nbtBlockPos(m)
+				if ok && blockInBox(x, y, z, dstStart, dstEnd) {
+					if !opts.SkipAir {
+						continue
+					}
+					air, err := isSourceAir(x-offset[0], y-offset[1], z-offset[2])
+					if err != nil {
+						return placed, blockEntities, err
+					}
+					if !air {
+						continue
+					}
+				}
+				merged = append(merged, m)
+			}
+			merged = append(merged, pasted[pos]...)
+			blockEntities += len(pasted[pos])
+
+			if err := dst.SaveNBT(opts.Dimension, pos, merged); err != nil {
+				return placed, blockEntities, fmt.Errorf("写入区块 %v 的方块实体失败: %w", pos, err)
+			}
+		}
+	}
+	return placed, blockEntities, nil
+}
+
+// nbtBlockPos 读取方块实体 NBT 中的 x/y/z 坐标
+func nbtBlockPos(m map[string]any) (x, y, z int32, ok bool) {
+	var pos [3]int32
+	for i, key := range []string{"x", "y", "z"} {
+		switch v := m[key].(type) {
+		case int32:
+			pos[i] = v
+		case int64:
+			pos[i] = int32(v)
+		case int16:
+			pos[i] = int32(v)
+		case int:
+			pos[i] = int32(v)
+		default:
+			return 0, 0, 0, false
+		}
+	}
+	return pos[0], pos[1], pos[2], true
+}
+
+// blockInBox 判断方块坐标是否在 start ~ end 范围内（闭区间）
+func blockInBox(x, y, z int32, start, end wsdefine.BlockPos) bool {
+	return x >= start.X() && x <= end.X() &&
+		y >= start.Y() && y <= end.Y() &&
+		z >= start.Z() && z <= end.Z()
+}
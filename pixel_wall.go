@@ -0,0 +1,495 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+	"strconv"
+	"strings"
+
+	bwo_define "github.com/TriM-Organization/bedrock-world-operator/define"
+	"github.com/TriM-Organization/bedrock-world-operator/world"
+	"github.com/disintegration/imaging"
+
+	"github.com/Yeah114/blocks"
+)
+
+// pixelArtBlock 像素画可用的方块及其平均颜色
+type pixelArtBlock struct {
+	Block string
+	Group string
+	Color color.NRGBA
+
+	runtimeID uint32
+	lab       [3]float64
+}
+
+// pixelArtPalette 像素画墙使用的方块调色板（颜色为材质平均色）
+// concrete_powder 组的方块受重力影响，默认不启用
+var pixelArtPalette = []pixelArtBlock{
+	{Block: "white_wool", Group: "wool", Color: color.NRGBA{233, 236, 236, 255}},
+	{Block: "orange_wool", Group: "wool", Color: color.NRGBA{240, 118, 19, 255}},
+	{Block: "magenta_wool", Group: "wool", Color: color.NRGBA{189, 68, 179, 255}},
+	{Block: "light_blue_wool", Group: "wool", Color: color.NRGBA{58, 175, 217, 255}},
+	{Block: "yellow_wool", Group: "wool", Color: color.NRGBA{248, 197, 39, 255}},
+	{Block: "lime_wool", Group: "wool", Color: color.NRGBA{112, 185, 25, 255}},
+	{Block: "pink_wool", Group: "wool", Color: color.NRGBA{237, 141, 172, 255}},
+	{Block: "gray_wool", Group: "wool", Color: color.NRGBA{62, 68, 71, 255}},
+	{Block: "light_gray_wool", Group: "wool", Color: color.NRGBA{142, 142, 134, 255}},
+	{Block: "cyan_wool", Group: "wool", Color: color.NRGBA{21, 137, 145, 255}},
+	{Block: "purple_wool", Group: "wool", Color: color.NRGBA{121, 42, 172, 255}},
+	{Block: "blue_wool", Group: "wool", Color: color.NRGBA{53, 57, 157, 255}},
+	{Block: "brown_wool", Group: "wool", Color: color.NRGBA{114, 71, 40, 255}},
+	{Block: "green_wool", Group: "wool", Color: color.NRGBA{84, 109, 27, 255}},
+	{Block: "red_wool", Group: "wool", Color: color.NRGBA{160, 39, 34, 255}},
+	{Block: "black_wool", Group: "wool", Color: color.NRGBA{20, 21, 25, 255}},
+
+	{Block: "white_concrete", Group: "concrete", Color: color.NRGBA{207, 213, 214, 255}},
+	{Block: "orange_concrete", Group: "concrete", Color: color.NRGBA{224, 97, 0, 255}},
+	{Block: "magenta_concrete", Group: "concrete", Color: color.NRGBA{169, 48, 159, 255}},
+	{Block: "light_blue_concrete", Group: "concrete", Color: color.NRGBA{35, 137, 198, 255}},
+	{Block: "yellow_concrete", Group: "concrete", Color: color.NRGBA{240, 175, 21, 255}},
+	{Block: "lime_concrete", Group: "concrete", Color: color.NRGBA{94, 168, 24, 255}},
+	{Block: "pink_concrete", Group: "concrete", Color: color.NRGBA{213, 101, 142, 255}},
+	{Block: "gray_concrete", Group: "concrete", Color: color.NRGBA{54, 57, 61, 255}},
+	{Block: "light_gray_concrete", Group: "concrete", Color: color.NRGBA{125, 125, 115, 255}},
+	{Block: "cyan_concrete", Group: "concrete", Color: color.NRGBA{21, 119, 136, 255}},
+	{Block: "purple_concrete", Group: "concrete", Color: color.NRGBA{100, 31, 156, 255}},
+	{Block: "blue_concrete", Group: "concrete", Color: color.NRGBA{44, 46, 143, 255}},
+	{Block: "brown_concrete", Group: "concrete", Color: color.NRGBA{96, 59, 31, 255}},
+	{Block: "green_concrete", Group: "concrete", Color: color.NRGBA{73, 91, 36, 255}},
+	{Block: "red_concrete", Group: "concrete", Color: color.NRGBA{142, 32, 32, 255}},
+	{Block: "black_concrete", Group: "concrete", Color: color.NRGBA{8, 10, 15, 255}},
+
+	{Block: "hardened_clay", Group: "terracotta", Color: color.NRGBA{152, 94, 67, 255}},
+	{Block: "white_terracotta", Group: "terracotta", Color: color.NRGBA{209, 178, 161, 255}},
+	{Block: "orange_terracotta", Group: "terracotta", Color: color.NRGBA{161, 83, 37, 255}},
+	{Block: "magenta_terracotta", Group: "terracotta", Color: color.NRGBA{149, 88, 108, 255}},
+	{Block: "light_blue_terracotta", Group: "terracotta", Color: color.NRGBA{113, 108, 137, 255}},
+	{Block: "yellow_terracotta", Group: "terracotta", Color: color.NRGBA{186, 133, 35, 255}},
+	{Block: "lime_terracotta", Group: "terracotta", Color: color.NRGBA{103, 117, 52, 255}},
+	{Block: "pink_terracotta", Group: "terracotta", Color: color.NRGBA{161, 78, 78, 255}},
+	{Block: "gray_terracotta", Group: "terracotta", Color: color.NRGBA{57, 42, 35, 255}},
+	{Block: "light_gray_terracotta", Group: "terracotta", Color: color.NRGBA{135, 106, 97, 255}},
+	{Block: "cyan_terracotta", Group: "terracotta", Color: color.NRGBA{86, 91, 91, 255}},
+	{Block: "purple_terracotta", Group: "terracotta", Color: color.NRGBA{118, 70, 86, 255}},
+	{Block: "blue_terracotta", Group: "terracotta", Color: color.NRGBA{74, 59, 91, 255}},
+	{Block: "brown_terracotta", Group: "terracotta", Color: color.NRGBA{77, 51, 35, 255}},
+	{Block: "green_terracotta", Group: "terracotta", Color: color.NRGBA{76, 83, 42, 255}},
+	{Block: "red_terracotta", Group: "terracotta", Color: color.NRGBA{143, 61, 46, 255}},
+	{Block: "black_terracotta", Group: "terracotta", Color: color.NRGBA{37, 22, 16, 255}},
+
+	{Block: "white_concrete_powder", Group: "concrete_powder", Color: color.NRGBA{225, 227, 227, 255}},
+	{Block: "orange_concrete_powder", Group: "concrete_powder", Color: color.NRGBA{227, 131, 31, 255}},
+	{Block: "magenta_concrete_powder", Group: "concrete_powder", Color: color.NRGBA{192, 83, 184, 255}},
+	{Block: "light_blue_concrete_powder", Group: "concrete_powder", Color: color.NRGBA{74, 180, 213, 255}},
+	{Block: "yellow_concrete_powder", Group: "concrete_powder", Color: color.NRGBA{232, 199, 54, 255}},
+	{Block: "lime_concrete_powder", Group: "concrete_powder", Color: color.NRGBA{125, 189, 41, 255}},
+	{Block: "pink_concrete_powder", Group: "concrete_powder", Color: color.NRGBA{228, 153, 181, 255}},
+	{Block: "gray_concrete_powder", Group: "concrete_powder", Color: color.NRGBA{76, 81, 84, 255}},
+	{Block: "light_gray_concrete_powder", Group: "concrete_powder", Color: color.NRGBA{154, 154, 148, 255}},
+	{Block: "cyan_concrete_powder", Group: "concrete_powder", Color: color.NRGBA{36, 147, 157, 255}},
+	{Block: "purple_concrete_powder", Group: "concrete_powder", Color: color.NRGBA{131, 55, 177, 255}},
+	{Block: "blue_concrete_powder", Group: "concrete_powder", Color: color.NRGBA{70, 73, 166, 255}},
+	{Block: "brown_concrete_powder", Group: "concrete_powder", Color: color.NRGBA{125, 84, 53, 255}},
+	{Block: "green_concrete_powder", Group: "concrete_powder", Color: color.NRGBA{97, 119, 44, 255}},
+	{Block: "red_concrete_powder", Group: "concrete_powder", Color: color.NRGBA{168, 54, 50, 255}},
+	{Block: "black_concrete_powder", Group: "concrete_powder", Color: color.NRGBA{25, 26, 31, 255}},
+
+	{Block: "stone", Group: "misc", Color: color.NRGBA{125, 125, 125, 255}},
+	{Block: "cobblestone", Group: "misc", Color: color.NRGBA{127, 127, 127, 255}},
+	{Block: "smooth_stone", Group: "misc", Color: color.NRGBA{158, 158, 158, 255}},
+	{Block: "deepslate", Group: "misc", Color: color.NRGBA{80, 80, 82, 255}},
+	{Block: "calcite", Group: "misc", Color: color.NRGBA{223, 224, 220, 255}},
+	{Block: "tuff", Group: "misc", Color: color.NRGBA{108, 109, 102, 255}},
+	{Block: "dripstone_block", Group: "misc", Color: color.NRGBA{134, 107, 92, 255}},
+	{Block: "blackstone", Group: "misc", Color: color.NRGBA{42, 36, 41, 255}},
+	{Block: "oak_planks", Group: "misc", Color: color.NRGBA{162, 130, 78, 255}},
+	{Block: "spruce_planks", Group: "misc", Color: color.NRGBA{114, 84, 48, 255}},
+	{Block: "birch_planks", Group: "misc", Color: color.NRGBA{192, 175, 121, 255}},
+	{Block: "jungle_planks", Group: "misc", Color: color.NRGBA{160, 115, 80, 255}},
+	{Block: "acacia_planks", Group: "misc", Color: color.NRGBA{168, 90, 50, 255}},
+	{Block: "dark_oak_planks", Group: "misc", Color: color.NRGBA{66, 43, 20, 255}},
+	{Block: "mangrove_planks", Group: "misc", Color: color.NRGBA{117, 54, 48, 255}},
+	{Block: "cherry_planks", Group: "misc", Color: color.NRGBA{226, 178, 172, 255}},
+	{Block: "bamboo_planks", Group: "misc", Color: color.NRGBA{193, 173, 80, 255}},
+	{Block: "crimson_planks", Group: "misc", Color: color.NRGBA{101, 48, 70, 255}},
+	{Block: "warped_planks", Group: "misc", Color: color.NRGBA{43, 104, 99, 255}},
+	{Block: "sandstone", Group: "misc", Color: color.NRGBA{216, 203, 155, 255}},
+	{Block: "red_sandstone", Group: "misc", Color: color.NRGBA{186, 99, 29, 255}},
+	{Block: "quartz_block", Group: "misc", Color: color.NRGBA{235, 229, 222, 255}},
+	{Block: "brick_block", Group: "misc", Color: color.NRGBA{150, 97, 83, 255}},
+	{Block: "packed_mud", Group: "misc", Color: color.NRGBA{142, 106, 79, 255}},
+	{Block: "clay", Group: "misc", Color: color.NRGBA{160, 166, 179, 255}},
+	{Block: "snow", Group: "misc", Color: color.NRGBA{249, 254, 254, 255}},
+	{Block: "bone_block", Group: "misc", Color: color.NRGBA{229, 225, 207, 255}},
+	{Block: "hay_block", Group: "misc", Color: color.NRGBA{166, 136, 38, 255}},
+	{Block: "melon_block", Group: "misc", Color: color.NRGBA{111, 145, 30, 255}},
+	{Block: "dried_kelp_block", Group: "misc", Color: color.NRGBA{50, 58, 38, 255}},
+	{Block: "moss_block", Group: "misc", Color: color.NRGBA{89, 109, 45, 255}},
+	{Block: "honeycomb_block", Group: "misc", Color: color.NRGBA{229, 148, 29, 255}},
+	{Block: "prismarine", Group: "misc", Color: color.NRGBA{99, 156, 151, 255}},
+	{Block: "end_stone", Group: "misc", Color: color.NRGBA{219, 222, 158, 255}},
+	{Block: "purpur_block", Group: "misc", Color: color.NRGBA{169, 125, 169, 255}},
+	{Block: "netherrack", Group: "misc", Color: color.NRGBA{97, 38, 38, 255}},
+	{Block: "nether_brick", Group: "misc", Color: color.NRGBA{44, 21, 26, 255}},
+	{Block: "nether_wart_block", Group: "misc", Color: color.NRGBA{114, 2, 2, 255}},
+	{Block: "warped_wart_block", Group: "misc", Color: color.NRGBA{22, 119, 121, 255}},
+	{Block: "obsidian", Group: "misc", Color: color.NRGBA{15, 10, 24, 255}},
+	{Block: "amethyst_block", Group: "misc", Color: color.NRGBA{133, 97, 191, 255}},
+	{Block: "copper_block", Group: "misc", Color: color.NRGBA{192, 107, 79, 255}},
+	{Block: "raw_iron_block", Group: "misc", Color: color.NRGBA{166, 135, 107, 255}},
+	{Block: "raw_gold_block", Group: "misc", Color: color.NRGBA{221, 169, 46, 255}},
+	{Block: "raw_copper_block", Group: "misc", Color: color.NRGBA{154, 105, 79, 255}},
+	{Block: "iron_block", Group: "misc", Color: color.NRGBA{220, 220, 220, 255}},
+	{Block: "gold_block", Group: "misc", Color: color.NRGBA{246, 208, 61, 255}},
+	{Block: "diamond_block", Group: "misc", Color: color.NRGBA{98, 237, 228, 255}},
+	{Block: "emerald_block", Group: "misc", Color: color.NRGBA{42, 203, 87, 255}},
+	{Block: "lapis_block", Group: "misc", Color: color.NRGBA{30, 67, 140, 255}},
+	{Block: "redstone_block", Group: "misc", Color: color.NRGBA{175, 24, 5, 255}},
+	{Block: "coal_block", Group: "misc", Color: color.NRGBA{16, 15, 15, 255}},
+}
+
+// pixelArtDefaultGroups 默认启用的方块组
+var pixelArtDefaultGroups = []string{"wool", "concrete", "terracotta", "misc"}
+
+// pixelWallOptions 像素画墙选项
+type pixelWallOptions struct {
+	Origin     [3]int32             // 左下角方块坐标
+	Dimension  bwo_define.Dimension // 写入的维度
+	Facing     string               // 墙面朝向（观看者所在方向）: north / south / east / west
+	Width      int                  // 宽度（方块，0 表示按图片计算）
+	Height     int                  // 高度（方块，0 表示按图片计算）
+	Groups     []string             // 启用的方块组
+	Dither     bool                 // 是否启用 Floyd-Steinberg 抖动
+	Preprocess *mapArtPreprocess
+}
+
+// convertPixelWall 将图片转换为竖直的像素画墙并写入世界
+func convertPixelWall(imagePath, worldPath, outputPath string, options []string) error {
+	img, err := imaging.Open(imagePath, imaging.AutoOrientation(true))
+	if err != nil {
+		return fmt.Errorf("无法打开图片: %w", err)
+	}
+
+	opts := &pixelWallOptions{
+		Origin:     [3]int32{0, -60, 0},
+		Facing:     "south",
+		Groups:     pixelArtDefaultGroups,
+		Preprocess: newMapArtPreprocess(),
+	}
+	if err := opts.parse(options); err != nil {
+		return err
+	}
+
+	palette, err := loadPixelArtPalette(opts.Groups)
+	if err != nil {
+		return err
+	}
+
+	width, height := opts.targetSize(img.Bounds().Dx(), img.Bounds().Dy())
+	fmt.Println("正在预处理图片...")
+	if opts.Preprocess.CropMode == "none" {
+		img = imaging.Resize(img, width, height, opts.Preprocess.resampleFilter())
+	}
+	img = opts.Preprocess.apply(img, width, height)
+
+	return editWorldPath(worldPath, outputPath, ".wall.mcworld", func(worldDir string) error {
+		fmt.Printf("正在%s生成像素画墙 (%d × %d，朝向 %s，%d 种方块)...\n", dimensionName(opts.Dimension), width, height, opts.Facing, len(palette))
+		minPos, maxPos, err := writePixelWallToWorldDir(worldDir, img, palette, opts)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("写入范围: (%d,%d,%d) ~ (%d,%d,%d)\n", minPos[0], minPos[1], minPos[2], maxPos[0], maxPos[1], maxPos[2])
+		return nil
+	})
+}
+
+// parse 解析像素画墙命令行选项
+func (opts *pixelWallOptions) parse(options []string) error {
+	for i := 0; i < len(options); i++ {
+		consumed, err := opts.Preprocess.parseOption(options, i)
+		if err != nil {
+			return err
+		}
+		if consumed > 0 {
+			i += consumed - 1
+			continue
+		}
+
+		value := func() (string, error) {
+			if i+1 >= len(options) {
+				return "", fmt.Errorf("选项 %s 缺少参数", options[i])
+			}
+			i++
+			return options[i], nil
+		}
+		integer := func() (int64, error) {
+			s, err := value()
+			if err != nil {
+				return 0, err
+			}
+			v, err := strconv.ParseInt(s, 10, 32)
+			if err != nil {
+				return 0, fmt.Errorf("选项 %s 的参数必须是整数: %s", options[i-1], s)
+			}
+			return v, nil
+		}
+
+		switch options[i] {
+		case "--x", "--y", "--z":
+			axis := int(options[i][2] - 'x')
+			v, err := integer()
+			if err != nil {
+				return err
+			}
+			opts.Origin[axis] = int32(v)
+		case "--facing":
+			facing, err := value()
+			if err != nil {
+				return err
+			}
+			facing = strings.ToLower(facing)
+			switch facing {
+			case "north", "south", "east", "west":
+				opts.Facing = facing
+			default:
+				return fmt.Errorf("未知的朝向: %s（可选: north, south, east, west）", facing)
+			}
+		case "--width", "--height":
+			v, err := integer()
+			if err != nil {
+				return err
+			}
+			if v <= 0 {
+				return fmt.Errorf("选项 %s 必须大于0", options[i-1])
+			}
+			if options[i-1] == "--width" {
+				opts.Width = int(v)
+			} else {
+				opts.Height = int(v)
+			}
+		case "--palette":
+			groups, err := value()
+			if err != nil {
+				return err
+			}
+			opts.Groups = nil
+			for _, g := range strings.Split(groups, ",") {
+				if g = strings.TrimSpace(g); g == "all" {
+					opts.Groups = append(opts.Groups, pixelArtDefaultGroups...)
+					opts.Groups = append(opts.Groups, "concrete_powder")
+				} else if g != "" {
+					opts.Groups = append(opts.Groups, g)
+				}
+			}
+		case "--dither":
+			opts.Dither = true
+		case "--dimension", "--dim":
+			name, err := value()
+			if err != nil {
+				return err
+			}
+			if opts.Dimension, err = parseDimension(name); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("未知的选项: %s", options[i])
+		}
+	}
+	return nil
+}
+
+// targetSize 根据选项和图片尺寸计算墙面尺寸（只指定一边时保持比例）
+func (opts *pixelWallOptions) targetSize(imgWidth, imgHeight int) (int, int) {
+	width, height := opts.Width, opts.Height
+	switch {
+	case width == 0 && height == 0:
+		width, height = imgWidth, imgHeight
+	case width == 0:
+		width = int(math.Round(float64(imgWidth) * float64(height) / float64(imgHeight)))
+	case height == 0:
+		height = int(math.Round(float64(imgHeight) * float64(width) / float64(imgWidth)))
+	}
+	return max(width, 1), max(height, 1)
+}
+
+// blockPos 将图片像素坐标（左上角为原点）转换为世界方块坐标
+// 朝向表示观看者所在的方向，保证站在该方向看墙时图片不会左右颠倒
+func (opts *pixelWallOptions) blockPos(px, py, width, height int) (x, y, z int32) {
+	x, y, z = opts.Origin[0], opts.Origin[1]+int32(height-1-py), opts.Origin[2]
+	switch opts.Facing {
+	case "south": // XY 平面，从 +Z 方向看，右侧为 +X
+		x += int32(px)
+	case "north": // XY 平面，从 -Z 方向看，右侧为 -X
+		x += int32(width - 1 - px)
+	case "east": // ZY 平面，从 +X 方向看，右侧为 -Z
+		z += int32(width - 1 - px)
+	case "west": // ZY 平面，从 -X 方向看，右侧为 +Z
+		z += int32(px)
+	}
+	return x, y, z
+}
+
+// loadPixelArtPalette 加载指定方块组的调色板并解析运行时ID
+func loadPixelArtPalette(groups []string) ([]pixelArtBlock, error) {
+	enabled := make(map[string]bool)
+	for _, g := range groups {
+		enabled[g] = true
+	}
+	known := make(map[string]bool)
+	var palette []pixelArtBlock
+	for _, b := range pixelArtPalette {
+		known[b.Group] = true
+		if !enabled[b.Group] {
+			continue
+		}
+		runtimeID, found := blocks.BlockStrToRuntimeID(b.Block)
+		if !found {
+			continue
+		}
+		b.runtimeID = runtimeID
+		b.lab = rgbToLab(float64(b.Color.R), float64(b.Color.G), float64(b.Color.B))
+		palette = append(palette, b)
+	}
+	for g := range enabled {
+		if !known[g] {
+			return nil, fmt.Errorf("未知的方块组: %s（可选: wool, concrete, terracotta, concrete_powder, misc, all）", g)
+		}
+	}
+	if len(palette) == 0 {
+		return nil, fmt.Errorf("调色板为空")
+	}
+	return palette, nil
+}
+
+// nearestPixelArtBlock 在 Lab 颜色空间中查找最接近的方块
+func nearestPixelArtBlock(palette []pixelArtBlock, r, g, b float64) int {
+	lab := rgbToLab(r, g, b)
+	best, bestDist := 0, math.MaxFloat64
+	for i := range palette {
+		dl := palette[i].lab[0] - lab[0]
+		da := palette[i].lab[1] - lab[1]
+		db := palette[i].lab[2] - lab[2]
+		if dist := dl*dl + da*da + db*db; dist < bestDist {
+			best, bestDist = i, dist
+		}
+	}
+	return best
+}
+
+// rgbToLab 将 sRGB (0-255) 转换为 CIE Lab (D65)
+func rgbToLab(r, g, b float64) [3]float64 {
+	linear := func(c float64) float64 {
+		c = math.Min(math.Max(c/255, 0), 1)
+		if c <= 0.04045 {
+			return c / 12.92
+		}
+		return math.Pow((c+0.055)/1.055, 2.4)
+	}
+	lr, lg, lb := linear(r), linear(g), linear(b)
+	x := (lr*0.4124 + lg*0.3576 + lb*0.1805) / 0.95047
+	y := lr*0.2126 + lg*0.7152 + lb*0.0722
+	z := (lr*0.0193 + lg*0.1192 + lb*0.9505) / 1.08883
+	f := func(t float64) float64 {
+		if t > 216.0/24389.0 {
+			return math.Cbrt(t)
+		}
+		return (24389.0/27.0*t + 16) / 116
+	}
+	fx, fy, fz := f(x), f(y), f(z)
+	return [3]float64{116*fy - 16, 500 * (fx - fy), 200 * (fy - fz)}
+}
+
+// quantizePixelWall 将图片每个像素映射为调色板索引（-1 表示透明像素）
+func quantizePixelWall(img image.Image, palette []pixelArtBlock, dither bool) [][]int {
+	src := imaging.Clone(img)
+	width, height := src.Bounds().Dx(), src.Bounds().Dy()
+
+	// 误差扩散需要浮点缓冲区
+	buf := make([][3]float64, width*height)
+	for py := 0; py < height; py++ {
+		for px := 0; px < width; px++ {
+			c := src.NRGBAAt(px, py)
+			buf[py*width+px] = [3]float64{float64(c.R), float64(c.G), float64(c.B)}
+		}
+	}
+	spread := func(px, py int, e [3]float64, weight float64) {
+		if px < 0 || px >= width || py >= height {
+			return
+		}
+		p := &buf[py*width+px]
+		for k := 0; k < 3; k++ {
+			p[k] += e[k] * weight
+		}
+	}
+
+	result := make([][]int, height)
+	for py := 0; py < height; py++ {
+		result[py] = make([]int, width)
+		for px := 0; px < width; px++ {
+			if src.NRGBAAt(px, py).A < 128 {
+				result[py][px] = -1
+				continue
+			}
+			c := buf[py*width+px]
+			index := nearestPixelArtBlock(palette, c[0], c[1], c[2])
+			result[py][px] = index
+			if !dither {
+				continue
+			}
+			chosen := palette[index].Color
+			e := [3]float64{c[0] - float64(chosen.R), c[1] - float64(chosen.G), c[2] - float64(chosen.B)}
+			spread(px+1, py, e, 7.0/16)
+			spread(px-1, py+1, e, 3.0/16)
+			spread(px, py+1, e, 5.0/16)
+			spread(px+1, py+1, e, 1.0/16)
+		}
+	}
+	return result
+}
+
+// writePixelWallToWorldDir 将像素画墙写入世界目录
+func writePixelWallToWorldDir(worldDir string, img image.Image, palette []pixelArtBlock, opts *pixelWallOptions) (minPos [3]int32, maxPos [3]int32, err error) {
+	bedrockWorld, err := world.Open(worldDir, nil)
+	if err != nil {
+		return [3]int32{}, [3]int32{}, fmt.Errorf("无法打开世界: %w", err)
+	}
+	defer func() { _ = bedrockWorld.CloseWorld() }()
+
+	width, height := img.Bounds().Dx(), img.Bounds().Dy()
+	indexes := quantizePixelWall(img, palette, opts.Dither)
+
+	editor := newChunkEditor(bedrockWorld, opts.Dimension)
+	usage := make(map[string]int)
+	first := true
+	for py := 0; py < height; py++ {
+		for px := 0; px < width; px++ {
+			index := indexes[py][px]
+			if index < 0 {
+				continue
+			}
+			x, y, z := opts.blockPos(px, py, width, height)
+			if err := editor.setBlock(x, y, z, 0, palette[index].runtimeID); err != nil {
+				return [3]int32{}, [3]int32{}, err
+			}
+			usage[palette[index].Block]++
+
+			pos := [3]int32{x, y, z}
+			if first {
+				minPos, maxPos, first = pos, pos, false
+			}
+			for k := 0; k < 3; k++ {
+				minPos[k] = min(minPos[k], pos[k])
+				maxPos[k] = max(maxPos[k], pos[k])
+			}
+		}
+	}
+	if err := editor.flush(); err != nil {
+		return [3]int32{}, [3]int32{}, err
+	}
+
+	fmt.Printf("使用了 %d 种方块\n", len(usage))
+	return minPos, maxPos, nil
+}
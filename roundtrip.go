@@ -0,0 +1,221 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/TriM-Organization/bedrock-world-operator/chunk"
+	bwo_define "github.com/TriM-Organization/bedrock-world-operator/define"
+
+	wsdefine "github.com/Yeah114/WaterStructure/define"
+	wsstructure "github.com/Yeah114/WaterStructure/structure"
+	"github.com/Yeah114/blocks"
+)
+
+// structureSnapshot 结构的尺寸和方块实体（坐标为结构内坐标，Y 从 0 开始）
+type structureSnapshot struct {
+	Format        string
+	Size          wsdefine.Size
+	BlockEntities map[wsdefine.BlockPos]string // 方块实体位置 → 该位置的方块名称
+}
+
+// roundTripResult 单个格式的往返检查结果
+type roundTripResult struct {
+	Format       string
+	Checked      int
+	Missing      []wsdefine.BlockPos // 转换后丢失的方块实体
+	BlockChanged []wsdefine.BlockPos // 方块实体仍在，但所在位置的方块变了
+	SizeChanged  bool
+	Err          error
+}
+
+// ok 判断往返检查是否通过
+func (r *roundTripResult) ok() bool {
+	return r.Err == nil && !r.SizeChanged && len(r.Missing) == 0 && len(r.BlockChanged) == 0
+}
+
+// readStructureSnapshot 读取结构文件的尺寸和所有方块实体所在的方块
+func readStructureSnapshot(path string) (*structureSnapshot, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("无法打开文件: %w", err)
+	}
+	defer file.Close()
+
+	structure, err := wsstructure.StructureFromFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("无法识别文件格式: %w", err)
+	}
+	defer structure.Close()
+
+	size := structure.GetSize()
+	var allChunkPos []wsdefine.ChunkPos
+	for x := 0; x < size.GetChunkXCount(); x++ {
+		for z := 0; z < size.GetChunkZCount(); z++ {
+			allChunkPos = append(allChunkPos, wsdefine.ChunkPos{int32(x), int32(z)})
+		}
+	}
+	chunks, err := structure.GetChunks(allChunkPos)
+	if err != nil {
+		return nil, fmt.Errorf("读取方块数据失败: %w", err)
+	}
+	chunksNBT, err := structure.GetChunksNBT(allChunkPos)
+	if err != nil {
+		return nil, fmt.Errorf("读取NBT数据失败: %w", err)
+	}
+
+	snapshot := &structureSnapshot{
+		Format:        structure.Name(),
+		Size:          size,
+		BlockEntities: make(map[wsdefine.BlockPos]string),
+	}
+	for cpos, blockMap := range chunksNBT {
+		c := chunks[cpos]
+		for bpos := range blockMap {
+			// 与 convertReaderToMCWorldFast 相同的坐标换算
+			localX, localZ := bpos.X()&15, bpos.Z()&15
+			pos := wsdefine.BlockPos{cpos.X()*16 + localX, bpos.Y() - structureBaseY, cpos.Z()*16 + localZ}
+			snapshot.BlockEntities[pos] = snapshotBlockName(c, uint8(localX), int16(bpos.Y()), uint8(localZ))
+		}
+	}
+	return snapshot, nil
+}
+
+// snapshotBlockName 返回区块中指定位置的方块名称
+func snapshotBlockName(c *chunk.Chunk, x uint8, y int16, z uint8) string {
+	if c == nil {
+		return "air"
+	}
+	block, found := blocks.RuntimeIDToBlock(c.Block(x, y, z, 0))
+	if !found {
+		return "未知方块"
+	}
+	return block.ShortName()
+}
+
+// compareSnapshots 检查源结构的每个方块实体在转换后是否仍位于相同的方块上
+func compareSnapshots(format string, src, dst *structureSnapshot) *roundTripResult {
+	result := &roundTripResult{Format: format, Checked: len(src.BlockEntities)}
+	result.SizeChanged = src.Size != dst.Size
+	for pos, block := range src.BlockEntities {
+		got, ok := dst.BlockEntities[pos]
+		switch {
+		case !ok:
+			result.Missing = append(result.Missing, pos)
+		case got != block:
+			result.BlockChanged = append(result.BlockChanged, pos)
+		}
+	}
+	sortBlockPos(result.Missing)
+	sortBlockPos(result.BlockChanged)
+	return result
+}
+
+// sortBlockPos 按 Y、Z、X 排序坐标，使输出稳定
+func sortBlockPos(list []wsdefine.BlockPos) {
+	sort.Slice(list, func(i, j int) bool {
+		a, b := list[i], list[j]
+		if a.Y() != b.Y() {
+			return a.Y() < b.Y()
+		}
+		if a.Z() != b.Z() {
+			return a.Z() < b.Z()
+		}
+		return a.X() < b.X()
+	})
+}
+
+// roundTripCheck 将结构依次转换为各个格式再读回，检查方块实体是否仍位于相同的方块上
+// formats 为空时检查所有支持的格式；任一格式不通过时返回错误
+func roundTripCheck(srcPath string, formats []string, useFast bool) error {
+	if len(formats) == 0 {
+		formats = getSupportedFormats()
+	}
+	for _, format := range formats {
+		if _, ok := wsstructure.StructureNamePool[format]; !ok {
+			return fmt.Errorf("不支持的目标格式: %s\n使用 'list' 命令查看支持的格式", format)
+		}
+	}
+
+	src, err := readStructureSnapshot(srcPath)
+	if err != nil {
+		return fmt.Errorf("读取源结构失败: %w", err)
+	}
+	fmt.Printf("源结构: %s (%d × %d × %d，%d 个方块实体)\n",
+		src.Format, src.Size.Width, src.Size.Height, src.Size.Length, len(src.BlockEntities))
+
+	tmpDir, err := os.MkdirTemp("", "fatalder-roundtrip-*")
+	if err != nil {
+		return fmt.Errorf("无法创建临时目录: %w", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	baseName := strings.TrimSuffix(filepath.Base(srcPath), filepath.Ext(srcPath))
+	var results []*roundTripResult
+	for _, format := range formats {
+		fmt.Println()
+		fmt.Printf("==> %s\n", format)
+		destPath := filepath.Join(tmpDir, baseName+"."+strings.ToLower(format))
+		if err := convertStructure(srcPath, format, destPath, useFast, worldPlacement{Dimension: bwo_define.DimensionIDOverworld}); err != nil {
+			results = append(results, &roundTripResult{Format: format, Err: fmt.Errorf("转换失败: %w", err)})
+			continue
+		}
+		dst, err := readStructureSnapshot(destPath)
+		if err != nil {
+			results = append(results, &roundTripResult{Format: format, Err: fmt.Errorf("读回失败: %w", err)})
+			continue
+		}
+		results = append(results, compareSnapshots(format, src, dst))
+	}
+
+	return printRoundTripResults(results)
+}
+
+// printRoundTripResults 输出往返检查结果，任一格式不通过时返回错误
+func printRoundTripResults(results []*roundTripResult) error {
+	const maxListed = 5
+	line := "=" + strings.Repeat("=", 70) + "="
+
+	fmt.Println()
+	fmt.Println(line)
+	fmt.Println("往返检查结果:")
+	var failed []string
+	for _, r := range results {
+		if r.ok() {
+			fmt.Printf("  ✓ %-14s %d 个方块实体位置正确\n", r.Format, r.Checked)
+			continue
+		}
+		failed = append(failed, r.Format)
+		if r.Err != nil {
+			fmt.Printf("  ✗ %-14s %v\n", r.Format, r.Err)
+			continue
+		}
+		fmt.Printf("  ✗ %-14s 丢失 %d，方块改变 %d（共 %d）\n", r.Format, len(r.Missing), len(r.BlockChanged), r.Checked)
+		if r.SizeChanged {
+			fmt.Println("      结构尺寸改变")
+		}
+		for i, pos := range r.Missing {
+			if i == maxListed {
+				fmt.Printf("      ... 还有 %d 个\n", len(r.Missing)-maxListed)
+				break
+			}
+			fmt.Printf("      丢失: (%d, %d, %d)\n", pos.X(), pos.Y(), pos.Z())
+		}
+		for i, pos := range r.BlockChanged {
+			if i == maxListed {
+				fmt.Printf("      ... 还有 %d 个\n", len(r.BlockChanged)-maxListed)
+				break
+			}
+			fmt.Printf("      方块改变: (%d, %d, %d)\n", pos.X(), pos.Y(), pos.Z())
+		}
+	}
+	fmt.Println(line)
+
+	if len(failed) > 0 {
+		return fmt.Errorf("%d 个格式未通过: %s", len(failed), strings.Join(failed, ", "))
+	}
+	return nil
+}
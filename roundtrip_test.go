@@ -0,0 +1,76 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	bwo_define "github.com/TriM-Organization/bedrock-world-operator/define"
+	wsstructure "github.com/Yeah114/WaterStructure/structure"
+)
+
+// roundTripFixture 带有箱子和告示牌的 3 × 2 × 3 测试结构
+var roundTripFixture = filepath.Join("testdata", "chest_room.mcstructure")
+
+// TestRoundTrip 把测试结构转换为每种支持的格式再读回，检查尺寸不变、方块实体仍位于相同的方块上
+func TestRoundTrip(t *testing.T) {
+	src, err := readStructureSnapshot(roundTripFixture)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(src.BlockEntities) != 2 {
+		t.Fatalf("测试结构应有 2 个方块实体，读到 %d 个", len(src.BlockEntities))
+	}
+
+	for _, format := range getSupportedFormats() {
+		t.Run(format, func(t *testing.T) {
+			destPath := filepath.Join(t.TempDir(), "chest_room."+strings.ToLower(format))
+			if err := convertStructure(roundTripFixture, format, destPath, false, worldPlacement{Dimension: bwo_define.DimensionIDOverworld}); err != nil {
+				t.Fatalf("转换失败: %v", err)
+			}
+			dst, err := readStructureSnapshot(destPath)
+			if err != nil {
+				t.Fatalf("读回失败: %v", err)
+			}
+			r := compareSnapshots(format, src, dst)
+			if r.SizeChanged {
+				t.Errorf("结构尺寸改变: %v → %v", src.Size, dst.Size)
+			}
+			if len(r.Missing) > 0 || len(r.BlockChanged) > 0 {
+				t.Errorf("丢失的方块实体 %v，方块改变 %v", r.Missing, r.BlockChanged)
+			}
+		})
+	}
+}
+
+// TestRoundTripBackToMCStructure 经过其他格式转换回 MCStructure 后，方块实体仍位于相同的方块上
+func TestRoundTripBackToMCStructure(t *testing.T) {
+	src, err := readStructureSnapshot(roundTripFixture)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, format := range getSupportedFormats() {
+		if format == wsstructure.NameMCStructure {
+			continue
+		}
+		t.Run(format, func(t *testing.T) {
+			dir := t.TempDir()
+			midPath := filepath.Join(dir, "chest_room."+strings.ToLower(format))
+			backPath := filepath.Join(dir, "back.mcstructure")
+			placement := worldPlacement{Dimension: bwo_define.DimensionIDOverworld}
+			if err := convertStructure(roundTripFixture, format, midPath, false, placement); err != nil {
+				t.Fatalf("转换为 %s 失败: %v", format, err)
+			}
+			if err := convertStructure(midPath, wsstructure.NameMCStructure, backPath, false, placement); err != nil {
+				t.Fatalf("转换回 MCStructure 失败: %v", err)
+			}
+			back, err := readStructureSnapshot(backPath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if r := compareSnapshots(format, src, back); !r.ok() {
+				t.Errorf("尺寸改变 %v，丢失 %v，方块改变 %v", r.SizeChanged, r.Missing, r.BlockChanged)
+			}
+		})
+	}
+}
@@ -0,0 +1,220 @@
+package main
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+
+	bwo_define "github.com/TriM-Organization/bedrock-world-operator/define"
+	"github.com/TriM-Organization/bedrock-world-operator/world"
+	"github.com/sandertv/gophertunnel/minecraft/nbt"
+
+	wsdefine "github.com/Yeah114/WaterStructure/define"
+	"github.com/Yeah114/blocks"
+	"github.com/Yeah114/blocks/block_entity"
+)
+
+// schematicFormat MCEdit/Schematica .schematic 的结构格式名称
+const schematicFormat = "Schematic"
+
+// schematicFile 解码后的 MCEdit .schematic 文件（gzip 压缩的大端序 NBT）
+type schematicFile struct {
+	rootName              string
+	root                  map[string]any
+	width, height, length int
+}
+
+// readSchematicFile 读取 .schematic 文件
+func readSchematicFile(r io.Reader) (*schematicFile, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("解压 schematic 失败: %w", err)
+	}
+	defer gz.Close()
+	data, err := io.ReadAll(gz)
+	if err != nil {
+		return nil, fmt.Errorf("解压 schematic 失败: %w", err)
+	}
+	var root map[string]any
+	if err := nbt.UnmarshalEncoding(data, &root, nbt.BigEndian); err != nil {
+		return nil, fmt.Errorf("解析 schematic 失败: %w", err)
+	}
+	width, _ := root["Width"].(int16)
+	height, _ := root["Height"].(int16)
+	length, _ := root["Length"].(int16)
+	s := &schematicFile{rootName: nbtRootName(data), root: root, width: int(uint16(width)), height: int(uint16(height)), length: int(uint16(length))}
+	if volume := s.width * s.height * s.length; len(nbtByteArray(root["Blocks"])) != volume || len(nbtByteArray(root["Data"])) != volume {
+		return nil, fmt.Errorf("schematic 的 Blocks/Data 长度与尺寸 %dx%dx%d 不符", s.width, s.height, s.length)
+	}
+	return s, nil
+}
+
+// write 把 schematic 重新压缩写入文件，覆盖原内容
+func (s *schematicFile) write(f *os.File) error {
+	data, err := nbt.MarshalEncoding(s.root, nbt.BigEndian)
+	if err != nil {
+		return fmt.Errorf("编码 schematic 失败: %w", err)
+	}
+	return writeGzipNBT(f, withNBTRootName(data, s.rootName))
+}
+
+// index 返回 schematic 内坐标在 Blocks/Data 中的下标（YZX 顺序）
+func (s *schematicFile) index(x, y, z int) int {
+	return (y*s.length+z)*s.width + x
+}
+
+// blockIDs 返回合并 AddBlocks（或旧版 Schematica 的 Add）后的 12 位方块 ID
+func (s *schematicFile) blockIDs() []uint16 {
+	add := nbtByteArray(s.root["AddBlocks"])
+	if add == nil {
+		add = nbtByteArray(s.root["Add"])
+	}
+	return blocks.SchematicBlockIDs(nbtByteArray(s.root["Blocks"]), add)
+}
+
+// idMapping 返回 schematic 自带的方块 ID 表（BlockIDs 或 SchematicaMapping），没有时返回 nil
+func (s *schematicFile) idMapping() *blocks.SchematicIDMapping {
+	return blocks.SchematicIDMappingFromNBT(s.root)
+}
+
+// applySchematicBlocks 按 12 位方块 ID 和 schematic 自带的 ID 表重新写入临时世界中 start 起的方块：
+// 结构读取器只处理 8 位 ID 和内置 ID 表，AddBlocks 中的方块和自定义 ID 会被读成错误的方块。
+// 返回被改正的方块数量
+func applySchematicBlocks(s *schematicFile, w *world.BedrockWorld, start wsdefine.BlockPos) (int, error) {
+	mapping := s.idMapping()
+	ids := s.blockIDs()
+	data := nbtByteArray(s.root["Data"])
+	runtimeIDs := map[blocks.SchematicID]uint32{}
+
+	editor := newChunkEditor(w, bwo_define.DimensionIDOverworld)
+	fixed := 0
+	for y := 0; y < s.height; y++ {
+		for z := 0; z < s.length; z++ {
+			for x := 0; x < s.width; x++ {
+				i := s.index(x, y, z)
+				id := blocks.SchematicID{Block: ids[i], Data: data[i] & 0xF}
+				runtimeID, ok := runtimeIDs[id]
+				if !ok {
+					if mapping != nil {
+						runtimeID = mapping.RuntimeID(id)
+					} else {
+						runtimeID = blocks.SchematicIDToRuntimeID(id)
+					}
+					runtimeIDs[id] = runtimeID
+				}
+				wx, wy, wz := start.X()+int32(x), start.Y()+int32(y), start.Z()+int32(z)
+				current, err := editor.block(wx, wy, wz, 0)
+				if err != nil {
+					return fixed, err
+				}
+				if current == runtimeID {
+					continue
+				}
+				if err := editor.setBlock(wx, wy, wz, 0, runtimeID); err != nil {
+					return fixed, err
+				}
+				fixed++
+			}
+		}
+	}
+	return fixed, editor.flush()
+}
+
+// rewriteSchematicBlocks 用世界 start~end 中的方块重新生成导出的 schematic 的 Blocks/Data/AddBlocks：
+// 基岩版状态没有对应数据值的方块使用同种方块中状态最接近的数据值，mapping 不为空时按其 ID 表写出方块 ID
+// 并一同写入 BlockIDs，ID 超过 255 时写入 AddBlocks。javaBlocks 中头颅和旗帜的类型、旋转和底色写入
+// 旧版方块实体的 SkullType/Rot/Base。返回无法精确表示的方块数量
+func rewriteSchematicBlocks(f *os.File, w *world.BedrockWorld, start, end wsdefine.BlockPos, mapping *blocks.SchematicIDMapping, javaBlocks javaBlockOverrides) (int, error) {
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return 0, err
+	}
+	s, err := readSchematicFile(f)
+	if err != nil {
+		return 0, err
+	}
+	size := [3]int{
+		int(maxInt32(start.X(), end.X())-minInt32(start.X(), end.X())) + 1,
+		int(maxInt32(start.Y(), end.Y())-minInt32(start.Y(), end.Y())) + 1,
+		int(maxInt32(start.Z(), end.Z())-minInt32(start.Z(), end.Z())) + 1,
+	}
+	if size != [3]int{s.width, s.height, s.length} {
+		return 0, fmt.Errorf("导出的 schematic 尺寸 %dx%dx%d 与选区 %dx%dx%d 不符", s.width, s.height, s.length, size[0], size[1], size[2])
+	}
+	origin := [3]int32{minInt32(start.X(), end.X()), minInt32(start.Y(), end.Y()), minInt32(start.Z(), end.Z())}
+
+	type schematicLookup struct {
+		id    blocks.SchematicID
+		exact bool
+	}
+	lookups := map[uint32]schematicLookup{}
+	ids := make([]uint16, s.width*s.height*s.length)
+	data := make([]byte, len(ids))
+	editor := newChunkEditor(w, bwo_define.DimensionIDOverworld)
+	inexact := 0
+	for y := 0; y < s.height; y++ {
+		for z := 0; z < s.length; z++ {
+			for x := 0; x < s.width; x++ {
+				runtimeID, err := editor.block(origin[0]+int32(x), origin[1]+int32(y), origin[2]+int32(z), 0)
+				if err != nil {
+					return inexact, err
+				}
+				l, ok := lookups[runtimeID]
+				if !ok {
+					var found bool
+					if mapping != nil {
+						l.id, l.exact, found = mapping.SchematicID(runtimeID)
+					} else {
+						l.id, l.exact, found = blocks.RuntimeIDToSchematicID(runtimeID)
+					}
+					if !found {
+						l = schematicLookup{}
+					}
+					lookups[runtimeID] = l
+				}
+				if !l.exact {
+					inexact++
+				}
+				i := s.index(x, y, z)
+				ids[i], data[i] = l.id.Block, l.id.Data
+			}
+		}
+	}
+
+	blockBytes, addBlocks := blocks.SplitSchematicBlockIDs(ids)
+	s.root["Blocks"] = byteArrayNBT(blockBytes)
+	s.root["Data"] = byteArrayNBT(data)
+	delete(s.root, "Add")
+	delete(s.root, "AddBlocks")
+	if addBlocks != nil {
+		s.root["AddBlocks"] = byteArrayNBT(addBlocks)
+	}
+	if mapping != nil {
+		s.root["BlockIDs"] = mapping.BlockIDsNBT()
+	}
+	tileEntities, _ := s.root["TileEntities"].([]any)
+	for _, e := range tileEntities {
+		m, ok := e.(map[string]any)
+		if !ok {
+			continue
+		}
+		if x, y, z, ok := nbtBlockPos(m); ok {
+			for k, v := range block_entity.LegacyJavaBlockNBT(javaBlocks[[3]int32{origin[0] + x, origin[1] + y, origin[2] + z}]) {
+				m[k] = v
+			}
+		}
+	}
+	return inexact, s.write(f)
+}
+
+// finishSchematicExport 导出为 schematic 后按世界中的方块改写方块 ID，并提示无法精确表示的方块数量
+func finishSchematicExport(f *os.File, w *world.BedrockWorld, start, end wsdefine.BlockPos, mapping *blocks.SchematicIDMapping, javaBlocks javaBlockOverrides) error {
+	inexact, err := rewriteSchematicBlocks(f, w, start, end, mapping, javaBlocks)
+	if err != nil {
+		return fmt.Errorf("写入 schematic 方块 ID 失败: %w", err)
+	}
+	if inexact > 0 {
+		fmt.Printf("%d 个方块的状态无法用 schematic 数据值精确表示，已使用最接近的数据值\n", inexact)
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/sandertv/gophertunnel/minecraft/nbt"
+)
+
+func TestReadSchematicFile(t *testing.T) {
+	// 2 × 1 × 2，第二个方块的 ID 为 0x123
+	f := writeTestNBTFile(t, map[string]any{
+		"Width": int16(2), "Height": int16(1), "Length": int16(2),
+		"Materials": "Alpha",
+		"Blocks":    byteArrayNBT([]byte{1, 0x23, 3, 4}),
+		"Data":      byteArrayNBT([]byte{0, 5, 0, 0}),
+		"AddBlocks": byteArrayNBT([]byte{0x01, 0x00}),
+	}, "Schematic")
+
+	s, err := readSchematicFile(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.rootName != "Schematic" {
+		t.Errorf("根标签名称为 %q", s.rootName)
+	}
+	if s.width != 2 || s.height != 1 || s.length != 2 {
+		t.Errorf("尺寸为 %dx%dx%d", s.width, s.height, s.length)
+	}
+	if got, want := s.blockIDs(), []uint16{1, 0x123, 3, 4}; !reflect.DeepEqual(got, want) {
+		t.Errorf("blockIDs() = %v，应为 %v", got, want)
+	}
+	if i := s.index(1, 0, 1); i != 3 {
+		t.Errorf("index(1, 0, 1) = %d，应为 3", i)
+	}
+
+	// 写回后根标签名称和数组类型保持不变
+	if _, err := f.Seek(0, 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.write(f); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.Seek(0, 0); err != nil {
+		t.Fatal(err)
+	}
+	again, err := readSchematicFile(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again.rootName != "Schematic" || !reflect.DeepEqual(again.blockIDs(), s.blockIDs()) {
+		t.Errorf("写回后根标签名称为 %q，方块 ID 为 %v", again.rootName, again.blockIDs())
+	}
+}
+
+func TestReadSchematicFileSizeMismatch(t *testing.T) {
+	f := writeTestNBTFile(t, map[string]any{
+		"Width": int16(2), "Height": int16(2), "Length": int16(2),
+		"Blocks": byteArrayNBT(make([]byte, 4)),
+		"Data":   byteArrayNBT(make([]byte, 4)),
+	}, "Schematic")
+	if _, err := readSchematicFile(f); err == nil {
+		t.Error("Blocks 长度与尺寸不符时应返回错误")
+	}
+}
+
+func TestNBTRootName(t *testing.T) {
+	data, err := nbt.MarshalEncoding(map[string]any{"a": int32(1)}, nbt.BigEndian)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if name := nbtRootName(data); name != "" {
+		t.Errorf("MarshalEncoding 写入了根标签名称 %q", name)
+	}
+	named := withNBTRootName(data, "Schematic")
+	if name := nbtRootName(named); name != "Schematic" {
+		t.Errorf("nbtRootName = %q，应为 Schematic", name)
+	}
+	var root map[string]any
+	if err := nbt.UnmarshalEncoding(named, &root, nbt.BigEndian); err != nil || root["a"] != int32(1) {
+		t.Errorf("带名称的 NBT 无法解码: %v %v", root, err)
+	}
+	if again := withNBTRootName(named, "Other"); !bytes.Equal(again, named) {
+		t.Error("已有名称的 NBT 不应被修改")
+	}
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	bwo_define "github.com/TriM-Organization/bedrock-world-operator/define"
+
+	wsstructure "github.com/Yeah114/WaterStructure/structure"
+)
+
+// 结构模板的来源
+const (
+	templateSourceLevelDB   = "LevelDB"
+	templateSourceDirectory = "structures/"
+)
+
+// worldStructureTemplate 世界中结构方块保存的结构模板
+type worldStructureTemplate struct {
+	Name   string
+	Source string
+	Data   []byte // .mcstructure 格式的小端 NBT
+}
+
+// loadWorldStructureTemplates 读取世界中的全部结构模板
+// 新版基岩版把结构保存在 LevelDB 的 structuretemplate_ 键中，旧版或手动放入的结构在 structures/ 目录
+// 数据库已加密时只读取 structures/ 目录并给出警告
+func loadWorldStructureTemplates(worldDir string) ([]worldStructureTemplate, error) {
+	var templates []worldStructureTemplate
+
+	db, err := openWorldDB(worldDir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "警告: 无法读取 LevelDB 中的结构模板: %v\n", err)
+	} else {
+		prefix := []byte(structureTemplatePrefix)
+		err = db.forEach(prefix, func(key, value []byte) error {
+			templates = append(templates, worldStructureTemplate{
+				Name:   string(key[len(prefix):]),
+				Source: templateSourceLevelDB,
+				Data:   append([]byte(nil), value...),
+			})
+			return nil
+		})
+		db.Close()
+		if err != nil {
+			return nil, fmt.Errorf("遍历数据库失败: %w", err)
+		}
+	}
+
+	names, err := listWorldStructures(worldDir)
+	if err != nil {
+		return nil, fmt.Errorf("读取结构列表失败: %w", err)
+	}
+	for _, name := range names {
+		data, err := readWorldStructure(worldDir, name)
+		if err != nil {
+			return nil, fmt.Errorf("读取结构 %s 失败: %w", name, err)
+		}
+		templates = append(templates, worldStructureTemplate{
+			Name:   name,
+			Source: templateSourceDirectory,
+			Data:   data,
+		})
+	}
+
+	sort.SliceStable(templates, func(i, j int) bool { return templates[i].Name < templates[j].Name })
+	return templates, nil
+}
+
+// structureTemplateFileName 将结构名称（如 mystructure:house）转换为可用的文件名
+func structureTemplateFileName(name string) string {
+	name = strings.Map(func(r rune) rune {
+		switch r {
+		case ':', '/', '\\', '*', '?', '"', '<', '>', '|':
+			return '_'
+		}
+		return r
+	}, name)
+	if name == "" {
+		name = "structure"
+	}
+	return name
+}
+
+// structureTemplateOutputPath 返回结构模板在输出目录中的文件路径，targetFormat 为空时保持 .mcstructure
+func structureTemplateOutputPath(dir string, t worldStructureTemplate, targetFormat string) string {
+	ext := ".mcstructure"
+	if targetFormat != "" {
+		ext = "." + strings.ToLower(targetFormat)
+	}
+	return filepath.Join(dir, structureTemplateFileName(t.Name)+ext)
+}
+
+// exportStructureTemplate 导出单个结构模板
+// targetFormat 为空时原样写出 .mcstructure，否则转换为 StructureNamePool 中的格式
+func exportStructureTemplate(t worldStructureTemplate, targetFormat, outputPath string, useFast bool) error {
+	if err := os.MkdirAll(filepath.Dir(outputPath), 0755); err != nil {
+		return fmt.Errorf("无法创建输出目录: %w", err)
+	}
+	if targetFormat == "" {
+		if err := os.WriteFile(outputPath, t.Data, 0644); err != nil {
+			return fmt.Errorf("写入文件失败: %w", err)
+		}
+		return nil
+	}
+
+	// 转换需要从文件读取，先把模板写到以结构名称命名的临时文件（生成 MCWorld 时用作世界名称）
+	tmpDir, err := os.MkdirTemp("", "fatalder-template-*")
+	if err != nil {
+		return fmt.Errorf("无法创建临时目录: %w", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	srcPath := structureTemplateOutputPath(tmpDir, t, "")
+	if err := os.WriteFile(srcPath, t.Data, 0644); err != nil {
+		return fmt.Errorf("写入临时文件失败: %w", err)
+	}
+	return convertStructure(srcPath, targetFormat, outputPath, useFast, worldPlacement{Dimension: bwo_define.DimensionIDOverworld})
+}
+
+// exportStructureTemplates 将多个结构模板导出到目录，单个结构失败不影响其余结构
+func exportStructureTemplates(templates []worldStructureTemplate, targetFormat, outputDir string, useFast bool) (int, error) {
+	if targetFormat != "" {
+		if _, ok := wsstructure.StructureNamePool[targetFormat]; !ok {
+			return 0, fmt.Errorf("不支持的目标格式: %s\n使用 'list' 命令查看支持的格式", targetFormat)
+		}
+	}
+
+	exported := 0
+	used := make(map[string]bool)
+	var failed []string
+	for i, t := range templates {
+		outputPath := structureTemplateOutputPath(outputDir, t, targetFormat)
+		// LevelDB 和 structures/ 中可能存在同名结构
+		if used[outputPath] {
+			ext := filepath.Ext(outputPath)
+			outputPath = strings.TrimSuffix(outputPath, ext) + "_" + strings.TrimSuffix(t.Source, "/") + ext
+		}
+		used[outputPath] = true
+
+		fmt.Printf("[%d/%d] %s (%s)\n", i+1, len(templates), t.Name, t.Source)
+		if err := exportStructureTemplate(t, targetFormat, outputPath, useFast); err != nil {
+			fmt.Fprintf(os.Stderr, "  导出失败: %v\n", err)
+			failed = append(failed, t.Name)
+			continue
+		}
+		fmt.Printf("  → %s\n", outputPath)
+		exported++
+	}
+	if len(failed) > 0 {
+		return exported, fmt.Errorf("%d 个结构导出失败: %s", len(failed), strings.Join(failed, ", "))
+	}
+	return exported, nil
+}
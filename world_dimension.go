@@ -0,0 +1,146 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/TriM-Organization/bedrock-world-operator/chunk"
+	bwo_define "github.com/TriM-Organization/bedrock-world-operator/define"
+	"github.com/TriM-Organization/bedrock-world-operator/world"
+
+	wsdefine "github.com/Yeah114/WaterStructure/define"
+	"github.com/Yeah114/blocks"
+)
+
+// parseDimension 解析维度名称（overworld/nether/end，也接受 0/1/2 和中文名称）
+func parseDimension(s string) (bwo_define.Dimension, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "", "overworld", "0", "主世界":
+		return bwo_define.DimensionIDOverworld, nil
+	case "nether", "the_nether", "1", "下界":
+		return bwo_define.DimensionIDNether, nil
+	case "end", "the_end", "2", "末地":
+		return bwo_define.DimensionIDEnd, nil
+	}
+	return 0, fmt.Errorf("未知的维度: %s（可选: overworld, nether, end）", s)
+}
+
+// subChunkRegion 子区块坐标表示的区域（闭区间）
+type subChunkRegion struct {
+	Min bwo_define.SubChunkPos
+	Max bwo_define.SubChunkPos
+}
+
+// subChunkRegionOf 返回包含两个方块坐标之间区域的子区块范围
+func subChunkRegionOf(start, end wsdefine.BlockPos) subChunkRegion {
+	return subChunkRegion{
+		Min: bwo_define.SubChunkPos{
+			minInt32(start.X(), end.X()) >> 4,
+			minInt32(start.Y(), end.Y()) >> 4,
+			minInt32(start.Z(), end.Z()) >> 4,
+		},
+		Max: bwo_define.SubChunkPos{
+			maxInt32(start.X(), end.X()) >> 4,
+			maxInt32(start.Y(), end.Y()) >> 4,
+			maxInt32(start.Z(), end.Z()) >> 4,
+		},
+	}
+}
+
+// copyDimensionRegion 将 src 世界 srcDim 维度中指定区域的子区块和方块实体复制到 dst 世界的 dstDim 维度
+// 子区块按绝对 Y 序号复制，坐标保持不变；方块实体按区块整体复制
+func copyDimensionRegion(src *world.BedrockWorld, srcDim bwo_define.Dimension, dst *world.BedrockWorld, dstDim bwo_define.Dimension, region subChunkRegion) (int, error) {
+	srcRange, dstRange := srcDim.Range(), dstDim.Range()
+	minY := maxInt32(region.Min[1], int32(srcRange[0])>>4)
+	maxY := minInt32(region.Max[1], int32(srcRange[1])>>4)
+
+	copied := 0
+	for x := region.Min[0]; x <= region.Max[0]; x++ {
+		for z := region.Min[2]; z <= region.Max[2]; z++ {
+			chunkPos := bwo_define.ChunkPos{x, z}
+			for y := minY; y <= maxY; y++ {
+				pos := bwo_define.SubChunkPos{x, y, z}
+				sub := src.LoadSubChunk(srcDim, pos)
+				if sub == nil || sub.Empty() {
+					continue
+				}
+				if y < int32(dstRange[0])>>4 || y > int32(dstRange[1])>>4 {
+					return copied, fmt.Errorf("子区块 %v 超出%s的高度范围 %v", pos, dimensionName(dstDim), dstRange)
+				}
+				if err := dst.SaveSubChunk(dstDim, pos, sub); err != nil {
+					return copied, fmt.Errorf("写入子区块 %v 失败: %w", pos, err)
+				}
+				copied++
+			}
+
+			// 直接复制原始数据，避免 LoadNBT 改写方块实体 id
+			if payload := src.LoadNBTPayloadOnly(srcDim, chunkPos); len(payload) > 0 {
+				if err := dst.SaveNBTPayloadOnly(dstDim, chunkPos, payload); err != nil {
+					return copied, fmt.Errorf("写入区块 %v 的方块实体失败: %w", chunkPos, err)
+				}
+			}
+		}
+	}
+	return copied, nil
+}
+
+// projectDimensionToOverworld 将指定维度的区域复制到临时世界的主世界中
+// 结构导出只能读取主世界，导出下界/末地时先投影到临时世界，坐标保持不变
+func projectDimensionToOverworld(src *world.BedrockWorld, dim bwo_define.Dimension, start, end wsdefine.BlockPos) (*world.BedrockWorld, func(), error) {
+	tempDir, err := os.MkdirTemp("", "fatalder-dimension-*")
+	if err != nil {
+		return nil, nil, err
+	}
+	projected, err := world.Open(tempDir, nil)
+	if err != nil {
+		_ = os.RemoveAll(tempDir)
+		return nil, nil, fmt.Errorf("无法创建临时世界: %w", err)
+	}
+	cleanup := func() {
+		_ = projected.Close()
+		_ = os.RemoveAll(tempDir)
+	}
+
+	copied, err := copyDimensionRegion(src, dim, projected, bwo_define.DimensionIDOverworld, subChunkRegionOf(start, end))
+	if err != nil {
+		cleanup()
+		return nil, nil, err
+	}
+	fmt.Printf("已读取%s的 %d 个子区块\n", dimensionName(dim), copied)
+	return projected, cleanup, nil
+}
+
+// checkDimensionHeight 检查从 startY 开始、高度为 height 的结构能否放入维度
+func checkDimensionHeight(dim bwo_define.Dimension, startY int32, height int) error {
+	r := dim.Range()
+	endY := startY + int32(height) - 1
+	if startY < int32(r[0]) || endY > int32(r[1]) {
+		return fmt.Errorf("结构的 Y 范围 %d ~ %d 超出%s的高度范围 %d ~ %d", startY, endY, dimensionName(dim), r[0], r[1])
+	}
+	return nil
+}
+
+// shiftChunkToDimension 将区块整体上下移动 yShift 格（必须是 16 的倍数）并转换为目标维度的高度范围
+// 超出目标维度范围的非空子区块视为错误
+func shiftChunkToDimension(c *chunk.Chunk, dim bwo_define.Dimension, yShift int32) (*chunk.Chunk, error) {
+	if yShift%16 != 0 {
+		return nil, fmt.Errorf("区块只能按子区块移动，Y 偏移 %d 不是 16 的倍数", yShift)
+	}
+	r := dim.Range()
+	if c.Range() == r && yShift == 0 {
+		return c, nil
+	}
+	shifted := chunk.NewChunk(blocks.AIR_RUNTIMEID, r)
+	for index, sub := range c.Sub() {
+		if sub == nil || sub.Empty() {
+			continue
+		}
+		y := int32(c.SubY(int16(index))) + yShift
+		if y < int32(r[0]) || y > int32(r[1]) {
+			return nil, fmt.Errorf("Y 坐标 %d 处的子区块超出%s的高度范围 %v", y, dimensionName(dim), r)
+		}
+		shifted.SetSubChunk(sub, shifted.SubIndex(int16(y)))
+	}
+	return shifted, nil
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"testing"
+
+	bwo_define "github.com/TriM-Organization/bedrock-world-operator/define"
+)
+
+func TestParseDimension(t *testing.T) {
+	cases := map[string]bwo_define.Dimension{
+		"":           bwo_define.DimensionIDOverworld,
+		"overworld":  bwo_define.DimensionIDOverworld,
+		"0":          bwo_define.DimensionIDOverworld,
+		"主世界":        bwo_define.DimensionIDOverworld,
+		"Nether":     bwo_define.DimensionIDNether,
+		"the_nether": bwo_define.DimensionIDNether,
+		" 1 ":        bwo_define.DimensionIDNether,
+		"下界":         bwo_define.DimensionIDNether,
+		"END":        bwo_define.DimensionIDEnd,
+		"the_end":    bwo_define.DimensionIDEnd,
+		"2":          bwo_define.DimensionIDEnd,
+		"末地":         bwo_define.DimensionIDEnd,
+	}
+	for s, want := range cases {
+		got, err := parseDimension(s)
+		if err != nil || got != want {
+			t.Errorf("parseDimension(%q) = %v, %v，应为 %v", s, got, err, want)
+		}
+	}
+	for _, s := range []string{"3", "nether2", "天堂"} {
+		if _, err := parseDimension(s); err == nil {
+			t.Errorf("parseDimension(%q) 应返回错误", s)
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/TriM-Organization/bedrock-world-operator/chunk"
+	bwo_define "github.com/TriM-Organization/bedrock-world-operator/define"
+	"github.com/TriM-Organization/bedrock-world-operator/world"
+
+	"github.com/Yeah114/blocks"
+)
+
+// chunkEditor 按区块缓存世界数据，用于批量读写方块
+// 修改过的区块在 flush 时统一写回世界
+type chunkEditor struct {
+	world     *world.BedrockWorld
+	dimension bwo_define.Dimension
+	chunks    map[bwo_define.ChunkPos]*chunk.Chunk
+	dirty     map[bwo_define.ChunkPos]bool
+}
+
+// newChunkEditor 创建区块编辑器
+func newChunkEditor(bedrockWorld *world.BedrockWorld, dimension bwo_define.Dimension) *chunkEditor {
+	return &chunkEditor{
+		world:     bedrockWorld,
+		dimension: dimension,
+		chunks:    make(map[bwo_define.ChunkPos]*chunk.Chunk),
+		dirty:     make(map[bwo_define.ChunkPos]bool),
+	}
+}
+
+// chunkAt 返回方块坐标所在的区块，不存在时创建空区块
+func (e *chunkEditor) chunkAt(x, z int32) (*chunk.Chunk, bwo_define.ChunkPos, error) {
+	pos := bwo_define.ChunkPos{x >> 4, z >> 4}
+	if c, ok := e.chunks[pos]; ok {
+		return c, pos, nil
+	}
+	c, exists, err := e.world.LoadChunk(e.dimension, pos)
+	if err != nil {
+		return nil, pos, fmt.Errorf("读取区块 %v 失败: %w", pos, err)
+	}
+	if !exists || c == nil {
+		c = chunk.NewChunk(blocks.AIR_RUNTIMEID, e.dimension.Range())
+	}
+	e.chunks[pos] = c
+	return c, pos, nil
+}
+
+// inRange 判断 Y 坐标是否在维度高度范围内
+func (e *chunkEditor) inRange(y int32) bool {
+	r := e.dimension.Range()
+	return y >= int32(r[0]) && y <= int32(r[1])
+}
+
+// block 读取指定位置、指定层的方块
+func (e *chunkEditor) block(x, y, z int32, layer uint8) (uint32, error) {
+	if !e.inRange(y) {
+		return blocks.AIR_RUNTIMEID, nil
+	}
+	c, _, err := e.chunkAt(x, z)
+	if err != nil {
+		return blocks.AIR_RUNTIMEID, err
+	}
+	return c.Block(uint8(x&15), int16(y), uint8(z&15), layer), nil
+}
+
+// setBlock 设置指定位置、指定层的方块
+func (e *chunkEditor) setBlock(x, y, z int32, layer uint8, runtimeID uint32) error {
+	if !e.inRange(y) {
+		return fmt.Errorf("Y 坐标 %d 超出%s的高度范围 %v", y, e.dimension, e.dimension.Range())
+	}
+	c, pos, err := e.chunkAt(x, z)
+	if err != nil {
+		return err
+	}
+	c.SetBlock(uint8(x&15), int16(y), uint8(z&15), layer, runtimeID)
+	e.dirty[pos] = true
+	return nil
+}
+
+// flush 将修改过的区块写回世界
+func (e *chunkEditor) flush() error {
+	for pos := range e.dirty {
+		c := e.chunks[pos]
+		c.Compact()
+		if err := e.world.SaveChunk(e.dimension, pos, c); err != nil {
+			return fmt.Errorf("保存区块 %v 失败: %w", pos, err)
+		}
+	}
+	e.dirty = make(map[bwo_define.ChunkPos]bool)
+	return nil
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	bwo_define "github.com/TriM-Organization/bedrock-world-operator/define"
+	"github.com/TriM-Organization/bedrock-world-operator/world"
+
+	wsdefine "github.com/Yeah114/WaterStructure/define"
+	"github.com/Yeah114/blocks"
+)
+
+// structureBaseY 结构读取器（GetChunks/GetChunksNBT）返回的数据中结构底部所在的 Y 坐标
+// 区块数据和 NBT 坐标都以此为基准，写入其他高度时需要换算
+const structureBaseY = int32(-64)
+
+// worldPlacement 结构写入 MCWorld 时的放置方式，以及输出使用的方块调色板
+type worldPlacement struct {
+	Dimension bwo_define.Dimension // 从 MCWorld 读取或写入 MCWorld 的维度
+	Origin    *wsdefine.BlockPos   // 结构最小角落的方块坐标，nil 表示维度底部 (0, 最低高度, 0)
+	UseOffset bool                 // 按源结构记录的偏移（GetOffsetPos）放置
+	Level     levelSettings        // 生成 MCWorld 的 level.dat 设置
+	Palette   *blocks.Palette      // 输出 MCStructure/MCWorld 使用的方块调色板，nil 表示内置的网易版调色板
+}
+
+// origin 返回结构最小角落在世界中的方块坐标
+// 默认放在维度底部；生成超平坦世界时放在地面上
+func (p worldPlacement) origin(offset wsdefine.Offset) wsdefine.BlockPos {
+	switch {
+	case p.Origin != nil:
+		return *p.Origin
+	case p.UseOffset:
+		return wsdefine.BlockPos{offset.X(), offset.Y(), offset.Z()}
+	default:
+		y := int32(p.Dimension.Range()[0])
+		// 超平坦主世界中放在地面上
+		if p.Level.FlatLayers != "" && p.Dimension == bwo_define.DimensionIDOverworld {
+			if layers, err := parseFlatWorldLayers(p.Level.FlatLayers); err == nil {
+				y += flatWorldHeight(layers)
+			}
+		}
+		return wsdefine.BlockPos{0, y, 0}
+	}
+}
+
+// parseBlockPos 解析 "x,y,z" 或 "x y z" 格式的方块坐标
+func parseBlockPos(s string) (wsdefine.BlockPos, error) {
+	fields := strings.FieldsFunc(s, func(r rune) bool { return r == ',' || r == ' ' })
+	if len(fields) != 3 {
+		return wsdefine.BlockPos{}, fmt.Errorf("坐标格式不正确，需要 x,y,z: %s", s)
+	}
+	var pos wsdefine.BlockPos
+	for i, f := range fields {
+		v, err := strconv.ParseInt(strings.TrimSpace(f), 10, 32)
+		if err != nil {
+			return wsdefine.BlockPos{}, fmt.Errorf("坐标必须是整数: %s", f)
+		}
+		pos[i] = int32(v)
+	}
+	return pos, nil
+}
+
+// placeStructureWorld 将临时世界主世界中 srcStart 处大小为 size 的结构逐方块复制到新世界 worldDir 的指定维度和坐标
+// 放置位置不需要按子区块对齐
+func placeStructureWorld(src *world.BedrockWorld, srcStart wsdefine.BlockPos, size wsdefine.Size, worldDir string, dim bwo_define.Dimension, origin wsdefine.BlockPos) (*world.BedrockWorld, error) {
+	if err := checkDimensionHeight(dim, origin.Y(), int(size.Height)); err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(worldDir, 0755); err != nil {
+		return nil, fmt.Errorf("无法创建世界目录: %w", err)
+	}
+	placed, err := world.Open(worldDir, nil)
+	if err != nil {
+		return nil, fmt.Errorf("无法创建世界: %w", err)
+	}
+	opts := &pasteOptions{Origin: [3]int32{origin.X(), origin.Y(), origin.Z()}, Dimension: dim}
+	if _, _, err := pasteWorldRegion(src, srcStart, size, placed, opts); err != nil {
+		_ = placed.Close()
+		return nil, err
+	}
+	return placed, nil
+}
@@ -0,0 +1,318 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	bwo_define "github.com/TriM-Organization/bedrock-world-operator/define"
+	world_define "github.com/TriM-Organization/bedrock-world-operator/world/define"
+)
+
+// 玩家数据在 LevelDB 中的键
+const (
+	playerServerPrefix = "player_server_" // 多人游戏中的玩家数据
+	playerIDPrefix     = "player_"        // 玩家账号 ID → player_server_ 键的映射
+)
+
+// worldAudit 世界中的玩家和实体数据
+type worldAudit struct {
+	World    string         `json:"world"`
+	Players  []playerRecord `json:"players"`
+	Entities []entityRecord `json:"entities,omitempty"`
+}
+
+// playerRecord 玩家数据
+type playerRecord struct {
+	Key           string         `json:"key"` // ~local_player 或 player_server_<uuid>
+	MsaID         string         `json:"msa_id,omitempty"`
+	SelfSignedID  string         `json:"self_signed_id,omitempty"`
+	UniqueID      int64          `json:"unique_id"`
+	Dimension     int32          `json:"dimension"`
+	DimensionName string         `json:"dimension_name"`
+	Position      []float32      `json:"position"`
+	Rotation      []float32      `json:"rotation,omitempty"`
+	Spawn         []int32        `json:"spawn,omitempty"`
+	GameMode      *int32         `json:"game_mode,omitempty"`
+	Level         int32          `json:"level"`
+	Health        *float32       `json:"health,omitempty"`
+	Abilities     map[string]any `json:"abilities,omitempty"`
+	Inventory     []ItemInfo     `json:"inventory"`
+	EnderChest    []ItemInfo     `json:"ender_chest"`
+	Armor         []ItemInfo     `json:"armor"`
+	Offhand       []ItemInfo     `json:"offhand"`
+}
+
+// entityRecord 实体数据
+type entityRecord struct {
+	Identifier    string     `json:"identifier"`
+	UniqueID      int64      `json:"unique_id"`
+	Dimension     int32      `json:"dimension"`
+	DimensionName string     `json:"dimension_name"`
+	Position      []float32  `json:"position"`
+	CustomName    string     `json:"custom_name,omitempty"`
+	Items         []ItemInfo `json:"items,omitempty"` // 运输矿车的 Items、驴和羊驼的 ChestItems
+	Armor         []ItemInfo `json:"armor,omitempty"`
+	Mainhand      []ItemInfo `json:"mainhand,omitempty"`
+	Offhand       []ItemInfo `json:"offhand,omitempty"`
+}
+
+// readWorldAudit 读取世界中的所有玩家数据，withEntities 为 true 时同时读取实体
+func readWorldAudit(worldPath string, withEntities bool) (*worldAudit, error) {
+	audit := &worldAudit{World: worldPath, Players: []playerRecord{}}
+	err := readWorldPath(worldPath, func(worldDir string) error {
+		db, err := openWorldDB(worldDir)
+		if err != nil {
+			return err
+		}
+		defer db.Close()
+
+		if audit.Players, err = readPlayers(db); err != nil {
+			return err
+		}
+		if withEntities {
+			if audit.Entities, err = readEntities(db); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return audit, nil
+}
+
+// readPlayers 读取 ~local_player 和 player_server_* 玩家数据
+func readPlayers(db *worldDB) ([]playerRecord, error) {
+	players := []playerRecord{}
+	// player_<id> 记录账号 ID 与 player_server_ 键的对应关系
+	identities := make(map[string]map[string]any)
+
+	err := db.forEach(nil, func(key, value []byte) error {
+		name := string(key)
+		switch {
+		case name == world_define.KeyLocalPlayer || strings.HasPrefix(name, playerServerPrefix):
+			list, err := decodeNBTList(value)
+			if err != nil || len(list) == 0 {
+				fmt.Fprintf(os.Stderr, "警告: 无法解析玩家数据 %s: %v\n", name, err)
+				return nil
+			}
+			players = append(players, parsePlayer(name, list[0]))
+		case strings.HasPrefix(name, playerIDPrefix):
+			if list, err := decodeNBTList(value); err == nil && len(list) > 0 {
+				if serverID, ok := list[0]["ServerId"].(string); ok {
+					identities[serverID] = list[0]
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("遍历数据库失败: %w", err)
+	}
+
+	for i := range players {
+		if identity, ok := identities[players[i].Key]; ok {
+			players[i].MsaID, _ = identity["MsaId"].(string)
+			players[i].SelfSignedID, _ = identity["SelfSignedId"].(string)
+		}
+	}
+	sort.Slice(players, func(i, j int) bool { return players[i].Key < players[j].Key })
+	return players, nil
+}
+
+// parsePlayer 解析单个玩家的 NBT 数据
+func parsePlayer(key string, m map[string]any) playerRecord {
+	p := playerRecord{
+		Key:        key,
+		UniqueID:   nbtInt64(m["UniqueID"]),
+		Dimension:  int32(nbtInt64(m["DimensionId"])),
+		Position:   nbtFloats(m["Pos"]),
+		Rotation:   nbtFloats(m["Rotation"]),
+		Level:      int32(nbtInt64(m["PlayerLevel"])),
+		Inventory:  emptyIfNil(parseItemList(m["Inventory"])),
+		EnderChest: emptyIfNil(parseItemList(m["EnderChestInventory"])),
+		Armor:      emptyIfNil(parseItemList(m["Armor"])),
+		Offhand:    emptyIfNil(parseItemList(m["Offhand"])),
+	}
+	p.DimensionName = dimensionName(bwo_define.Dimension(p.Dimension))
+
+	if _, ok := m["SpawnX"]; ok {
+		p.Spawn = []int32{int32(nbtInt64(m["SpawnX"])), int32(nbtInt64(m["SpawnY"])), int32(nbtInt64(m["SpawnZ"]))}
+	}
+	if v, ok := m["PlayerGameMode"]; ok {
+		mode := int32(nbtInt64(v))
+		p.GameMode = &mode
+	}
+	if abilities, ok := m["abilities"].(map[string]any); ok {
+		p.Abilities = abilities
+	}
+	if attributes, ok := m["Attributes"].([]any); ok {
+		for _, raw := range attributes {
+			attr, ok := raw.(map[string]any)
+			if !ok || attr["Name"] != "minecraft:health" {
+				continue
+			}
+			if current, ok := attr["Current"].(float32); ok {
+				p.Health = &current
+			}
+		}
+	}
+	return p
+}
+
+// readEntities 读取所有实体：新版存档的 actorprefix 键（维度由 digp 索引确定）和旧版存档区块中的实体
+func readEntities(db *worldDB) ([]entityRecord, error) {
+	entities := []entityRecord{}
+	actorPrefix := []byte(world_define.KeyEntity)
+	actorDims := make(map[string]bwo_define.Dimension)
+	actors := make(map[string]map[string]any)
+
+	err := db.forEach(nil, func(key, value []byte) error {
+		if k, ok := parseChunkKey(key); ok {
+			if k.Tag == world_define.KeyEntities {
+				list, _ := decodeNBTList(value)
+				for _, m := range list {
+					entities = append(entities, parseEntity(m, k.Dimension))
+				}
+			}
+			return nil
+		}
+		if dim, _, ok := parseDigpKey(key); ok {
+			// digp 的值是区块内实体 ID 的列表，每个 ID 8 字节，对应 actorprefix 键的后缀
+			for i := 0; i+8 <= len(value); i += 8 {
+				actorDims[string(value[i:i+8])] = dim
+			}
+			return nil
+		}
+		if bytes.HasPrefix(key, actorPrefix) {
+			list, err := decodeNBTList(value)
+			if err != nil || len(list) == 0 {
+				fmt.Fprintf(os.Stderr, "警告: 无法解析实体数据: %v\n", err)
+				return nil
+			}
+			actors[string(key[len(actorPrefix):])] = list[0]
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("遍历数据库失败: %w", err)
+	}
+
+	for id, m := range actors {
+		dim, ok := actorDims[id]
+		if !ok {
+			dim = bwo_define.DimensionIDOverworld
+		}
+		entities = append(entities, parseEntity(m, dim))
+	}
+	sort.Slice(entities, func(i, j int) bool {
+		if entities[i].Identifier != entities[j].Identifier {
+			return entities[i].Identifier < entities[j].Identifier
+		}
+		return entities[i].UniqueID < entities[j].UniqueID
+	})
+	return entities, nil
+}
+
+// parseEntity 解析单个实体的 NBT 数据
+func parseEntity(m map[string]any, dim bwo_define.Dimension) entityRecord {
+	e := entityRecord{
+		UniqueID:      nbtInt64(m["UniqueID"]),
+		Dimension:     int32(dim),
+		DimensionName: dimensionName(dim),
+		Position:      nbtFloats(m["Pos"]),
+		Armor:         parseItemList(m["Armor"]),
+		Mainhand:      parseItemList(m["Mainhand"]),
+		Offhand:       parseItemList(m["Offhand"]),
+	}
+	e.Identifier, _ = m["identifier"].(string)
+	e.CustomName, _ = m["CustomName"].(string)
+	e.Items = append(parseItemList(m["Items"]), parseItemList(m["ChestItems"])...)
+	return e
+}
+
+// nbtInt64 将 NBT 整数转换为 int64，类型不符时返回 0
+func nbtInt64(v any) int64 {
+	switch n := v.(type) {
+	case byte:
+		return int64(n)
+	case int16:
+		return int64(n)
+	case int32:
+		return int64(n)
+	case int64:
+		return n
+	case int:
+		return int64(n)
+	}
+	return 0
+}
+
+// nbtFloats 将 NBT 浮点数列表（如 Pos、Rotation）转换为 []float32
+func nbtFloats(v any) []float32 {
+	list, ok := v.([]any)
+	if !ok {
+		return nil
+	}
+	floats := make([]float32, 0, len(list))
+	for _, item := range list {
+		switch f := item.(type) {
+		case float32:
+			floats = append(floats, f)
+		case float64:
+			floats = append(floats, float32(f))
+		}
+	}
+	return floats
+}
+
+// emptyIfNil 让空物品栏在 JSON 中输出为 [] 而不是 null
+func emptyIfNil(items []ItemInfo) []ItemInfo {
+	if items == nil {
+		return []ItemInfo{}
+	}
+	return items
+}
+
+// exportWorldAudit 将世界中的玩家和实体数据导出为 JSON（只读）
+func exportWorldAudit(worldPath, outputPath string, withEntities bool) error {
+	audit, err := readWorldAudit(worldPath, withEntities)
+	if err != nil {
+		return err
+	}
+
+	if outputPath == "" {
+		base := strings.TrimSuffix(filepath.Clean(worldPath), filepath.Ext(worldPath))
+		outputPath = base + "_players.json"
+	}
+	data, err := json.MarshalIndent(audit, "", "  ")
+	if err != nil {
+		return fmt.Errorf("生成 JSON 失败: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(outputPath), 0755); err != nil {
+		return fmt.Errorf("无法创建输出目录: %w", err)
+	}
+	if err := os.WriteFile(outputPath, data, 0644); err != nil {
+		return fmt.Errorf("写入文件失败: %w", err)
+	}
+
+	fmt.Printf("玩家: %d\n", len(audit.Players))
+	for _, p := range audit.Players {
+		pos := "未知"
+		if len(p.Position) == 3 {
+			pos = fmt.Sprintf("(%.1f, %.1f, %.1f)", p.Position[0], p.Position[1], p.Position[2])
+		}
+		fmt.Printf("  %-40s %s %s  背包 %d  末影箱 %d\n", p.Key, p.DimensionName, pos, len(p.Inventory), len(p.EnderChest))
+	}
+	if withEntities {
+		fmt.Printf("实体: %d\n", len(audit.Entities))
+	}
+	fmt.Printf("已写入: %s\n", outputPath)
+	return nil
+}
@@ -0,0 +1,255 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	bwo_define "github.com/TriM-Organization/bedrock-world-operator/define"
+	"github.com/TriM-Organization/bedrock-world-operator/world"
+	world_define "github.com/TriM-Organization/bedrock-world-operator/world/define"
+	"github.com/TriM-Organization/bedrock-world-operator/world/leveldat"
+	"github.com/df-mc/goleveldb/leveldb"
+	"github.com/df-mc/goleveldb/leveldb/opt"
+	"github.com/df-mc/goleveldb/leveldb/util"
+	"github.com/sandertv/gophertunnel/minecraft/nbt"
+)
+
+// structureTemplatePrefix 结构方块保存到 LevelDB 中的结构模板键前缀
+const structureTemplatePrefix = "structuretemplate_"
+
+// worldDB 以只读方式直接打开世界的 LevelDB
+// bwo 的 LevelDB 接口不支持遍历键，统计区块、实体和结构模板时需要直接遍历
+type worldDB struct {
+	db *leveldb.DB
+}
+
+// openWorldDB 只读打开世界目录下的 db，已加密的存档直接报错
+func openWorldDB(worldDir string) (*worldDB, error) {
+	dbDir := filepath.Join(worldDir, "db")
+	state, _, err := detectDBCrypt(dbDir)
+	if err != nil {
+		return nil, fmt.Errorf("无法读取 db 目录: %w", err)
+	}
+	if state == cryptStateEncrypted || state == cryptStateMixed {
+		return nil, fmt.Errorf("数据库%s，请先解密", state)
+	}
+	db, err := leveldb.OpenFile(dbDir, &opt.Options{ReadOnly: true})
+	if err != nil {
+		return nil, fmt.Errorf("无法打开数据库: %w", err)
+	}
+	return &worldDB{db: db}, nil
+}
+
+// readLevelDat 直接读取世界目录下的 level.dat，不打开数据库，也不会改写任何文件
+// level.dat 不存在时返回默认值
+func readLevelDat(worldDir string) (*leveldat.Data, error) {
+	ldat := &leveldat.Data{}
+	path := filepath.Join(worldDir, "level.dat")
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		ldat.FillDefault()
+		return ldat, nil
+	}
+	raw, err := leveldat.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("无法读取 level.dat: %w", err)
+	}
+	if ver := raw.Ver(); ver != leveldat.Version && ver >= 10 {
+		return nil, fmt.Errorf("不支持的 level.dat 版本: %d", ver)
+	}
+	if err := raw.Unmarshal(ldat); err != nil {
+		return nil, fmt.Errorf("无法解析 level.dat: %w", err)
+	}
+	return ldat, nil
+}
+
+// openWorldReadOnly 以只读方式打开世界，用于读取区块；LevelDB 只读打开，不会改写 db
+// 调用方只能用 Close 关闭，不能调用会改写 level.dat 的 CloseWorld
+func openWorldReadOnly(worldDir string) (*world.BedrockWorld, error) {
+	conf := world.Config{LDBOptions: &opt.Options{ReadOnly: true}}
+	bedrockWorld, err := conf.Open(worldDir, nil)
+	if err != nil {
+		return nil, fmt.Errorf("无法打开世界: %w", err)
+	}
+	return bedrockWorld, nil
+}
+
+// Close 关闭数据库
+func (w *worldDB) Close() error {
+	return w.db.Close()
+}
+
+// forEach 遍历指定前缀的键值对，prefix 为空时遍历全部
+// key 和 value 只在回调内有效，需要保留时应自行复制
+func (w *worldDB) forEach(prefix []byte, fn func(key, value []byte) error) error {
+	var slice *util.Range
+	if len(prefix) > 0 {
+		slice = util.BytesPrefix(prefix)
+	}
+	iter := w.db.NewIterator(slice, nil)
+	defer iter.Release()
+	for iter.Next() {
+		if err := fn(iter.Key(), iter.Value()); err != nil {
+			return err
+		}
+	}
+	return iter.Error()
+}
+
+// chunkKey 解析后的区块数据键
+type chunkKey struct {
+	Dimension bwo_define.Dimension
+	Pos       bwo_define.ChunkPos
+	Tag       byte
+	SubChunk  int8 // 仅 Tag 为子区块数据时有效
+}
+
+// chunkKeyTags 区块数据键可能使用的标签
+var chunkKeyTags = map[byte]bool{
+	world_define.KeySubChunkData:   true,
+	world_define.KeyVersion:        true,
+	world_define.KeyVersionOld:     true,
+	world_define.KeyBlockEntities:  true,
+	world_define.KeyEntities:       true,
+	world_define.KeyFinalisation:   true,
+	world_define.Key3DData:         true,
+	world_define.Key2DData:         true,
+	world_define.KeyChecksums:      true,
+	world_define.KeyChunkTimeStamp: true,
+}
+
+// parseChunkKey 解析区块数据键: x(4) z(4) [维度(4)] 标签(1) [子区块序号(1)]
+func parseChunkKey(key []byte) (chunkKey, bool) {
+	var k chunkKey
+	var tagIndex int
+	switch len(key) {
+	case 9, 10:
+		tagIndex = 8
+		k.Dimension = bwo_define.DimensionIDOverworld
+	case 13, 14:
+		tagIndex = 12
+		dim := int32(binary.LittleEndian.Uint32(key[8:12]))
+		if dim != bwo_define.DimensionIDNether && dim != bwo_define.DimensionIDEnd {
+			return k, false
+		}
+		k.Dimension = bwo_define.Dimension(dim)
+	default:
+		return k, false
+	}
+
+	k.Tag = key[tagIndex]
+	if !chunkKeyTags[k.Tag] {
+		return k, false
+	}
+	hasSubChunk := len(key) == tagIndex+2
+	if hasSubChunk != (k.Tag == world_define.KeySubChunkData) {
+		return k, false
+	}
+	if hasSubChunk {
+		k.SubChunk = int8(key[tagIndex+1])
+	}
+	k.Pos = bwo_define.ChunkPos{
+		int32(binary.LittleEndian.Uint32(key[0:4])),
+		int32(binary.LittleEndian.Uint32(key[4:8])),
+	}
+	return k, true
+}
+
+// parseDigpKey 解析实体索引键 digp + x(4) z(4) [维度(4)]
+func parseDigpKey(key []byte) (bwo_define.Dimension, bwo_define.ChunkPos, bool) {
+	prefix := []byte(world_define.KeyEntityIdentifiers)
+	if !bytes.HasPrefix(key, prefix) {
+		return 0, bwo_define.ChunkPos{}, false
+	}
+	index := key[len(prefix):]
+	dim := bwo_define.Dimension(bwo_define.DimensionIDOverworld)
+	switch len(index) {
+	case 8:
+	case 12:
+		dim = bwo_define.Dimension(int32(binary.LittleEndian.Uint32(index[8:12])))
+	default:
+		return 0, bwo_define.ChunkPos{}, false
+	}
+	pos := bwo_define.ChunkPos{
+		int32(binary.LittleEndian.Uint32(index[0:4])),
+		int32(binary.LittleEndian.Uint32(index[4:8])),
+	}
+	return dim, pos, true
+}
+
+// decodeNBTList 解码首尾相接的多个小端 NBT 复合标签（方块实体、旧版实体均以此格式保存）
+func decodeNBTList(data []byte) ([]map[string]any, error) {
+	r := bytes.NewReader(data)
+	dec := nbt.NewDecoderWithEncoding(r, nbt.LittleEndian)
+	var list []map[string]any
+	for r.Len() > 0 {
+		var m map[string]any
+		if err := dec.Decode(&m); err != nil {
+			return list, err
+		}
+		list = append(list, m)
+	}
+	return list, nil
+}
+
+// worldDimensions 所有维度
+var worldDimensions = []bwo_define.Dimension{
+	bwo_define.DimensionIDOverworld,
+	bwo_define.DimensionIDNether,
+	bwo_define.DimensionIDEnd,
+}
+
+// dimensionName 维度的中文名称
+func dimensionName(dim bwo_define.Dimension) string {
+	switch dim {
+	case bwo_define.DimensionIDOverworld:
+		return "主世界"
+	case bwo_define.DimensionIDNether:
+		return "下界"
+	case bwo_define.DimensionIDEnd:
+		return "末地"
+	default:
+		return fmt.Sprintf("未知维度(%d)", int32(dim))
+	}
+}
+
+// readWorldPath 以只读方式处理世界目录、db 目录或 .mcworld 文件
+// .mcworld 只解压 db 和 level.dat 等必要文件，交互模式下同一会话内会复用解压结果，原文件不会被修改
+func readWorldPath(worldPath string, fn func(worldDir string) error) error {
+	info, err := os.Stat(worldPath)
+	if err != nil {
+		return fmt.Errorf("无法访问路径: %w", err)
+	}
+	if info.IsDir() {
+		worldDir := worldPath
+		if filepath.Base(worldPath) == "db" {
+			worldDir = filepath.Dir(worldPath)
+		}
+		return fn(worldDir)
+	}
+
+	worldDir, release, err := extractMCWorldForRead(worldPath)
+	if err != nil {
+		return fmt.Errorf("无法解压世界文件: %w", err)
+	}
+	defer release()
+	return fn(worldDir)
+}
+
+// listWorldChunks 列出指定维度中所有存在的区块
+func listWorldChunks(db *worldDB, dim bwo_define.Dimension) ([]bwo_define.ChunkPos, error) {
+	var chunks []bwo_define.ChunkPos
+	err := db.forEach(nil, func(key, value []byte) error {
+		k, ok := parseChunkKey(key)
+		if ok && k.Dimension == dim && (k.Tag == world_define.KeyVersion || k.Tag == world_define.KeyVersionOld) {
+			chunks = append(chunks, k.Pos)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("遍历数据库失败: %w", err)
+	}
+	return chunks, nil
+}
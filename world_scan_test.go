@@ -0,0 +1,51 @@
+package main
+
+import (
+	"encoding/binary"
+	"testing"
+
+	bwo_define "github.com/TriM-Organization/bedrock-world-operator/define"
+	world_define "github.com/TriM-Organization/bedrock-world-operator/world/define"
+)
+
+// testChunkKey 生成区块数据键，dim 为主世界时不写入维度
+func testChunkKey(x, z int32, dim bwo_define.Dimension, tag byte, extra ...byte) []byte {
+	key := binary.LittleEndian.AppendUint32(nil, uint32(x))
+	key = binary.LittleEndian.AppendUint32(key, uint32(z))
+	if dim != bwo_define.DimensionIDOverworld {
+		key = binary.LittleEndian.AppendUint32(key, uint32(dim))
+	}
+	key = append(key, tag)
+	return append(key, extra...)
+}
+
+func TestParseChunkKey(t *testing.T) {
+	cases := []struct {
+		name string
+		key  []byte
+		ok   bool
+		want chunkKey
+	}{
+		{"主世界子区块", testChunkKey(3, -2, bwo_define.DimensionIDOverworld, world_define.KeySubChunkData, 0xfc), true,
+			chunkKey{Dimension: bwo_define.DimensionIDOverworld, Pos: bwo_define.ChunkPos{3, -2}, Tag: world_define.KeySubChunkData, SubChunk: -4}},
+		{"下界版本", testChunkKey(-1, 7, bwo_define.DimensionIDNether, world_define.KeyVersion), true,
+			chunkKey{Dimension: bwo_define.DimensionIDNether, Pos: bwo_define.ChunkPos{-1, 7}, Tag: world_define.KeyVersion}},
+		{"末地方块实体", testChunkKey(0, 0, bwo_define.DimensionIDEnd, world_define.KeyBlockEntities), true,
+			chunkKey{Dimension: bwo_define.DimensionIDEnd, Tag: world_define.KeyBlockEntities}},
+		{"未知维度", testChunkKey(0, 0, 5, world_define.KeyVersion), false, chunkKey{}},
+		{"未知标签", testChunkKey(0, 0, bwo_define.DimensionIDOverworld, 0x01), false, chunkKey{}},
+		{"子区块缺少序号", testChunkKey(0, 0, bwo_define.DimensionIDOverworld, world_define.KeySubChunkData), false, chunkKey{}},
+		{"非子区块带序号", testChunkKey(0, 0, bwo_define.DimensionIDOverworld, world_define.KeyVersion, 0), false, chunkKey{}},
+		{"其他键", []byte("~local_player"), false, chunkKey{}},
+	}
+	for _, c := range cases {
+		got, ok := parseChunkKey(c.key)
+		if ok != c.ok {
+			t.Errorf("%s: ok = %v，应为 %v", c.name, ok, c.ok)
+			continue
+		}
+		if ok && got != c.want {
+			t.Errorf("%s: 得到 %+v，应为 %+v", c.name, got, c.want)
+		}
+	}
+}